@@ -0,0 +1,264 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Run queries from stdin against one language server",
+	Long: `Run queries read from stdin as JSON Lines against a single language
+server session, writing one JSON line per query as each completes.
+
+Each request names a command, its symbol or other arguments, and flags:
+
+  {"id": 1, "command": "callers", "symbol": "config.Load", "flags": {"depth": 2}}
+  {"id": 2, "command": "path", "args": ["main.main", "db.Query"]}
+  {"id": 3, "command": "unused", "flags": {"entry": ["*Handler"]}}
+
+Each response echoes the request's id, or its line number when it has
+none, and holds either the command's usual JSON output or its error:
+
+  {"id": 1, "command": "callers", "response": {...}}
+  {"id": 2, "command": "path", "error": {"code": "symbol_not_found", ...}}
+
+A failing request does not stop the rest. --timeout applies to each
+request. Flags that configure the session, such as --language or
+--lsp-replay, apply to the whole batch and cannot be set per request.
+
+Commands: callers, callees, refs, tree, cycles, path, graph, unused,
+impact, implements, satisfies, deps.
+
+Examples:
+  wildcat batch < queries.jsonl
+  echo '{"command":"refs","symbol":"Config"}' | wildcat batch`,
+	Args: cobra.NoArgs,
+	RunE: runBatch,
+}
+
+// batchCommands are the commands a batch request may run.
+var batchCommands = []string{
+	"callers", "callees", "refs", "tree", "cycles", "path", "graph",
+	"unused", "impact", "implements", "satisfies", "deps",
+}
+
+// batchSessionFlags configure the shared session, so requests cannot set them.
+var batchSessionFlags = []string{
+	"config", "output", "language", "no-daemon", "ready-timeout",
+	"call-timeout", "retries", "retry-backoff", "lsp-record", "lsp-replay",
+}
+
+// batchRequest is one line of the batch command's input.
+type batchRequest struct {
+	ID      any            `json:"id"`
+	Command string         `json:"command"`
+	Symbol  string         `json:"symbol"`
+	Args    []string       `json:"args"`
+	Flags   map[string]any `json:"flags"`
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+}
+
+func runBatch(cmd *cobra.Command, args []string) error {
+	if globalOutput != "" && globalOutput != "json" {
+		return output.NewWriter(cmd.OutOrStdout(), false).WriteError("invalid_argument", "batch always writes JSON Lines; omit --output", nil, nil)
+	}
+	enc := json.NewEncoder(cmd.OutOrStdout())
+
+	// Get working directory
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	// The session outlives any one request, so only its startup is bounded
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startCtx, startCancel := context.WithTimeout(ctx, globalTimeout)
+	defer startCancel()
+
+	client, werr := startClient(startCtx, workDir)
+	if werr != nil {
+		// No request can run, so the batch fails as a whole
+		if err := enc.Encode(output.BatchResponse{Error: errorDetail(werr)}); err != nil {
+			return err
+		}
+		return werr
+	}
+	defer client.Stop()
+
+	batchClient = client
+	defer func() { batchClient = nil }()
+
+	return serveBatch(cmd.InOrStdin(), enc)
+}
+
+// serveBatch runs each request read from r against batchClient, encoding
+// one response per request.
+func serveBatch(r io.Reader, enc *json.Encoder) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if err := enc.Encode(runBatchRequest(text, line)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// runBatchRequest runs the request on one input line and returns its
+// response. Failures, including panics, are reported in the response.
+func runBatchRequest(text []byte, line int) (resp output.BatchResponse) {
+	// Deferred first, so it runs after the flags are restored
+	defer func() {
+		if r := recover(); r != nil {
+			if resp.ID == nil {
+				resp.ID = line
+			}
+			resp.Response = nil
+			resp.Error = &output.ErrorDetail{
+				Code:    "internal_error",
+				Message: fmt.Sprintf("%s panicked: %v", resp.Command, r),
+			}
+		}
+	}()
+
+	var req batchRequest
+	dec := json.NewDecoder(bytes.NewReader(text))
+	dec.UseNumber()
+	if err := dec.Decode(&req); err != nil {
+		return output.BatchResponse{ID: line, Error: &output.ErrorDetail{
+			Code:    string(errors.CodeParseError),
+			Message: fmt.Sprintf("invalid request on line %d: %v", line, err),
+		}}
+	}
+
+	resp = output.BatchResponse{ID: req.ID, Command: req.Command}
+	if resp.ID == nil {
+		resp.ID = line
+	}
+	fail := func(code, message string) output.BatchResponse {
+		resp.Error = &output.ErrorDetail{Code: code, Message: message}
+		return resp
+	}
+
+	if !slices.Contains(batchCommands, req.Command) {
+		return fail("invalid_argument", fmt.Sprintf("unknown batch command %q", req.Command))
+	}
+	sub, _, err := rootCmd.Find([]string{req.Command})
+	if err != nil {
+		return fail("invalid_argument", err.Error())
+	}
+
+	args := req.Args
+	if req.Symbol != "" {
+		args = append([]string{req.Symbol}, args...)
+	}
+	if err := sub.ValidateArgs(args); err != nil {
+		return fail("invalid_argument", err.Error())
+	}
+
+	// Flags are the command's globals: set them for this request only
+	sub.InheritedFlags() // merges the persistent flags into sub.Flags()
+	for name, value := range req.Flags {
+		f := sub.Flags().Lookup(name)
+		if f == nil {
+			return fail("invalid_argument", fmt.Sprintf("unknown flag --%s for %s", name, req.Command))
+		}
+		if slices.Contains(batchSessionFlags, name) {
+			return fail("invalid_argument", fmt.Sprintf("--%s applies to the whole batch", name))
+		}
+		defer restoreFlag(f)()
+		if err := setFlag(f, value); err != nil {
+			return fail("invalid_argument", fmt.Sprintf("invalid value for --%s: %v", name, err))
+		}
+	}
+
+	var buf bytes.Buffer
+	sub.SetOut(&buf)
+	defer sub.SetOut(nil)
+	if err := sub.RunE(sub, args); err != nil {
+		return fail("internal_error", err.Error())
+	}
+
+	// Commands write either their response or an error response
+	var reply output.ErrorResponse
+	if err := json.Unmarshal(buf.Bytes(), &reply); err == nil && reply.Error.Code != "" {
+		resp.Error = &reply.Error
+		return resp
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, buf.Bytes()); err != nil {
+		return fail("internal_error", fmt.Sprintf("invalid response: %v", err))
+	}
+	resp.Response = compact.Bytes()
+	return resp
+}
+
+// setFlag sets a flag from a JSON value. A list sets each element of a
+// repeatable flag.
+func setFlag(f *pflag.Flag, value any) error {
+	if list, ok := value.([]any); ok {
+		sv, ok := f.Value.(pflag.SliceValue)
+		if !ok {
+			return fmt.Errorf("--%s takes a single value", f.Name)
+		}
+		values := make([]string, len(list))
+		for i, v := range list {
+			values[i] = fmt.Sprint(v)
+		}
+		f.Changed = true
+		return sv.Replace(values)
+	}
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		f.Changed = true
+		return sv.Replace([]string{fmt.Sprint(value)})
+	}
+	f.Changed = true
+	return f.Value.Set(fmt.Sprint(value))
+}
+
+// restoreFlag records a flag's value and returns a function restoring it.
+func restoreFlag(f *pflag.Flag) func() {
+	changed := f.Changed
+	if sv, ok := f.Value.(pflag.SliceValue); ok {
+		old := sv.GetSlice()
+		return func() {
+			sv.Replace(old)
+			f.Changed = changed
+		}
+	}
+	old := f.Value.String()
+	return func() {
+		f.Value.Set(old)
+		f.Changed = changed
+	}
+}
+
+// errorDetail converts a structured error for a response.
+func errorDetail(we *errors.WildcatError) *output.ErrorDetail {
+	return &output.ErrorDetail{
+		Code:        string(we.Code),
+		Message:     we.Message,
+		Suggestions: we.Suggestions,
+		Context:     we.Context,
+	}
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// startBatch serves batch requests from a fake server for the test.
+func startBatch(t *testing.T) *lsptest.Server {
+	t.Helper()
+	srv := lsptest.NewServer(t.TempDir())
+	main := srv.Func("main", "main", "main.go", 3)
+	load := srv.Func("config", "Load", "config/config.go", 10)
+	srv.Func("config", "Parse", "config/config.go", 20)
+	srv.Call(main, load, 4)
+
+	batchClient = srv.Client(t)
+	t.Cleanup(func() { batchClient = nil })
+	return srv
+}
+
+// runLines runs the batch input and decodes its responses.
+func runLines(t *testing.T, input string) []output.BatchResponse {
+	t.Helper()
+	var out bytes.Buffer
+	if err := serveBatch(strings.NewReader(input), json.NewEncoder(&out)); err != nil {
+		t.Fatalf("serveBatch: %v", err)
+	}
+	var resps []output.BatchResponse
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var resp output.BatchResponse
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatalf("decoding response: %v", err)
+		}
+		resps = append(resps, resp)
+	}
+	return resps
+}
+
+func TestServeBatch_FailuresDoNotStopTheRest(t *testing.T) {
+	startBatch(t)
+
+	resps := runLines(t, `{"id": "a", "command": "callers", "symbol": "Load"}
+not json
+{"id": "b", "command": "rename", "symbol": "Load"}
+{"id": "c", "command": "callers", "symbol": "Missing"}
+
+{"command": "callers", "symbol": "Load", "flags": {"nope": 1}}
+{"id": "d", "command": "callers", "symbol": "Load"}
+`)
+
+	want := []struct {
+		id   any
+		code string
+	}{
+		{id: "a"},
+		{id: float64(2), code: "parse_error"},
+		{id: "b", code: "invalid_argument"},
+		{id: "c", code: "symbol_not_found"},
+		{id: float64(6), code: "invalid_argument"},
+		{id: "d"},
+	}
+	if len(resps) != len(want) {
+		t.Fatalf("got %d responses, want %d: %+v", len(resps), len(want), resps)
+	}
+	for i, w := range want {
+		resp := resps[i]
+		if resp.ID != w.id {
+			t.Errorf("response %d: id = %v, want %v", i, resp.ID, w.id)
+		}
+		switch {
+		case w.code == "" && resp.Error != nil:
+			t.Errorf("response %d: unexpected error %+v", i, resp.Error)
+		case w.code == "" && len(resp.Response) == 0:
+			t.Errorf("response %d: no response", i)
+		case w.code != "" && (resp.Error == nil || resp.Error.Code != w.code):
+			t.Errorf("response %d: error = %+v, want code %s", i, resp.Error, w.code)
+		}
+	}
+
+	var callers output.CallersResponse
+	if err := json.Unmarshal(resps[5].Response, &callers); err != nil {
+		t.Fatal(err)
+	}
+	if callers.Summary.Count != 1 || callers.Results[0].Symbol != "main" {
+		t.Errorf("callers = %+v, want main", callers.Results)
+	}
+}
+
+func TestRunBatchRequest_RestoresFlags(t *testing.T) {
+	startBatch(t)
+
+	resp := runBatchRequest([]byte(`{"command": "unused", "flags": {"entry": ["Load", "Parse"], "exported": true}}`), 1)
+	if resp.Error != nil {
+		t.Fatalf("unused: %+v", resp.Error)
+	}
+	resp = runBatchRequest([]byte(`{"command": "callers", "symbol": "Load", "flags": {"depth": 3, "compact": true}}`), 2)
+	if resp.Error != nil {
+		t.Fatalf("callers: %+v", resp.Error)
+	}
+
+	if len(unusedEntries) != 0 || unusedExported {
+		t.Errorf("unused flags = %v, %v after request, want defaults", unusedEntries, unusedExported)
+	}
+	if callersDepth != 1 || callersCompact {
+		t.Errorf("callers flags = %d, %v after request, want defaults", callersDepth, callersCompact)
+	}
+	for _, sub := range []*cobra.Command{unusedCmd, callersCmd} {
+		for _, name := range []string{"entry", "exported", "depth", "compact"} {
+			if f := sub.Flags().Lookup(name); f != nil && f.Changed {
+				t.Errorf("%s --%s still marked changed", sub.Name(), name)
+			}
+		}
+	}
+
+	// A flag set by one request is not seen by the next
+	resp = runBatchRequest([]byte(`{"command": "unused", "flags": {"entry": ["main"]}}`), 3)
+	if resp.Error != nil {
+		t.Fatalf("unused: %+v", resp.Error)
+	}
+	if len(unusedEntries) != 0 {
+		t.Errorf("--entry = %v after request, want empty", unusedEntries)
+	}
+}
+
+func TestRunBatchRequest_Panic(t *testing.T) {
+	startBatch(t)
+
+	runE := callersCmd.RunE
+	callersCmd.RunE = func(*cobra.Command, []string) error {
+		panic("boom")
+	}
+	t.Cleanup(func() { callersCmd.RunE = runE })
+
+	resps := runLines(t, `{"id": 1, "command": "callers", "symbol": "Load", "flags": {"depth": 4}}
+{"id": 2, "command": "refs", "symbol": "Load"}
+`)
+	if len(resps) != 2 {
+		t.Fatalf("got %d responses, want 2", len(resps))
+	}
+	if resps[0].Error == nil || resps[0].Error.Code != "internal_error" || !strings.Contains(resps[0].Error.Message, "boom") {
+		t.Errorf("panicking request: error = %+v, want internal_error", resps[0].Error)
+	}
+	if resps[1].Error != nil {
+		t.Errorf("next request: unexpected error %+v", resps[1].Error)
+	}
+	if callersDepth != 1 || callersCmd.Flags().Lookup("depth").Changed {
+		t.Errorf("--depth = %d after panic, want it restored", callersDepth)
+	}
+}
+
+func TestRunBatch_StartFailure(t *testing.T) {
+	replay := globalLSPReplay
+	globalLSPReplay = filepath.Join(t.TempDir(), "missing.jsonl")
+	t.Cleanup(func() { globalLSPReplay = replay })
+
+	var out bytes.Buffer
+	batchCmd.SetOut(&out)
+	batchCmd.SetIn(strings.NewReader(`{"command": "callers", "symbol": "Load"}`))
+	t.Cleanup(func() {
+		batchCmd.SetOut(nil)
+		batchCmd.SetIn(nil)
+	})
+
+	if err := runBatch(batchCmd, nil); err == nil {
+		t.Error("runBatch succeeded, want the session's error")
+	}
+	var resp output.BatchResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Code != "server_not_found" {
+		t.Errorf("error = %+v, want server_not_found", resp.Error)
+	}
+}
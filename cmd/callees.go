@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
 	"github.com/jasonmoo/wildcat/internal/lsp"
 	"github.com/jasonmoo/wildcat/internal/output"
-	"github.com/jasonmoo/wildcat/internal/symbols"
 	"github.com/jasonmoo/wildcat/internal/traverse"
 	"github.com/spf13/cobra"
 )
@@ -25,21 +23,27 @@ Symbol formats:
   Type.Method           Method on type
   (*Type).Method        Method on pointer receiver
   path/to/pkg.Function  Full package path
+  file.go:42            Symbol enclosing a line
+  file.go:42:7          Symbol under the cursor (its definition)
+  file.go#Name          Symbol declared in a file
 
 Examples:
   wildcat callees main.main
-  wildcat callees Server.Start`,
+  wildcat callees Server.Start
+  wildcat callees main.main --depth 3 --group-by depth`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCallees,
 }
 
 var (
-	calleesExcludeTests  bool
-	calleesExcludeStdlib bool
-	calleesLimit         int
-	calleesContext       int
-	calleesCompact       bool
-	calleesDepth         int
+	calleesExcludeTests     bool
+	calleesExcludeStdlib    bool
+	calleesLimit            int
+	calleesContext          int
+	calleesCompact          bool
+	calleesDepth            int
+	calleesExpandInterfaces bool
+	calleesGroupBy          string
 )
 
 func init() {
@@ -51,66 +55,55 @@ func init() {
 	calleesCmd.Flags().IntVar(&calleesContext, "context", 3, "Lines of context in snippet")
 	calleesCmd.Flags().BoolVar(&calleesCompact, "compact", false, "Omit snippets")
 	calleesCmd.Flags().IntVar(&calleesDepth, "depth", 1, "Depth of callee traversal (1 = direct only)")
+	calleesCmd.Flags().BoolVar(&calleesExpandInterfaces, "expand-interfaces", false, "Follow calls through interface methods to their implementations")
+	calleesCmd.Flags().StringVar(&calleesGroupBy, "group-by", "", "Group results: depth")
 }
 
 func runCallees(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
 
+	if calleesGroupBy != "" && calleesGroupBy != "depth" {
+		return writer.WriteError("invalid_argument", "group-by must be 'depth'", nil, nil)
+	}
+
 	// Get working directory
 	workDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
+	defer release()
 
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
+	if werr := requireCapability(client, "callHierarchy/outgoingCalls"); werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Close()
 
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
+	if calleesExpandInterfaces {
+		if werr := requireCapability(client, "textDocument/implementation"); werr != nil {
+			return writeError(writer, werr)
+		}
 	}
-	defer client.Shutdown(ctx)
-
-	time.Sleep(200 * time.Millisecond)
 
 	// Resolve symbol
-	resolver := symbols.NewResolver(client)
+	resolver := newResolver(client)
 	resolved, err := resolver.Resolve(ctx, query)
 	if err != nil {
 		if we, ok := err.(*errors.WildcatError); ok {
@@ -122,12 +115,7 @@ func runCallees(cmd *cobra.Command, args []string) error {
 	// Prepare call hierarchy
 	items, err := client.PrepareCallHierarchy(ctx, resolved.URI, resolved.Position)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to prepare call hierarchy: %v", err),
-			nil,
-			nil,
-		)
+		return writeError(writer, lspError(err, "Failed to prepare call hierarchy"))
 	}
 
 	if len(items) == 0 {
@@ -142,38 +130,45 @@ func runCallees(cmd *cobra.Command, args []string) error {
 	// Get callees
 	traverser := traverse.NewTraverser(client)
 	opts := traverse.Options{
-		Direction:     traverse.Down,
-		MaxDepth:      calleesDepth,
-		ExcludeTests:  calleesExcludeTests,
-		ExcludeStdlib: calleesExcludeStdlib,
+		Direction:        traverse.Down,
+		MaxDepth:         calleesDepth,
+		ExcludeTests:     calleesExcludeTests,
+		ExcludeStdlib:    calleesExcludeStdlib,
+		Concurrency:      globalConcurrency,
+		MaxNodes:         globalMaxNodes,
+		MaxEdges:         globalMaxEdges,
+		Timeout:          globalMaxTime,
+		ExpandInterfaces: calleesExpandInterfaces,
 	}
 
 	callees, err := traverser.GetCallees(ctx, items[0], opts)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to get callees: %v", err),
-			nil,
-			nil,
-		)
+		return writeError(writer, lspError(err, "Failed to get callees"))
 	}
 
 	// Build results
 	extractor := output.NewSnippetExtractor()
-	var results []output.Result
+	results := []output.Result{}
 	packagesSet := make(map[string]bool)
 	inTests := 0
 
-	for _, callee := range callees {
+	for _, callee := range callees.Calls {
 		if calleesLimit > 0 && len(results) >= calleesLimit {
 			break
 		}
 
 		result := output.Result{
-			Symbol: callee.Symbol,
-			File:   output.AbsolutePath(callee.File),
-			Line:   callee.Line,
-			InTest: callee.InTest,
+			Symbol:    callee.Symbol,
+			File:      output.AbsolutePath(callee.File),
+			Line:      callee.Line,
+			InTest:    callee.InTest,
+			Depth:     callee.Depth,
+			Dynamic:   callee.Dynamic,
+			Truncated: callee.Truncated,
+			Error:     callee.Error,
+		}
+		if calleesDepth != 1 {
+			result.Chain = callee.Chain
 		}
 
 		if !calleesCompact && len(callee.CallRanges) > 0 {
@@ -210,11 +205,17 @@ func runCallees(cmd *cobra.Command, args []string) error {
 		},
 		Results: results,
 		Summary: output.Summary{
-			Count:     len(results),
-			Packages:  packages,
-			InTests:   inTests,
-			Truncated: calleesLimit > 0 && len(callees) > calleesLimit,
+			Count:      len(results),
+			Packages:   packages,
+			InTests:    inTests,
+			Truncated:  callees.Stopped != "" || (calleesLimit > 0 && len(callees.Calls) > calleesLimit),
+			StopReason: callees.Stopped,
 		},
+		Meta: responseMeta(client),
+	}
+	if calleesGroupBy == "depth" {
+		response.Groups = output.GroupByDepth(results)
+		response.Results = []output.Result{}
 	}
 
 	return writer.Write(response)
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
 	"github.com/jasonmoo/wildcat/internal/lsp"
@@ -25,22 +24,33 @@ Symbol formats:
   Type.Method           Method on type
   (*Type).Method        Method on pointer receiver
   path/to/pkg.Function  Full package path
+  file.go:42            Symbol enclosing a line
+  file.go:42:7          Symbol under the cursor (its definition)
+  file.go#Name          Symbol declared in a file
+  Server.*, *Handler    Glob: every matching symbol
+  re:^Load.*$           Regular expression over symbol names
+
+A pattern reports one section per matching symbol, with a combined summary.
 
 Examples:
   wildcat callers config.Load
   wildcat callers Server.Start
-  wildcat callers (*Handler).ServeHTTP`,
+  wildcat callers (*Handler).ServeHTTP
+  wildcat callers 'Server.*'
+  wildcat callers config.Load --depth 3 --group-by depth`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCallers,
 }
 
 var (
-	callersExcludeTests bool
-	callersPackage      string
-	callersLimit        int
-	callersContext      int
-	callersCompact      bool
-	callersDepth        int
+	callersExcludeTests     bool
+	callersPackage          string
+	callersLimit            int
+	callersContext          int
+	callersCompact          bool
+	callersDepth            int
+	callersExpandInterfaces bool
+	callersGroupBy          string
 )
 
 func init() {
@@ -52,69 +62,82 @@ func init() {
 	callersCmd.Flags().IntVar(&callersContext, "context", 3, "Lines of context in snippet")
 	callersCmd.Flags().BoolVar(&callersCompact, "compact", false, "Omit snippets")
 	callersCmd.Flags().IntVar(&callersDepth, "depth", 1, "Depth of caller traversal (1 = direct only)")
+	callersCmd.Flags().BoolVar(&callersExpandInterfaces, "expand-interfaces", false, "Follow calls through interface methods to their implementations")
+	callersCmd.Flags().StringVar(&callersGroupBy, "group-by", "", "Group results: depth")
 }
 
 func runCallers(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
 
+	if callersGroupBy != "" && callersGroupBy != "depth" {
+		return writer.WriteError("invalid_argument", "group-by must be 'depth'", nil, nil)
+	}
+
 	// Get working directory
 	workDir, err := os.Getwd()
 	if err != nil {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	// Get language server configuration
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
+	defer release()
 
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
+	if werr := requireCapability(client, "callHierarchy/incomingCalls"); werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Close()
 
-	// Initialize
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
+	if callersExpandInterfaces {
+		if werr := requireCapability(client, "textDocument/implementation"); werr != nil {
+			return writeError(writer, werr)
+		}
 	}
-	defer client.Shutdown(ctx)
-
-	// Give LSP server time to index
-	time.Sleep(200 * time.Millisecond)
 
 	// Resolve symbol
-	resolver := symbols.NewResolver(client)
+	resolver := newResolver(client)
+	if query.IsPattern() {
+		targets, capped, werr := resolveTargets(ctx, resolver, query)
+		if werr != nil {
+			return writeError(writer, werr)
+		}
+
+		response := output.MultiCallersResponse{
+			Query: output.QueryInfo{
+				Command: "callers",
+				Target:  query.Raw,
+			},
+			Targets: []output.CallersResponse{},
+			Meta:    responseMeta(client),
+		}
+		response.Summary.SearchCapped = capped
+		for _, target := range targets {
+			section, werr := findCallers(ctx, client, query, &target)
+			if werr != nil {
+				response.Summary.Errors = append(response.Summary.Errors, fmt.Sprintf("%s: %s", target.Name, werr.Message))
+				continue
+			}
+			response.Targets = append(response.Targets, *section)
+			response.Summary.Add(section.Summary)
+		}
+		return writer.Write(response)
+	}
+
 	resolved, err := resolver.Resolve(ctx, query)
 	if err != nil {
 		if we, ok := err.(*errors.WildcatError); ok {
@@ -123,51 +146,55 @@ func runCallers(cmd *cobra.Command, args []string) error {
 		return writer.WriteError(string(errors.CodeSymbolNotFound), err.Error(), nil, nil)
 	}
 
+	response, werr := findCallers(ctx, client, query, resolved)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	response.Meta = responseMeta(client)
+
+	return writer.Write(response)
+}
+
+// findCallers builds the callers response for one resolved target of query.
+func findCallers(ctx context.Context, client *lsp.Client, query *symbols.Query, resolved *symbols.ResolvedSymbol) (*output.CallersResponse, *errors.WildcatError) {
 	// Prepare call hierarchy
 	items, err := client.PrepareCallHierarchy(ctx, resolved.URI, resolved.Position)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to prepare call hierarchy: %v", err),
-			nil,
-			nil,
-		)
+		return nil, lspError(err, "Failed to prepare call hierarchy")
 	}
 
 	if len(items) == 0 {
-		return writer.WriteError(
-			string(errors.CodeSymbolNotFound),
-			fmt.Sprintf("No call hierarchy found for '%s'", query.Raw),
-			nil,
-			nil,
-		)
+		return nil, &errors.WildcatError{
+			Code:    errors.CodeSymbolNotFound,
+			Message: fmt.Sprintf("No call hierarchy found for '%s'", resolved.Name),
+		}
 	}
 
 	// Get callers
 	traverser := traverse.NewTraverser(client)
 	opts := traverse.Options{
-		Direction:    traverse.Up,
-		MaxDepth:     callersDepth,
-		ExcludeTests: callersExcludeTests,
+		Direction:        traverse.Up,
+		MaxDepth:         callersDepth,
+		ExcludeTests:     callersExcludeTests,
+		Concurrency:      globalConcurrency,
+		MaxNodes:         globalMaxNodes,
+		MaxEdges:         globalMaxEdges,
+		Timeout:          globalMaxTime,
+		ExpandInterfaces: callersExpandInterfaces,
 	}
 
 	callers, err := traverser.GetCallers(ctx, items[0], opts)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to get callers: %v", err),
-			nil,
-			nil,
-		)
+		return nil, lspError(err, "Failed to get callers")
 	}
 
 	// Build results
 	extractor := output.NewSnippetExtractor()
-	var results []output.Result
+	results := []output.Result{}
 	packagesSet := make(map[string]bool)
 	inTests := 0
 
-	for _, caller := range callers {
+	for _, caller := range callers.Calls {
 		// Apply limit
 		if callersLimit > 0 && len(results) >= callersLimit {
 			break
@@ -179,10 +206,17 @@ func runCallers(cmd *cobra.Command, args []string) error {
 		}
 
 		result := output.Result{
-			Symbol: caller.Symbol,
-			File:   output.AbsolutePath(caller.File),
-			Line:   caller.Line,
-			InTest: caller.InTest,
+			Symbol:    caller.Symbol,
+			File:      output.AbsolutePath(caller.File),
+			Line:      caller.Line,
+			InTest:    caller.InTest,
+			Depth:     caller.Depth,
+			Dynamic:   caller.Dynamic,
+			Truncated: caller.Truncated,
+			Error:     caller.Error,
+		}
+		if callersDepth != 1 {
+			result.Chain = caller.Chain
 		}
 
 		// Extract snippet if not compact
@@ -236,12 +270,33 @@ func runCallers(cmd *cobra.Command, args []string) error {
 		},
 		Results: results,
 		Summary: output.Summary{
-			Count:     len(results),
-			Packages:  packages,
-			InTests:   inTests,
-			Truncated: callersLimit > 0 && len(callers) > callersLimit,
+			Count:      len(results),
+			Packages:   packages,
+			InTests:    inTests,
+			Truncated:  callers.Stopped != "" || (callersLimit > 0 && len(callers.Calls) > callersLimit),
+			StopReason: callers.Stopped,
 		},
 	}
+	if callersGroupBy == "depth" {
+		response.Groups = output.GroupByDepth(results)
+		response.Results = []output.Result{}
+	}
 
-	return writer.Write(response)
+	return &response, nil
+}
+
+// resolveTargets resolves a pattern query to every symbol it matches,
+// and reports whether the server's search may have left some out.
+func resolveTargets(ctx context.Context, resolver *symbols.Resolver, query *symbols.Query) ([]symbols.ResolvedSymbol, bool, *errors.WildcatError) {
+	targets, capped, err := resolver.FindAll(ctx, query)
+	if err != nil {
+		if we, ok := err.(*errors.WildcatError); ok {
+			return nil, false, we
+		}
+		return nil, false, &errors.WildcatError{Code: errors.CodeSymbolNotFound, Message: err.Error()}
+	}
+	if len(targets) == 0 {
+		return nil, false, errors.NewSymbolNotFound(query.Raw, nil)
+	}
+	return targets, capped, nil
 }
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/daemon"
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/jasonmoo/wildcat/internal/servers"
+	"github.com/jasonmoo/wildcat/internal/traverse"
+)
+
+var (
+	globalTimeout      time.Duration
+	globalCallTimeout  time.Duration
+	globalNoDaemon     bool
+	globalReadyTimeout time.Duration
+	globalRetries      int
+	globalRetryBackoff time.Duration
+	globalLSPRecord    string
+	globalLSPReplay    string
+	globalConcurrency  int
+	globalMaxNodes     int
+	globalMaxEdges     int
+	globalMaxTime      time.Duration
+)
+
+func init() {
+	rootCmd.PersistentFlags().DurationVar(&globalTimeout, "timeout", 60*time.Second, "Maximum time for the whole command")
+	rootCmd.PersistentFlags().DurationVar(&globalCallTimeout, "call-timeout", 0, "Maximum time for any single LSP request (0 = bounded only by --timeout)")
+	rootCmd.PersistentFlags().BoolVar(&globalNoDaemon, "no-daemon", false, "Always start the language server in-process")
+	rootCmd.PersistentFlags().DurationVar(&globalReadyTimeout, "ready-timeout", 30*time.Second, "Maximum time to wait for the language server to finish indexing")
+	rootCmd.PersistentFlags().IntVar(&globalRetries, "retries", lsp.DefaultRetryPolicy.MaxRetries, "Retries for LSP requests that fail while the server is busy (0 = never retry)")
+	rootCmd.PersistentFlags().IntVar(&globalConcurrency, "concurrency", traverse.DefaultConcurrency, "Maximum concurrent LSP requests while traversing call graphs")
+	rootCmd.PersistentFlags().IntVar(&globalMaxNodes, "max-nodes", 0, "Stop traversing call graphs after expanding this many symbols and return partial results (0 = unlimited)")
+	rootCmd.PersistentFlags().IntVar(&globalMaxEdges, "max-edges", 0, "Stop traversing call graphs after finding this many calls and return partial results (0 = unlimited)")
+	rootCmd.PersistentFlags().DurationVar(&globalMaxTime, "max-time", 0, "Stop traversing call graphs after this long and return partial results (0 = bounded only by --timeout)")
+	rootCmd.PersistentFlags().StringVar(&globalLSPRecord, "lsp-record", "", "Record the LSP session to a transcript file (implies --no-daemon)")
+	rootCmd.PersistentFlags().StringVar(&globalLSPReplay, "lsp-replay", "", "Answer LSP requests from a recorded transcript instead of a language server")
+	rootCmd.PersistentFlags().DurationVar(&globalRetryBackoff, "retry-backoff", lsp.DefaultRetryPolicy.Backoff, "Delay before the first retry, doubled on each further retry")
+}
+
+// configureClient applies the request timeout and retry flags to client.
+func configureClient(client *lsp.Client) {
+	client.SetCallTimeout(globalCallTimeout)
+	client.SetRetryPolicy(lsp.RetryPolicy{
+		MaxRetries: globalRetries,
+		Backoff:    globalRetryBackoff,
+		MaxBackoff: lsp.DefaultRetryPolicy.MaxBackoff,
+	})
+}
+
+// batchClient, when set, is the session commands run against instead of
+// starting their own. See batch.
+var batchClient *lsp.Client
+
+// acquireClient returns the client a command should query and a function
+// that releases it when the command is done.
+func acquireClient(ctx context.Context, workDir string) (*lsp.Client, func(), *errors.WildcatError) {
+	if batchClient != nil {
+		return batchClient, func() {}, nil
+	}
+	client, werr := startClient(ctx, workDir)
+	if werr != nil {
+		return nil, nil, werr
+	}
+	return client, client.Stop, nil
+}
+
+// startClient attaches to a running daemon for the workspace containing
+// workDir, or starts the language server in-process when none is running,
+// completes the initialize handshake and waits for indexing to finish.
+func startClient(ctx context.Context, workDir string) (*lsp.Client, *errors.WildcatError) {
+	spec, err := GetServerSpec()
+	if err != nil {
+		return nil, &errors.WildcatError{Code: errors.CodeServerNotFound, Message: err.Error()}
+	}
+
+	// Serve the whole workspace, so commands run from any directory in it
+	// share one daemon
+	root := spec.Root(workDir)
+
+	if globalLSPReplay != "" {
+		return startReplayClient(ctx, root)
+	}
+
+	// Recording needs the real server's side of the handshake, which the
+	// daemon answers itself
+	if !globalNoDaemon && globalLSPRecord == "" {
+		if client, err := daemon.Connect(root, spec.Language); err == nil {
+			configureClient(client)
+			if err := client.Initialize(ctx); err == nil {
+				if werr := waitReady(ctx, client); werr != nil {
+					client.Close()
+					return nil, werr
+				}
+				return client, nil
+			}
+			// Daemon is wedged or shutting down; fall back to in-process
+			client.Close()
+		}
+	}
+
+	config, err := GetServerConfig(root)
+	if err != nil {
+		return nil, &errors.WildcatError{Code: errors.CodeServerNotFound, Message: err.Error()}
+	}
+
+	client, err := lsp.NewClient(ctx, config)
+	if err != nil {
+		return nil, &errors.WildcatError{
+			Code:    errors.CodeServerNotFound,
+			Message: fmt.Sprintf("Failed to start language server: %v", err),
+			Context: map[string]any{"server": config.Command},
+		}
+	}
+
+	return initClient(ctx, client, root)
+}
+
+// startReplayClient serves the session from the --lsp-replay transcript.
+func startReplayClient(ctx context.Context, workDir string) (*lsp.Client, *errors.WildcatError) {
+	client, err := lsp.NewClient(ctx, lsp.ServerConfig{WorkDir: workDir, Replay: globalLSPReplay})
+	if err != nil {
+		return nil, &errors.WildcatError{
+			Code:    errors.CodeServerNotFound,
+			Message: fmt.Sprintf("Failed to load LSP transcript: %v", err),
+			Context: map[string]any{"transcript": globalLSPReplay},
+		}
+	}
+	return initClient(ctx, client, workDir)
+}
+
+// initClient configures a freshly started client, starts recording if
+// requested and completes the handshake.
+func initClient(ctx context.Context, client *lsp.Client, workDir string) (*lsp.Client, *errors.WildcatError) {
+	configureClient(client)
+
+	if globalLSPRecord != "" {
+		rec, err := lsp.CreateRecorder(globalLSPRecord, workDir)
+		if err != nil {
+			client.Close()
+			return nil, &errors.WildcatError{Code: errors.CodeLSPError, Message: err.Error()}
+		}
+		client.Conn().Record(rec)
+	}
+
+	if err := client.Initialize(ctx); err != nil {
+		client.Close()
+		return nil, lspError(err, "LSP initialization failed")
+	}
+
+	if werr := waitReady(ctx, client); werr != nil {
+		client.Close()
+		return nil, werr
+	}
+
+	return client, nil
+}
+
+// waitReady waits for the server behind client to finish indexing.
+func waitReady(ctx context.Context, client *lsp.Client) *errors.WildcatError {
+	if _, err := client.WaitReady(ctx, lsp.ReadyOptions{Timeout: globalReadyTimeout}); err != nil {
+		return errors.NewTimeout("waiting for language server to index")
+	}
+	return nil
+}
+
+// lspError converts a failed LSP request into a structured error. Timeouts
+// keep their own code so agents can tell a slow server from a broken one.
+func lspError(err error, message string) *errors.WildcatError {
+	if we, ok := errors.FromError(err); ok {
+		return we
+	}
+	return &errors.WildcatError{
+		Code:    errors.CodeLSPError,
+		Message: fmt.Sprintf("%s: %v", message, err),
+	}
+}
+
+// responseMeta describes the session behind a response.
+func responseMeta(client *lsp.Client) *output.Meta {
+	r := client.Readiness()
+	meta := &output.Meta{
+		IndexMS:  r.Duration.Milliseconds(),
+		ReadyVia: r.Via,
+		Retries:  client.Retries(),
+		Emulated: client.Emulated(),
+	}
+	if spec, err := GetServerSpec(); err == nil {
+		meta.Unsupported = spec.Missing(client.Capabilities())
+	}
+	return meta
+}
+
+// requireCapability returns an unsupported_capability error naming the
+// servers that do support method when the running server did not
+// advertise it.
+func requireCapability(client *lsp.Client, method string) *errors.WildcatError {
+	if client.Supports(method) {
+		return nil
+	}
+	return errors.NewUnsupportedCapability(method, serverName(client), servers.Supporting(method))
+}
+
+// serverName names the running server for messages.
+func serverName(client *lsp.Client) string {
+	if info := client.ServerInfo(); info != nil && info.Name != "" {
+		return info.Name
+	}
+	if spec, err := GetServerSpec(); err == nil {
+		return spec.Name
+	}
+	return "unknown"
+}
+
+// writeError writes a structured error response.
+func writeError(writer *output.Writer, we *errors.WildcatError) error {
+	return writer.WriteError(string(we.Code), we.Message, we.Suggestions, we.Context)
+}
@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/jasonmoo/wildcat/internal/traverse"
+	"github.com/spf13/cobra"
+)
+
+var cyclesCmd = &cobra.Command{
+	Use:   "cycles <symbol>",
+	Short: "Find recursion and call cycles reachable from a function",
+	Long: `Find recursion and call cycles reachable from a function.
+
+Explores the call graph from the symbol like tree does and reports every
+set of functions that can call each other again, such as a parser and an
+evaluator that recurse into one another, with the call sites that close
+each cycle.
+
+Examples:
+  wildcat cycles parser.Parse
+  wildcat cycles main.main --depth 8 --exclude-tests
+  wildcat cycles db.Query --direction up`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCycles,
+}
+
+var (
+	cyclesDepth         int
+	cyclesDirection     string
+	cyclesExcludeTests  bool
+	cyclesExcludeStdlib bool
+)
+
+func init() {
+	rootCmd.AddCommand(cyclesCmd)
+
+	cyclesCmd.Flags().IntVar(&cyclesDepth, "depth", 5, "Maximum depth to explore")
+	cyclesCmd.Flags().StringVar(&cyclesDirection, "direction", "down", "Traversal direction: up, down or both")
+	cyclesCmd.Flags().BoolVar(&cyclesExcludeTests, "exclude-tests", false, "Exclude test files")
+	cyclesCmd.Flags().BoolVar(&cyclesExcludeStdlib, "exclude-stdlib", false, "Exclude standard library")
+}
+
+func runCycles(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(cmd.OutOrStdout())
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	// Parse symbol
+	query, err := parseSymbol(args[0])
+	if err != nil {
+		return writer.WriteError("parse_error", err.Error(), nil, nil)
+	}
+
+	// Validate direction
+	var direction traverse.Direction
+	switch cyclesDirection {
+	case "up":
+		direction = traverse.Up
+	case "down":
+		direction = traverse.Down
+	case "both":
+		direction = traverse.Both
+	default:
+		return writer.WriteError("invalid_argument", "direction must be 'up', 'down' or 'both'", nil, nil)
+	}
+
+	// Get working directory
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
+	defer cancel()
+
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	defer release()
+
+	var methods []string
+	if direction != traverse.Down {
+		methods = append(methods, "callHierarchy/incomingCalls")
+	}
+	if direction != traverse.Up {
+		methods = append(methods, "callHierarchy/outgoingCalls")
+	}
+	for _, method := range methods {
+		if werr := requireCapability(client, method); werr != nil {
+			return writeError(writer, werr)
+		}
+	}
+
+	resolved, item, werr := resolveCallItem(ctx, client, query)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+
+	// Explore and find cycles
+	traverser := traverse.NewTraverser(client)
+	opts := traverse.Options{
+		Direction:     direction,
+		MaxDepth:      cyclesDepth,
+		ExcludeTests:  cyclesExcludeTests,
+		ExcludeStdlib: cyclesExcludeStdlib,
+		Concurrency:   globalConcurrency,
+		MaxNodes:      globalMaxNodes,
+		MaxEdges:      globalMaxEdges,
+		Timeout:       globalMaxTime,
+		FindCycles:    true,
+	}
+
+	tree, err := traverser.BuildTree(ctx, item, opts)
+	if err != nil {
+		return writeError(writer, lspError(err, "Failed to explore call graph"))
+	}
+
+	cycles := tree.Cycles
+	if cycles == nil {
+		cycles = []output.Cycle{}
+	}
+
+	tree.Query.Command = "cycles"
+	tree.Query.Root = resolved.Name
+	response := output.CyclesResponse{
+		Query:  tree.Query,
+		Cycles: cycles,
+		Summary: output.CyclesSummary{
+			Count:      len(cycles),
+			NodeCount:  tree.Summary.NodeCount,
+			Truncated:  tree.Summary.Truncated,
+			StopReason: tree.Summary.StopReason,
+		},
+		Meta: responseMeta(client),
+	}
+
+	return writer.Write(response)
+}
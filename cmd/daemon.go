@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/daemon"
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep a language server warm across invocations",
+	Long: `Run a daemon that keeps one language server warm for this workspace.
+
+While a daemon is running, other commands run from anywhere in the same
+workspace with the same language attach to it over a unix socket instead
+of starting and indexing a fresh server. When no daemon is running,
+commands fall back to starting the server in-process.
+
+The workspace is the nearest directory holding the language's project file
+(go.mod, pyproject.toml, package.json, Cargo.toml, ...) or else .git. Only
+one daemon runs per workspace and language; starting a second one is a
+no-op. The daemon exits after --idle-timeout without clients.
+
+The daemon scans the workspace every few seconds and reports changed files
+to the server, so queries follow edits without a restart.
+
+Examples:
+  wildcat daemon --detach
+  wildcat daemon --idle-timeout 30m
+  wildcat daemon status
+  wildcat daemon stop`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the daemon serving this workspace",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon serving this workspace",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+var (
+	daemonIdleTimeout time.Duration
+	daemonDetach      bool
+)
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+
+	daemonCmd.Flags().DurationVar(&daemonIdleTimeout, "idle-timeout", 10*time.Minute, "Exit after this long without clients (0 = never)")
+	daemonCmd.Flags().BoolVar(&daemonDetach, "detach", false, "Run in the background and return once ready")
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	spec, err := GetServerSpec()
+	if err != nil {
+		return writer.WriteError(string(errors.CodeServerNotFound), err.Error(), nil, nil)
+	}
+	workDir = spec.Root(workDir)
+
+	config, err := GetServerConfig(workDir)
+	if err != nil {
+		return writer.WriteError(string(errors.CodeServerNotFound), err.Error(), nil, nil)
+	}
+
+	if daemonDetach {
+		return detachDaemon(cmd, workDir, spec.Language)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	opts := daemon.Options{
+		WorkDir:     workDir,
+		Language:    spec.Language,
+		Server:      spec.Name,
+		IdleTimeout: daemonIdleTimeout,
+		Ready:       lsp.ReadyOptions{Timeout: globalReadyTimeout},
+	}
+
+	if err := daemon.Run(ctx, config, opts); err != nil {
+		if err == daemon.ErrRunning {
+			return runDaemonStatus(cmd, args)
+		}
+		return writer.WriteError("daemon_error", err.Error(), nil, map[string]any{"work_dir": workDir})
+	}
+
+	return nil
+}
+
+// detachDaemon re-executes wildcat as a background daemon and waits for its
+// socket to accept connections.
+func detachDaemon(cmd *cobra.Command, workDir, language string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating executable: %w", err)
+	}
+
+	logFile, err := os.OpenFile(daemon.LogPath(workDir, language), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("opening daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	child := exec.Command(exe, "daemon",
+		"--language", language,
+		"--idle-timeout", daemonIdleTimeout.String(),
+		"--ready-timeout", globalReadyTimeout.String(),
+	)
+	child.Dir = workDir
+	child.Stdout = logFile
+	child.Stderr = logFile
+	child.SysProcAttr = daemon.DetachAttr()
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("starting daemon: %w", err)
+	}
+	_ = child.Process.Release()
+
+	// Wait for the socket so the next command can attach immediately
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		if nc, err := daemon.Dial(workDir, language); err == nil {
+			nc.Close()
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return runDaemonStatus(cmd, nil)
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	spec, err := GetServerSpec()
+	if err != nil {
+		return writer.WriteError(string(errors.CodeServerNotFound), err.Error(), nil, nil)
+	}
+	workDir = spec.Root(workDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	status, err := daemon.GetStatus(ctx, workDir, spec.Language)
+	if err != nil {
+		return writer.WriteError("daemon_error", err.Error(), nil, nil)
+	}
+
+	return writer.Write(status)
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(os.Stdout)
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	spec, err := GetServerSpec()
+	if err != nil {
+		return writer.WriteError(string(errors.CodeServerNotFound), err.Error(), nil, nil)
+	}
+	workDir = spec.Root(workDir)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := daemon.Stop(ctx, workDir, spec.Language); err != nil && err != daemon.ErrNotRunning {
+		return writer.WriteError("daemon_error", err.Error(), nil, nil)
+	}
+
+	// Wait for the socket to go away so a follow-up start does not race
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		nc, err := daemon.Dial(workDir, spec.Language)
+		if err != nil {
+			break
+		}
+		nc.Close()
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	status, err := daemon.GetStatus(ctx, workDir, spec.Language)
+	if err != nil {
+		return writer.WriteError("daemon_error", err.Error(), nil, nil)
+	}
+
+	return writer.Write(status)
+}
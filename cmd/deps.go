@@ -55,7 +55,7 @@ type goListPackage struct {
 }
 
 func runDeps(cmd *cobra.Command, args []string) error {
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
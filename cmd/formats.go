@@ -19,6 +19,7 @@ Built-in formats:
   yaml       YAML output
   dot        Graphviz DOT format (for call trees)
   markdown   Markdown tables and lists
+  graphml    GraphML (for call trees and graphs)
 
 Custom formats:
   template:<path>   Use a Go template file
@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/jasonmoo/wildcat/internal/traverse"
+	"github.com/spf13/cobra"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph [dir...]",
+	Short: "Export the call graph of the whole module",
+	Long: `Export the call graph of the whole module.
+
+Lists every function and method declared in the workspace, or in the given
+directories, with the calls each one makes. Nodes carry their package and
+file and are classified as test, stdlib or external; edges carry the call
+site.
+
+Formats:
+  -o json       Nodes and edges (default)
+  -o dot        Graphviz; stdlib and external functions are greyed
+  -o graphml    GraphML for Gephi, yEd or NetworkX
+  --csv DIR     Writes DIR/nodes.csv and DIR/edges.csv, for loading into a
+                graph database or DuckDB
+
+With --collapse package or --collapse file, functions are merged into one
+node per package or file, with edges weighted by the number of calls.
+
+Examples:
+  wildcat graph --exclude-tests --exclude-stdlib
+  wildcat graph ./internal -o graphml > calls.graphml
+  wildcat graph --exclude-tests --collapse package -o dot | dot -Tsvg > pkgs.svg
+  wildcat graph --csv graph/ && duckdb -c "select * from 'graph/edges.csv'"`,
+	RunE: runGraph,
+}
+
+var (
+	graphCSV              string
+	graphCollapse         string
+	graphExcludeTests     bool
+	graphExcludeStdlib    bool
+	graphExpandInterfaces bool
+)
+
+func init() {
+	rootCmd.AddCommand(graphCmd)
+
+	graphCmd.Flags().StringVar(&graphCSV, "csv", "", "Write nodes.csv and edges.csv to this directory")
+	graphCmd.Flags().StringVar(&graphCollapse, "collapse", "", "Collapse functions into groups: package or file")
+	graphCmd.Flags().BoolVar(&graphExcludeTests, "exclude-tests", false, "Exclude test files")
+	graphCmd.Flags().BoolVar(&graphExcludeStdlib, "exclude-stdlib", false, "Exclude standard library")
+	graphCmd.Flags().BoolVar(&graphExpandInterfaces, "expand-interfaces", false, "Add calls from interface methods to their implementations")
+}
+
+func runGraph(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(cmd.OutOrStdout())
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	if werr := validateCollapse(graphCollapse); werr != nil {
+		return writeError(writer, werr)
+	}
+	if graphCollapse != "" && graphCSV != "" {
+		return writer.WriteError("invalid_argument", "--csv cannot be combined with --collapse", nil, nil)
+	}
+
+	// Get working directory
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	spec, err := GetServerSpec()
+	if err != nil {
+		return writer.WriteError(string(errors.CodeServerNotFound), err.Error(), nil, nil)
+	}
+
+	dirs := args
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	files, err := sourceFiles(dirs, spec.Extensions)
+	if err != nil {
+		return writer.WriteError("invalid_argument", err.Error(), nil, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
+	defer cancel()
+
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	defer release()
+
+	methods := []string{"textDocument/documentSymbol", "callHierarchy/outgoingCalls"}
+	if graphExpandInterfaces {
+		methods = append(methods, "textDocument/implementation")
+	}
+	for _, method := range methods {
+		if werr := requireCapability(client, method); werr != nil {
+			return writeError(writer, werr)
+		}
+	}
+
+	uris := make([]string, len(files))
+	for i, f := range files {
+		uris[i] = lsp.FileURI(f)
+	}
+
+	// Build graph
+	traverser := traverse.NewTraverser(client)
+	opts := traverse.Options{
+		ExcludeTests:     graphExcludeTests,
+		ExcludeStdlib:    graphExcludeStdlib,
+		Concurrency:      globalConcurrency,
+		MaxNodes:         globalMaxNodes,
+		MaxEdges:         globalMaxEdges,
+		Timeout:          globalMaxTime,
+		ExpandInterfaces: graphExpandInterfaces,
+	}
+
+	graph, err := traverser.BuildGraph(ctx, uris, opts)
+	if err != nil {
+		return writeError(writer, lspError(err, "Failed to build call graph"))
+	}
+
+	graph.Query = output.QueryInfo{
+		Command: "graph",
+		Target:  strings.Join(dirs, " "),
+	}
+	graph.Meta = responseMeta(client)
+
+	if graphCollapse != "" {
+		return writer.Write(output.CollapseGraph(graph, graphCollapse))
+	}
+
+	if graphCSV != "" {
+		written, err := writeGraphCSV(graphCSV, graph)
+		if err != nil {
+			return writer.WriteError("io_error", err.Error(), nil, nil)
+		}
+		graph.Nodes, graph.Edges = nil, nil
+		graph.Files = written
+	}
+
+	return writer.Write(graph)
+}
+
+// validateCollapse checks a --collapse value; empty means no collapsing.
+func validateCollapse(by string) *errors.WildcatError {
+	switch by {
+	case "", output.CollapsePackage, output.CollapseFile:
+		return nil
+	}
+	return &errors.WildcatError{Code: "invalid_argument", Message: "collapse must be 'package' or 'file'"}
+}
+
+// sourceFiles lists the files under dirs with one of the given extensions,
+// skipping hidden, vendor, node_modules and testdata directories.
+func sourceFiles(dirs, extensions []string) ([]string, error) {
+	var files []string
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "node_modules" || name == "testdata") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if slices.Contains(extensions, strings.TrimPrefix(filepath.Ext(path), ".")) {
+				abs, err := filepath.Abs(path)
+				if err != nil {
+					return err
+				}
+				files = append(files, abs)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(files)
+	return slices.Compact(files), nil
+}
+
+// writeGraphCSV writes the graph's nodes.csv and edges.csv into dir and
+// returns their paths.
+func writeGraphCSV(dir string, graph *output.GraphResponse) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths := []string{filepath.Join(dir, "nodes.csv"), filepath.Join(dir, "edges.csv")}
+
+	nodes, err := os.Create(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	defer nodes.Close()
+	edges, err := os.Create(paths[1])
+	if err != nil {
+		return nil, err
+	}
+	defer edges.Close()
+
+	if err := output.WriteGraphCSV(nodes, edges, graph); err != nil {
+		return nil, err
+	}
+	if err := nodes.Close(); err != nil {
+		return nil, err
+	}
+	if err := edges.Close(); err != nil {
+		return nil, err
+	}
+
+	for i, p := range paths {
+		paths[i] = output.AbsolutePath(p)
+	}
+	return paths, nil
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
+	"slices"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
 	"github.com/jasonmoo/wildcat/internal/lsp"
@@ -24,10 +24,14 @@ This command answers "What breaks if I change this?" by combining:
   - All references (type usage, not just calls)
   - Interface implementations (if changing an interface)
 
+A glob (Server.*, *Handler) or regular expression (re:^Load.*$) reports
+one section per matching symbol, with a combined summary.
+
 Examples:
   wildcat impact config.Config
   wildcat impact Server.Start
-  wildcat impact Handler`,
+  wildcat impact Handler
+  wildcat impact 're:^Load.*$'`,
 	Args: cobra.ExactArgs(1),
 	RunE: runImpact,
 }
@@ -46,13 +50,13 @@ func init() {
 
 func runImpact(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
@@ -63,45 +67,51 @@ func runImpact(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
+	defer release()
 
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
-	}
-	defer client.Close()
-
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
+	if werr := requireCapability(client, "textDocument/references"); werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Shutdown(ctx)
-
-	time.Sleep(200 * time.Millisecond)
 
 	// Resolve symbol
-	resolver := symbols.NewResolver(client)
+	resolver := newResolver(client)
+	if query.IsPattern() {
+		targets, capped, werr := resolveTargets(ctx, resolver, query)
+		if werr != nil {
+			return writeError(writer, werr)
+		}
+
+		response := output.MultiImpactResponse{
+			Query: output.QueryInfo{
+				Command: "impact",
+				Target:  query.Raw,
+			},
+			Targets: []output.ImpactResponse{},
+			Meta:    impactMeta(client),
+		}
+		response.Summary.SearchCapped = capped
+		for _, target := range targets {
+			section, fallback := findImpact(ctx, client, query, &target)
+			response.Targets = append(response.Targets, *section)
+			response.Summary.Add(section.Summary)
+			for _, e := range section.Summary.Errors {
+				response.Summary.Errors = append(response.Summary.Errors, fmt.Sprintf("%s: %s", target.Name, e))
+			}
+			if fallback != "" && !slices.Contains(response.Meta.Fallbacks, fallback) {
+				response.Meta.Fallbacks = append(response.Meta.Fallbacks, fallback)
+			}
+		}
+		return writer.Write(response)
+	}
+
 	resolved, err := resolver.Resolve(ctx, query)
 	if err != nil {
 		if we, ok := err.(*errors.WildcatError); ok {
@@ -110,6 +120,20 @@ func runImpact(cmd *cobra.Command, args []string) error {
 		return writer.WriteError(string(errors.CodeSymbolNotFound), err.Error(), nil, nil)
 	}
 
+	response, fallback := findImpact(ctx, client, query, resolved)
+	response.Meta = impactMeta(client)
+	if fallback != "" {
+		response.Meta.Fallbacks = append(response.Meta.Fallbacks, fallback)
+	}
+
+	return writer.Write(response)
+}
+
+// findImpact builds the impact response for one resolved target of query,
+// and names the fallback used to find implementations, if any. Parts the
+// server cannot answer are left empty, and parts that fail are listed in
+// the summary's errors.
+func findImpact(ctx context.Context, client *lsp.Client, query *symbols.Query, resolved *symbols.ResolvedSymbol) (*output.ImpactResponse, string) {
 	// Determine symbol kind for display
 	kind := "symbol"
 	switch resolved.Kind {
@@ -128,40 +152,56 @@ func runImpact(cmd *cobra.Command, args []string) error {
 	}
 
 	impact := output.Impact{}
+	summary := output.ImpactSummary{}
 	var callersCount, refsCount, implsCount, inTestsCount int
 
 	// Get transitive callers (for functions/methods)
-	if resolved.Kind == lsp.SymbolKindFunction || resolved.Kind == lsp.SymbolKindMethod {
+	hasCallers := client.Supports("callHierarchy/incomingCalls")
+	if hasCallers && (resolved.Kind == lsp.SymbolKindFunction || resolved.Kind == lsp.SymbolKindMethod) {
 		items, err := client.PrepareCallHierarchy(ctx, resolved.URI, resolved.Position)
-		if err == nil && len(items) > 0 {
+		if err != nil {
+			summary.Errors = append(summary.Errors, "callers: "+lspError(err, "Failed to prepare call hierarchy").Message)
+		} else if len(items) > 0 {
 			traverser := traverse.NewTraverser(client)
 			opts := traverse.Options{
 				Direction:    traverse.Up,
 				MaxDepth:     impactDepth,
 				ExcludeTests: impactExcludeTests,
+				Concurrency:  globalConcurrency,
+				MaxNodes:     globalMaxNodes,
+				MaxEdges:     globalMaxEdges,
+				Timeout:      globalMaxTime,
 			}
 
 			callers, err := traverser.GetCallers(ctx, items[0], opts)
-			if err == nil {
-				for _, caller := range callers {
+			if err != nil {
+				summary.Errors = append(summary.Errors, "callers: "+lspError(err, "Failed to get callers").Message)
+			} else {
+				for _, caller := range callers.Calls {
 					impact.Callers = append(impact.Callers, output.ImpactCategory{
-						Symbol: caller.Symbol,
-						File:   output.AbsolutePath(caller.File),
-						Line:   caller.Line,
-						Reason: "calls this function",
+						Symbol:    caller.Symbol,
+						File:      output.AbsolutePath(caller.File),
+						Line:      caller.Line,
+						Reason:    "calls this function",
+						Truncated: caller.Truncated,
+						Error:     caller.Error,
 					})
 					if caller.InTest {
 						inTestsCount++
 					}
 				}
-				callersCount = len(callers)
+				callersCount = len(callers.Calls)
+				summary.Truncated = callers.Stopped != ""
+				summary.StopReason = callers.Stopped
 			}
 		}
 	}
 
 	// Get all references
 	refs, err := client.References(ctx, resolved.URI, resolved.Position, false)
-	if err == nil {
+	if err != nil {
+		summary.Errors = append(summary.Errors, "references: "+lspError(err, "Failed to get references").Message)
+	} else {
 		for _, ref := range refs {
 			file := lsp.URIToPath(ref.URI)
 			isTest := output.IsTestFile(file)
@@ -183,9 +223,13 @@ func runImpact(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get implementations (for interfaces)
+	var fallback string
 	if resolved.Kind == lsp.SymbolKindInterface {
-		impls, err := client.Implementation(ctx, resolved.URI, resolved.Position)
-		if err == nil {
+		var impls []lsp.Location
+		impls, fallback, err = findImplementations(ctx, client, resolved.URI, resolved.Position)
+		if err != nil {
+			summary.Errors = append(summary.Errors, "implementations: "+lspError(err, "Failed to find implementations").Message)
+		} else {
 			for _, impl := range impls {
 				file := lsp.URIToPath(impl.URI)
 				isTest := output.IsTestFile(file)
@@ -207,7 +251,11 @@ func runImpact(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	totalLocations := callersCount + refsCount + implsCount
+	summary.TotalLocations = callersCount + refsCount + implsCount
+	summary.Callers = callersCount
+	summary.References = refsCount
+	summary.Implementations = implsCount
+	summary.InTests = inTestsCount
 
 	response := output.ImpactResponse{
 		Query: output.QueryInfo{
@@ -221,15 +269,19 @@ func runImpact(cmd *cobra.Command, args []string) error {
 			File:   output.AbsolutePath(lsp.URIToPath(resolved.URI)),
 			Line:   resolved.Position.Line + 1,
 		},
-		Impact: impact,
-		Summary: output.ImpactSummary{
-			TotalLocations:  totalLocations,
-			Callers:         callersCount,
-			References:      refsCount,
-			Implementations: implsCount,
-			InTests:         inTestsCount,
-		},
+		Impact:  impact,
+		Summary: summary,
 	}
 
-	return writer.Write(response)
+	return &response, fallback
+}
+
+// impactMeta describes the session behind an impact response.
+func impactMeta(client *lsp.Client) *output.Meta {
+	meta := responseMeta(client)
+	if !client.Supports("callHierarchy/incomingCalls") && !slices.Contains(meta.Unsupported, "callHierarchy/incomingCalls") {
+		// Callers were left out; say so rather than reporting zero
+		meta.Unsupported = append(meta.Unsupported, "callHierarchy/incomingCalls")
+	}
+	return meta
 }
@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
 	"github.com/jasonmoo/wildcat/internal/lsp"
 	"github.com/jasonmoo/wildcat/internal/output"
-	"github.com/jasonmoo/wildcat/internal/symbols"
 	"github.com/spf13/cobra"
 )
 
@@ -45,13 +43,13 @@ func init() {
 
 func runImplements(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
@@ -62,45 +60,24 @@ func runImplements(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
-	}
-
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Close()
+	defer release()
 
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
+	if !client.Supports("typeHierarchy/subtypes") {
+		if werr := requireCapability(client, "textDocument/implementation"); werr != nil {
+			return writeError(writer, werr)
+		}
 	}
-	defer client.Shutdown(ctx)
-
-	time.Sleep(200 * time.Millisecond)
 
 	// Resolve symbol
-	resolver := symbols.NewResolver(client)
+	resolver := newResolver(client)
 	resolved, err := resolver.Resolve(ctx, query)
 	if err != nil {
 		if we, ok := err.(*errors.WildcatError); ok {
@@ -120,14 +97,9 @@ func runImplements(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get implementations
-	impls, err := client.Implementation(ctx, resolved.URI, resolved.Position)
+	impls, fallback, err := findImplementations(ctx, client, resolved.URI, resolved.Position)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to get implementations: %v", err),
-			nil,
-			nil,
-		)
+		return writeError(writer, lspError(err, "Failed to get implementations"))
 	}
 
 	// Build results
@@ -181,11 +153,44 @@ func runImplements(cmd *cobra.Command, args []string) error {
 			Count:   len(results),
 			InTests: inTests,
 		},
+		Meta: responseMeta(client),
+	}
+	if fallback != "" {
+		response.Meta.Fallbacks = append(response.Meta.Fallbacks, fallback)
 	}
 
 	return writer.Write(response)
 }
 
+// findImplementations lists implementations of the interface at uri/pos.
+// Servers without textDocument/implementation are asked for subtypes
+// through the type hierarchy instead, and the substitution is returned
+// for the response metadata.
+func findImplementations(ctx context.Context, client *lsp.Client, uri string, pos lsp.Position) ([]lsp.Location, string, error) {
+	if client.Supports("textDocument/implementation") {
+		locs, err := client.Implementation(ctx, uri, pos)
+		return locs, "", err
+	}
+
+	const fallback = "textDocument/implementation via typeHierarchy/subtypes"
+
+	items, err := client.PrepareTypeHierarchy(ctx, uri, pos)
+	if err != nil || len(items) == 0 {
+		return nil, fallback, err
+	}
+
+	subtypes, err := client.Subtypes(ctx, items[0])
+	if err != nil {
+		return nil, fallback, err
+	}
+
+	locs := make([]lsp.Location, len(subtypes))
+	for i, st := range subtypes {
+		locs[i] = lsp.Location{URI: st.URI, Range: st.SelectionRange}
+	}
+	return locs, fallback, nil
+}
+
 func symbolKindName(kind lsp.SymbolKind) string {
 	switch kind {
 	case lsp.SymbolKindFunction:
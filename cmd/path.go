@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/jasonmoo/wildcat/internal/symbols"
+	"github.com/jasonmoo/wildcat/internal/traverse"
+	"github.com/spf13/cobra"
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path <from> <to>",
+	Short: "Find call paths from one symbol to another",
+	Long: `Find the shortest call paths from one function to another.
+
+Searches forward along the calls made by <from> and backward along the
+callers of <to> until the two meet, then reports each path with the call
+site of every hop.
+
+Examples:
+  wildcat path handler.Serve db.Query
+  wildcat path main.main config.Load --max-paths 5 --exclude-tests
+  wildcat path Server.Start db.Query -o dot | dot -Tsvg > path.svg`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPath,
+}
+
+var (
+	pathMaxPaths      int
+	pathDepth         int
+	pathExcludeTests  bool
+	pathExcludeStdlib bool
+)
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+
+	pathCmd.Flags().IntVar(&pathMaxPaths, "max-paths", 3, "Maximum number of paths to return")
+	pathCmd.Flags().IntVar(&pathDepth, "depth", traverse.DefaultPathLength, "Maximum number of calls in a path")
+	pathCmd.Flags().BoolVar(&pathExcludeTests, "exclude-tests", false, "Exclude test files")
+	pathCmd.Flags().BoolVar(&pathExcludeStdlib, "exclude-stdlib", false, "Exclude standard library")
+}
+
+func runPath(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(cmd.OutOrStdout())
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	// Parse symbols
+	fromQuery, err := parseSymbol(args[0])
+	if err != nil {
+		return writer.WriteError("parse_error", err.Error(), nil, nil)
+	}
+	toQuery, err := parseSymbol(args[1])
+	if err != nil {
+		return writer.WriteError("parse_error", err.Error(), nil, nil)
+	}
+
+	// Get working directory
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
+	defer cancel()
+
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	defer release()
+
+	for _, method := range []string{"callHierarchy/outgoingCalls", "callHierarchy/incomingCalls"} {
+		if werr := requireCapability(client, method); werr != nil {
+			return writeError(writer, werr)
+		}
+	}
+
+	from, fromItem, werr := resolveCallItem(ctx, client, fromQuery)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	to, toItem, werr := resolveCallItem(ctx, client, toQuery)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+
+	// Search
+	traverser := traverse.NewTraverser(client)
+	opts := traverse.Options{
+		MaxDepth:      pathDepth,
+		ExcludeTests:  pathExcludeTests,
+		ExcludeStdlib: pathExcludeStdlib,
+		Concurrency:   globalConcurrency,
+		MaxNodes:      globalMaxNodes,
+		MaxEdges:      globalMaxEdges,
+		Timeout:       globalMaxTime,
+	}
+
+	response, err := traverser.FindPaths(ctx, fromItem, toItem, pathMaxPaths, opts)
+	if err != nil {
+		return writeError(writer, lspError(err, "Failed to search call paths"))
+	}
+
+	response.Query = output.QueryInfo{
+		Command:  "path",
+		Target:   fromQuery.Raw + " -> " + toQuery.Raw,
+		Resolved: from.Name + " -> " + to.Name,
+	}
+	response.From = targetInfo(from)
+	response.To = targetInfo(to)
+	for i := range response.Paths {
+		for j := range response.Paths[i].Hops {
+			hop := &response.Paths[i].Hops[j]
+			hop.File = output.AbsolutePath(hop.File)
+		}
+	}
+	response.Meta = responseMeta(client)
+
+	return writer.Write(response)
+}
+
+// resolveCallItem resolves a symbol query to its call hierarchy item.
+func resolveCallItem(ctx context.Context, client *lsp.Client, query *symbols.Query) (*symbols.ResolvedSymbol, lsp.CallHierarchyItem, *errors.WildcatError) {
+	resolved, err := newResolver(client).Resolve(ctx, query)
+	if err != nil {
+		if we, ok := err.(*errors.WildcatError); ok {
+			return nil, lsp.CallHierarchyItem{}, we
+		}
+		return nil, lsp.CallHierarchyItem{}, &errors.WildcatError{Code: errors.CodeSymbolNotFound, Message: err.Error()}
+	}
+
+	items, err := client.PrepareCallHierarchy(ctx, resolved.URI, resolved.Position)
+	if err != nil {
+		return nil, lsp.CallHierarchyItem{}, lspError(err, "Failed to prepare call hierarchy")
+	}
+	if len(items) == 0 {
+		return nil, lsp.CallHierarchyItem{}, &errors.WildcatError{
+			Code:    errors.CodeSymbolNotFound,
+			Message: fmt.Sprintf("No call hierarchy found for '%s'", query.Raw),
+		}
+	}
+	return resolved, items[0], nil
+}
+
+// targetInfo describes a resolved symbol.
+func targetInfo(resolved *symbols.ResolvedSymbol) output.TargetInfo {
+	return output.TargetInfo{
+		Symbol: resolved.Name,
+		File:   output.AbsolutePath(lsp.URIToPath(resolved.URI)),
+		Line:   resolved.Position.Line + 1,
+	}
+}
@@ -49,22 +49,32 @@ func printCompactReadme() {
 - wildcat callees <symbol>     Find functions called by a function
 - wildcat refs <symbol>        Find all references to a symbol
 - wildcat tree <symbol>        Build call hierarchy tree
+- wildcat path <from> <to>     Find call paths between functions
+- wildcat cycles <symbol>      Find recursion and call cycles
+- wildcat graph [dir...]       Export the whole-module call graph
+- wildcat unused [dir...]      Find functions no entrypoint reaches
 - wildcat impact <symbol>      Analyze change impact
 - wildcat implements <iface>   Find types implementing interface
 - wildcat satisfies <type>     Find interfaces a type satisfies
 - wildcat deps [package]       Show package dependencies
+- wildcat batch                Run JSONL queries from stdin in one session
+- wildcat daemon --detach      Keep the language server warm
 
 ## Symbol Formats
 - Function               pkg.Function, main.main
 - Method                 Type.Method, Server.Start
 - Pointer receiver       (*Type).Method
 - Full path              path/to/pkg.Function
+- Position               file.go:42, file.go:42:7, file.go#Name
+- Pattern                Server.*, *Handler, re:^Load.*$ (callers, refs, impact)
+- Other languages        pkg.module.Class.method (python), Server#start (typescript),
+                         crate::net::Server::start (rust), ns::Widget::draw (c)
 
 ## Common Flags
 - --compact              Omit code snippets
 - --exclude-tests        Exclude test files
 - --depth N              Limit traversal depth
-- -o, --output FORMAT    json|yaml|markdown|dot
+- -o, --output FORMAT    json|yaml|markdown|dot|graphml
 `)
 }
 
@@ -110,7 +120,47 @@ Returns all references to a symbol including type usage, not just calls.
 ### tree - Build call hierarchy
 `+"`"+`wildcat tree Server.Start --direction up --depth 3`+"`"+`
 
-Builds a visual call tree. Use --direction up for callers, down for callees.
+Builds a visual call tree. Use --direction up for callers, down for callees,
+or both for one graph with callers above the symbol and callees below it.
+Each node's depth is negative for callers and positive for callees.
+
+### path - Find call paths
+`+"`"+`wildcat path handler.Serve db.Query --max-paths 3`+"`"+`
+
+Returns the shortest call paths from one function to another, with the call
+site of each hop. Use -o dot to render them with the shortest highlighted.
+
+### cycles - Find recursion
+`+"`"+`wildcat cycles parser.Parse --depth 8`+"`"+`
+
+Reports each set of functions that call back into one another, with the
+call sites that close the cycle. tree --cycles adds the same report to a
+call tree, and tree always marks calls back up the tree as back_edge.
+
+### graph - Export the whole call graph
+`+"`"+`wildcat graph --exclude-tests --csv graph/`+"`"+`
+
+Lists every function and method in the module with the calls it makes,
+classified as test, stdlib or external. Use -o dot or -o graphml for
+graph tools, or --csv DIR for nodes.csv and edges.csv.
+
+### unused - Find dead code
+`+"`"+`wildcat unused --exclude-tests --entry '*Handler'`+"`"+`
+
+Lists functions and methods not reachable from main, init, tests or
+--entry patterns (the exported API too, for libraries). Each result has
+the line range of its declaration and a reason: no_references, self_only,
+test_only or unreachable. Check test_only and unreachable results before
+deleting them.
+
+### batch - Many queries, one session
+`+"`"+`wildcat batch < queries.jsonl`+"`"+`
+
+Reads one request per line, such as
+{"id": 1, "command": "callers", "symbol": "config.Load", "flags": {"depth": 2}},
+and writes one line per request with its id and either "response" or
+"error". A failing request does not stop the rest. Prefer it over many
+separate calls when planning a refactor.
 
 ### impact - Change impact analysis
 `+"`"+`wildcat impact lsp.Client`+"`"+`
@@ -133,6 +183,13 @@ Find all interfaces that a type satisfies.
 
 Show what a package imports, or with --reverse, what imports it.
 
+### daemon - Keep the language server warm
+`+"`"+`wildcat daemon --detach`+"`"+`
+
+Starts one language server for the workspace that later commands attach to
+automatically. Run this before issuing many queries in a row. Use
+`+"`"+`wildcat daemon status`+"`"+` and `+"`"+`wildcat daemon stop`+"`"+` to manage it.
+
 ## Symbol Formats
 
 | Format | Example | Description |
@@ -142,6 +199,20 @@ Show what a package imports, or with --reverse, what imports it.
 | Type.Method | Server.Start | Method on type |
 | (*Type).Method | (*Handler).ServeHTTP | Method on pointer receiver |
 | Full path | github.com/user/pkg.Func | Fully qualified |
+| file:line | server/server.go:42 | Symbol enclosing the line |
+| file:line:col | server/server.go:42:7 | Symbol under the cursor, via its definition |
+| file#Name | server/server.go#Server.Start | Symbol declared in the file |
+| Glob | Server.*, *Handler | Every matching symbol (callers, refs, impact) |
+| Regexp | re:^Load.*$ | Every symbol whose name matches (callers, refs, impact) |
+
+Other languages name symbols in their own syntax, chosen by --language:
+
+| Language | Example | Description |
+|----------|---------|-------------|
+| python | pkg.module.Class.method | Dotted module path, then classes |
+| typescript | src/server.Server#start | Instance member; Server.create is static |
+| rust | crate::net::Server::start | Module path, then type |
+| c | ns::Widget::draw | Namespaces, then classes |
 
 ## Output Formats
 
@@ -149,6 +220,7 @@ Show what a package imports, or with --reverse, what imports it.
 - --output yaml: YAML format
 - --output markdown: Markdown tables
 - --output dot: Graphviz DOT for visualization
+- --output graphml: GraphML for graph tools such as Gephi or yEd
 
 ## Common Flags
 
@@ -157,8 +229,20 @@ Show what a package imports, or with --reverse, what imports it.
 | --compact | Omit code snippets for smaller output |
 | --exclude-tests | Exclude test files from results |
 | --depth N | Limit traversal depth |
+| --group-by depth | Group callers/callees by distance from the target |
+| --expand-interfaces | Follow calls through interfaces to their implementations |
+| --collapse package\|file | Merge tree or graph nodes by package or file, with weighted edges |
 | --context N | Lines of context in snippets (default 3) |
 | -l, --language | Force language (go, python, typescript, rust, c) |
+| --timeout D | Maximum time for the whole command (default 60s) |
+| --call-timeout D | Maximum time for any single LSP request |
+| --retries N | Retries when the server is busy indexing (default 3) |
+| --concurrency N | Maximum concurrent LSP requests while traversing (default 8) |
+| --max-nodes N | Stop traversing after expanding N symbols |
+| --max-edges N | Stop traversing after finding N calls |
+| --max-time D | Stop traversing after D |
+| --lsp-record FILE | Record the LSP session to a transcript |
+| --lsp-replay FILE | Answer LSP requests from a recorded transcript |
 
 ## Workflow Patterns
 
@@ -171,6 +255,22 @@ wildcat callers old.Function
 wildcat callees old.Function
 `+"`"+`
 
+### Explaining Transitive Results
+`+"`"+`bash
+# Each result carries its depth and the chain of calls back to the target
+wildcat callers config.Load --depth 3 --group-by depth
+`+"`"+`
+
+### Bounding Large Traversals
+`+"`"+`bash
+# Return whatever is found within 500 calls or 10 seconds
+wildcat tree log.Printf --direction up --max-edges 500 --max-time 10s
+`+"`"+`
+When a budget runs out the command still succeeds. summary.stop_reason
+names the budget, and nodes or results whose own calls were not explored
+carry "truncated": true. A branch that failed carries "error" instead of
+failing the whole command.
+
 ### Understanding New Code
 `+"`"+`bash
 # Start from main and go down
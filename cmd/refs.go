@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
 	"github.com/jasonmoo/wildcat/internal/lsp"
@@ -25,10 +24,14 @@ This includes:
   - Constant usage
   - Functions passed as values
 
+A glob (Server.*, *Handler) or regular expression (re:^Load.*$) reports
+one section per matching symbol, with a combined summary.
+
 Examples:
   wildcat refs config.Load
   wildcat refs Config
-  wildcat refs MaxRetries`,
+  wildcat refs MaxRetries
+  wildcat refs '*Handler'`,
 	Args: cobra.ExactArgs(1),
 	RunE: runRefs,
 }
@@ -55,13 +58,13 @@ func init() {
 
 func runRefs(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
@@ -72,45 +75,49 @@ func runRefs(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
+	defer release()
 
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
+	if werr := requireCapability(client, "textDocument/references"); werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Close()
-
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
-	}
-	defer client.Shutdown(ctx)
-
-	time.Sleep(200 * time.Millisecond)
 
 	// Resolve symbol
-	resolver := symbols.NewResolver(client)
+	resolver := newResolver(client)
+	if query.IsPattern() {
+		targets, capped, werr := resolveTargets(ctx, resolver, query)
+		if werr != nil {
+			return writeError(writer, werr)
+		}
+
+		response := output.MultiRefsResponse{
+			Query: output.QueryInfo{
+				Command: "refs",
+				Target:  query.Raw,
+			},
+			Targets: []output.RefsResponse{},
+			Meta:    responseMeta(client),
+		}
+		response.Summary.SearchCapped = capped
+		for _, target := range targets {
+			section, werr := findRefs(ctx, client, query, &target)
+			if werr != nil {
+				response.Summary.Errors = append(response.Summary.Errors, fmt.Sprintf("%s: %s", target.Name, werr.Message))
+				continue
+			}
+			response.Targets = append(response.Targets, *section)
+			response.Summary.Add(section.Summary)
+		}
+		return writer.Write(response)
+	}
+
 	resolved, err := resolver.Resolve(ctx, query)
 	if err != nil {
 		if we, ok := err.(*errors.WildcatError); ok {
@@ -119,15 +126,21 @@ func runRefs(cmd *cobra.Command, args []string) error {
 		return writer.WriteError(string(errors.CodeSymbolNotFound), err.Error(), nil, nil)
 	}
 
+	response, werr := findRefs(ctx, client, query, resolved)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	response.Meta = responseMeta(client)
+
+	return writer.Write(response)
+}
+
+// findRefs builds the refs response for one resolved target of query.
+func findRefs(ctx context.Context, client *lsp.Client, query *symbols.Query, resolved *symbols.ResolvedSymbol) (*output.RefsResponse, *errors.WildcatError) {
 	// Get references
 	refs, err := client.References(ctx, resolved.URI, resolved.Position, refsIncludeDeclaration)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to get references: %v", err),
-			nil,
-			nil,
-		)
+		return nil, lspError(err, "Failed to get references")
 	}
 
 	// Build results
@@ -195,5 +208,5 @@ func runRefs(cmd *cobra.Command, args []string) error {
 		},
 	}
 
-	return writer.Write(response)
+	return &response, nil
 }
@@ -4,12 +4,10 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
 	"github.com/jasonmoo/wildcat/internal/lsp"
 	"github.com/jasonmoo/wildcat/internal/output"
-	"github.com/jasonmoo/wildcat/internal/symbols"
 	"github.com/spf13/cobra"
 )
 
@@ -45,13 +43,13 @@ func init() {
 
 func runSatisfies(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
@@ -62,45 +60,22 @@ func runSatisfies(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
-	}
-
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Close()
+	defer release()
 
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
+	if werr := requireCapability(client, "typeHierarchy/supertypes"); werr != nil {
+		return writeError(writer, werr)
 	}
-	defer client.Shutdown(ctx)
-
-	time.Sleep(200 * time.Millisecond)
 
 	// Resolve symbol
-	resolver := symbols.NewResolver(client)
+	resolver := newResolver(client)
 	resolved, err := resolver.Resolve(ctx, query)
 	if err != nil {
 		if we, ok := err.(*errors.WildcatError); ok {
@@ -112,12 +87,7 @@ func runSatisfies(cmd *cobra.Command, args []string) error {
 	// Prepare type hierarchy
 	items, err := client.PrepareTypeHierarchy(ctx, resolved.URI, resolved.Position)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to prepare type hierarchy: %v", err),
-			nil,
-			nil,
-		)
+		return writeError(writer, lspError(err, "Failed to prepare type hierarchy"))
 	}
 
 	if len(items) == 0 {
@@ -132,12 +102,7 @@ func runSatisfies(cmd *cobra.Command, args []string) error {
 	// Get supertypes (interfaces this type satisfies)
 	supertypes, err := client.Supertypes(ctx, items[0])
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to get supertypes: %v", err),
-			nil,
-			nil,
-		)
+		return writeError(writer, lspError(err, "Failed to get supertypes"))
 	}
 
 	// Build results
@@ -189,6 +154,7 @@ func runSatisfies(cmd *cobra.Command, args []string) error {
 		Summary: output.Summary{
 			Count: len(results),
 		},
+		Meta: responseMeta(client),
 	}
 
 	return writer.Write(response)
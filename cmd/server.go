@@ -3,10 +3,13 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"path/filepath"
+	"sync"
 
 	"github.com/jasonmoo/wildcat/internal/lsp"
 	"github.com/jasonmoo/wildcat/internal/output"
 	"github.com/jasonmoo/wildcat/internal/servers"
+	"github.com/jasonmoo/wildcat/internal/symbols"
 )
 
 var (
@@ -25,8 +28,8 @@ func GetWriter(w io.Writer) (*output.Writer, error) {
 	return output.NewWriterWithFormat(w, globalOutput)
 }
 
-// GetServerConfig returns the LSP server configuration for the specified or detected language.
-func GetServerConfig(workDir string) (lsp.ServerConfig, error) {
+// GetServerSpec returns the language server spec for the specified or detected language.
+func GetServerSpec() (*servers.ServerSpec, error) {
 	var spec *servers.ServerSpec
 
 	if globalLanguage != "" {
@@ -38,7 +41,7 @@ func GetServerConfig(workDir string) (lsp.ServerConfig, error) {
 			for i, a := range available {
 				langs[i] = a.Language
 			}
-			return lsp.ServerConfig{}, fmt.Errorf("unknown language %q, available: %v", globalLanguage, langs)
+			return nil, fmt.Errorf("unknown language %q, available: %v", globalLanguage, langs)
 		}
 		spec = s
 	} else {
@@ -48,6 +51,53 @@ func GetServerConfig(workDir string) (lsp.ServerConfig, error) {
 		spec = s
 	}
 
+	return spec, nil
+}
+
+// parseSymbol parses a symbol query in the syntax of the configured language.
+// Files are relative to the working directory, which may lie below the
+// workspace root the server was started in.
+func parseSymbol(input string) (*symbols.Query, error) {
+	parse := symbols.Parse
+	if spec, err := GetServerSpec(); err == nil {
+		parse = symbols.ParserFor(spec)
+	}
+	query, err := parse(input)
+	if err != nil {
+		return nil, err
+	}
+	if query.IsLocation() && !filepath.IsAbs(query.File) {
+		if abs, err := filepath.Abs(query.File); err == nil {
+			query.File = abs
+		}
+	}
+	return query, nil
+}
+
+// packageFinder finds packages the way the configured language lays them
+// out. It is made once, so a batch session's queries share its cache.
+var packageFinder = sync.OnceValue(func() symbols.PackageFinder {
+	spec, err := GetServerSpec()
+	if err != nil {
+		return nil
+	}
+	return symbols.FinderFor(spec)
+})
+
+// newResolver returns a symbol resolver using packageFinder.
+func newResolver(client *lsp.Client) *symbols.Resolver {
+	resolver := symbols.NewResolver(client)
+	resolver.SetPackageFinder(packageFinder())
+	return resolver
+}
+
+// GetServerConfig returns the LSP server configuration for the specified or detected language.
+func GetServerConfig(workDir string) (lsp.ServerConfig, error) {
+	spec, err := GetServerSpec()
+	if err != nil {
+		return lsp.ServerConfig{}, err
+	}
+
 	if !spec.Available() {
 		return lsp.ServerConfig{}, fmt.Errorf("language server %q not found in PATH", spec.Command)
 	}
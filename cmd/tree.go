@@ -4,11 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"time"
 
-	"github.com/jasonmoo/wildcat/internal/errors"
-	"github.com/jasonmoo/wildcat/internal/lsp"
-	"github.com/jasonmoo/wildcat/internal/symbols"
+	"github.com/jasonmoo/wildcat/internal/output"
 	"github.com/jasonmoo/wildcat/internal/traverse"
 	"github.com/spf13/cobra"
 )
@@ -21,39 +18,60 @@ var treeCmd = &cobra.Command{
 Direction:
   up    - Show callers (what calls this function)
   down  - Show callees (what this function calls)
+  both  - Show callers above and callees below it in one graph
+
+Each node carries its depth from the root, negative for callers and
+positive for callees.
+
+With --expand-interfaces, calls through an interface method continue into
+each implementation, and callers that reach a method through its interface
+are included. Those edges are marked "dynamic".
+
+With --collapse package or --collapse file, functions are merged into one
+node per package or file that lists the functions it contains, and calls
+between them into one edge per pair weighted by the number of calls.
 
 Examples:
   wildcat tree main.main --depth 3 --direction down
-  wildcat tree db.Query --depth 2 --direction up`,
+  wildcat tree db.Query --depth 2 --direction up
+  wildcat tree config.Load --direction both -o dot | dot -Tsvg > load.svg
+  wildcat tree Server.Handle --expand-interfaces
+  wildcat tree main.main --depth 4 --collapse package -o dot`,
 	Args: cobra.ExactArgs(1),
 	RunE: runTree,
 }
 
 var (
-	treeDepth         int
-	treeDirection     string
-	treeExcludeTests  bool
-	treeExcludeStdlib bool
+	treeDepth            int
+	treeExpandInterfaces bool
+	treeDirection        string
+	treeExcludeTests     bool
+	treeExcludeStdlib    bool
+	treeCycles           bool
+	treeCollapse         string
 )
 
 func init() {
 	rootCmd.AddCommand(treeCmd)
 
 	treeCmd.Flags().IntVar(&treeDepth, "depth", 3, "Maximum tree depth")
-	treeCmd.Flags().StringVar(&treeDirection, "direction", "down", "Traversal direction: up or down")
+	treeCmd.Flags().BoolVar(&treeExpandInterfaces, "expand-interfaces", false, "Follow calls through interface methods to their implementations")
+	treeCmd.Flags().StringVar(&treeDirection, "direction", "down", "Traversal direction: up, down or both")
 	treeCmd.Flags().BoolVar(&treeExcludeTests, "exclude-tests", false, "Exclude test files")
 	treeCmd.Flags().BoolVar(&treeExcludeStdlib, "exclude-stdlib", false, "Exclude standard library")
+	treeCmd.Flags().BoolVar(&treeCycles, "cycles", false, "Report recursive cycles among the explored functions")
+	treeCmd.Flags().StringVar(&treeCollapse, "collapse", "", "Collapse functions into groups: package or file")
 }
 
 func runTree(cmd *cobra.Command, args []string) error {
 	symbolArg := args[0]
-	writer, err := GetWriter(os.Stdout)
+	writer, err := GetWriter(cmd.OutOrStdout())
 	if err != nil {
 		return fmt.Errorf("invalid output format: %w", err)
 	}
 
 	// Parse symbol
-	query, err := symbols.Parse(symbolArg)
+	query, err := parseSymbol(symbolArg)
 	if err != nil {
 		return writer.WriteError("parse_error", err.Error(), nil, nil)
 	}
@@ -65,8 +83,14 @@ func runTree(cmd *cobra.Command, args []string) error {
 		direction = traverse.Up
 	case "down":
 		direction = traverse.Down
+	case "both":
+		direction = traverse.Both
 	default:
-		return writer.WriteError("invalid_argument", "direction must be 'up' or 'down'", nil, nil)
+		return writer.WriteError("invalid_argument", "direction must be 'up', 'down' or 'both'", nil, nil)
+	}
+
+	if werr := validateCollapse(treeCollapse); werr != nil {
+		return writeError(writer, werr)
 	}
 
 	// Get working directory
@@ -75,94 +99,66 @@ func runTree(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting working directory: %w", err)
 	}
 
-	// Start LSP client
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
 	defer cancel()
 
-	config, err := GetServerConfig(workDir)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			err.Error(),
-			nil,
-			nil,
-		)
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
+	defer release()
 
-	client, err := lsp.NewClient(ctx, config)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeServerNotFound),
-			fmt.Sprintf("Failed to start language server: %v", err),
-			nil,
-			map[string]any{"server": config.Command},
-		)
+	var methods []string
+	if direction != traverse.Down {
+		methods = append(methods, "callHierarchy/incomingCalls")
 	}
-	defer client.Close()
-
-	if err := client.Initialize(ctx); err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("LSP initialization failed: %v", err),
-			nil,
-			nil,
-		)
+	if direction != traverse.Up {
+		methods = append(methods, "callHierarchy/outgoingCalls")
 	}
-	defer client.Shutdown(ctx)
-
-	time.Sleep(200 * time.Millisecond)
-
-	// Resolve symbol
-	resolver := symbols.NewResolver(client)
-	resolved, err := resolver.Resolve(ctx, query)
-	if err != nil {
-		if we, ok := err.(*errors.WildcatError); ok {
-			return writer.WriteError(string(we.Code), we.Message, we.Suggestions, we.Context)
+	for _, method := range methods {
+		if werr := requireCapability(client, method); werr != nil {
+			return writeError(writer, werr)
 		}
-		return writer.WriteError(string(errors.CodeSymbolNotFound), err.Error(), nil, nil)
 	}
 
-	// Prepare call hierarchy
-	items, err := client.PrepareCallHierarchy(ctx, resolved.URI, resolved.Position)
-	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to prepare call hierarchy: %v", err),
-			nil,
-			nil,
-		)
+	if treeExpandInterfaces {
+		if werr := requireCapability(client, "textDocument/implementation"); werr != nil {
+			return writeError(writer, werr)
+		}
 	}
 
-	if len(items) == 0 {
-		return writer.WriteError(
-			string(errors.CodeSymbolNotFound),
-			fmt.Sprintf("No call hierarchy found for '%s'", query.Raw),
-			nil,
-			nil,
-		)
+	resolved, item, werr := resolveCallItem(ctx, client, query)
+	if werr != nil {
+		return writeError(writer, werr)
 	}
 
 	// Build tree
 	traverser := traverse.NewTraverser(client)
 	opts := traverse.Options{
-		Direction:     direction,
-		MaxDepth:      treeDepth,
-		ExcludeTests:  treeExcludeTests,
-		ExcludeStdlib: treeExcludeStdlib,
+		Direction:        direction,
+		MaxDepth:         treeDepth,
+		ExcludeTests:     treeExcludeTests,
+		ExcludeStdlib:    treeExcludeStdlib,
+		Concurrency:      globalConcurrency,
+		MaxNodes:         globalMaxNodes,
+		MaxEdges:         globalMaxEdges,
+		Timeout:          globalMaxTime,
+		ExpandInterfaces: treeExpandInterfaces,
+		FindCycles:       treeCycles,
 	}
 
-	tree, err := traverser.BuildTree(ctx, items[0], opts)
+	tree, err := traverser.BuildTree(ctx, item, opts)
 	if err != nil {
-		return writer.WriteError(
-			string(errors.CodeLSPError),
-			fmt.Sprintf("Failed to build call tree: %v", err),
-			nil,
-			nil,
-		)
+		return writeError(writer, lspError(err, "Failed to build call tree"))
 	}
 
 	// Update query info
 	tree.Query.Root = resolved.Name
+	tree.Meta = responseMeta(client)
 
+	if treeCollapse != "" {
+		return writer.Write(output.CollapseTree(tree, treeCollapse))
+	}
 	return writer.Write(tree)
 }
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+	"github.com/jasonmoo/wildcat/internal/traverse"
+	"github.com/spf13/cobra"
+)
+
+var unusedCmd = &cobra.Command{
+	Use:   "unused [dir...]",
+	Short: "Find functions no entrypoint reaches",
+	Long: `Find functions and methods that no entrypoint reaches.
+
+Entrypoints are main and init functions, tests, and any function matching
+an --entry pattern, such as HTTP handlers registered by reflection. When
+the workspace has no main function it is treated as a library and its
+exported API is an entrypoint too; --exported forces this.
+
+Reachability follows calls and references that use a function as a value.
+Methods implementing an interface are assumed reachable. Each result
+spans the declaration and gives a reason:
+
+  no_references   Nothing refers to it
+  self_only       Only its own body refers to it (recursion)
+  test_only       Only tests refer to it (with --exclude-tests)
+  unreachable     Only other unused code refers to it
+
+Examples:
+  wildcat unused
+  wildcat unused ./internal --exclude-tests
+  wildcat unused --entry '*Handler' --entry 'api.*'`,
+	RunE: runUnused,
+}
+
+var (
+	unusedEntries      []string
+	unusedExported     bool
+	unusedExcludeTests bool
+)
+
+func init() {
+	rootCmd.AddCommand(unusedCmd)
+
+	unusedCmd.Flags().StringArrayVar(&unusedEntries, "entry", nil, "Glob of functions to treat as entrypoints (repeatable)")
+	unusedCmd.Flags().BoolVar(&unusedExported, "exported", false, "Treat exported functions and methods as entrypoints")
+	unusedCmd.Flags().BoolVar(&unusedExcludeTests, "exclude-tests", false, "Do not treat tests as entrypoints or report test code")
+}
+
+func runUnused(cmd *cobra.Command, args []string) error {
+	writer, err := GetWriter(cmd.OutOrStdout())
+	if err != nil {
+		return fmt.Errorf("invalid output format: %w", err)
+	}
+
+	for _, p := range unusedEntries {
+		if _, err := path.Match(p, ""); err != nil {
+			return writer.WriteError("invalid_argument", fmt.Sprintf("invalid --entry pattern %q: %v", p, err), nil, nil)
+		}
+	}
+
+	// Get working directory
+	workDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("getting working directory: %w", err)
+	}
+
+	spec, err := GetServerSpec()
+	if err != nil {
+		return writer.WriteError(string(errors.CodeServerNotFound), err.Error(), nil, nil)
+	}
+
+	dirs := args
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	files, err := sourceFiles(dirs, spec.Extensions)
+	if err != nil {
+		return writer.WriteError("invalid_argument", err.Error(), nil, nil)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), globalTimeout)
+	defer cancel()
+
+	// Start LSP client
+	client, release, werr := acquireClient(ctx, workDir)
+	if werr != nil {
+		return writeError(writer, werr)
+	}
+	defer release()
+
+	for _, method := range []string{
+		"textDocument/documentSymbol",
+		"callHierarchy/outgoingCalls",
+		"textDocument/references",
+		"textDocument/implementation",
+	} {
+		if werr := requireCapability(client, method); werr != nil {
+			return writeError(writer, werr)
+		}
+	}
+
+	uris := make([]string, len(files))
+	for i, f := range files {
+		uris[i] = lsp.FileURI(f)
+	}
+
+	// Search
+	traverser := traverse.NewTraverser(client)
+	entry := traverse.Entrypoints{
+		Tests:    !unusedExcludeTests,
+		Exported: unusedExported,
+		Patterns: unusedEntries,
+	}
+	opts := traverse.Options{
+		ExcludeTests: unusedExcludeTests,
+		Concurrency:  globalConcurrency,
+		MaxNodes:     globalMaxNodes,
+		MaxEdges:     globalMaxEdges,
+		Timeout:      globalMaxTime,
+	}
+
+	response, err := traverser.FindUnused(ctx, uris, entry, opts)
+	if err != nil {
+		return writeError(writer, lspError(err, "Failed to find unused functions"))
+	}
+
+	response.Query = output.QueryInfo{
+		Command: "unused",
+		Target:  strings.Join(dirs, " "),
+	}
+	response.Meta = responseMeta(client)
+
+	return writer.Write(response)
+}
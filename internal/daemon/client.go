@@ -0,0 +1,104 @@
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// dialTimeout bounds how long we wait for a daemon socket to accept.
+const dialTimeout = 500 * time.Millisecond
+
+// SocketPath returns the unix socket path for a workspace and language.
+func SocketPath(workDir, language string) string {
+	return filepath.Join(runtimeDir(), workspaceKey(workDir, language)+".sock")
+}
+
+// LogPath returns the log file path used by detached daemons.
+func LogPath(workDir, language string) string {
+	return filepath.Join(runtimeDir(), workspaceKey(workDir, language)+".log")
+}
+
+// lockPath returns the lock file path for a workspace and language.
+func lockPath(workDir, language string) string {
+	return filepath.Join(runtimeDir(), workspaceKey(workDir, language)+".lock")
+}
+
+// runtimeDir returns the per-user directory holding sockets and locks.
+func runtimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "wildcat")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("wildcat-%d", os.Getuid()))
+}
+
+// workspaceKey derives a short, stable file name for a workspace and language.
+// Socket paths are length-limited, so the workspace path is hashed.
+func workspaceKey(workDir, language string) string {
+	sum := sha256.Sum256([]byte(filepath.Clean(workDir) + "\x00" + language))
+	return language + "-" + hex.EncodeToString(sum[:8])
+}
+
+// Dial connects to the daemon socket for a workspace and language.
+func Dial(workDir, language string) (net.Conn, error) {
+	nc, err := net.DialTimeout("unix", SocketPath(workDir, language), dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	return nc, nil
+}
+
+// Connect returns an LSP client attached to the daemon serving workDir.
+// Returns ErrNotRunning if there is none.
+func Connect(workDir, language string) (*lsp.Client, error) {
+	nc, err := Dial(workDir, language)
+	if err != nil {
+		return nil, err
+	}
+	return lsp.NewClientConn(nc, workDir), nil
+}
+
+// GetStatus asks the daemon serving workDir for its status.
+// A daemon that is not running reports Running=false rather than an error.
+func GetStatus(ctx context.Context, workDir, language string) (*output.DaemonStatusResponse, error) {
+	stopped := &output.DaemonStatusResponse{
+		WorkDir:  workDir,
+		Language: language,
+		Socket:   SocketPath(workDir, language),
+	}
+
+	client, err := Connect(workDir, language)
+	if err != nil {
+		return stopped, nil
+	}
+	defer client.Close()
+
+	var status output.DaemonStatusResponse
+	if err := client.Conn().Call(ctx, MethodStatus, nil, &status); err != nil {
+		return nil, fmt.Errorf("%s: %w", MethodStatus, err)
+	}
+	return &status, nil
+}
+
+// Stop asks the daemon serving workDir to exit.
+// Returns ErrNotRunning if there is none.
+func Stop(ctx context.Context, workDir, language string) error {
+	client, err := Connect(workDir, language)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Conn().Call(ctx, MethodStop, nil, nil); err != nil {
+		return fmt.Errorf("%s: %w", MethodStop, err)
+	}
+	return nil
+}
@@ -0,0 +1,412 @@
+// Package daemon keeps a language server warm across wildcat invocations.
+//
+// A daemon owns one lsp.Client for a workspace root and language and listens
+// on a per-workspace unix socket. Commands attach to it with Connect and speak
+// plain LSP over the socket; the daemon answers the lifecycle messages itself
+// and forwards everything else to the shared server. It reports file changes
+// in the workspace to the server, so the warm index follows edits.
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// Methods understood by the daemon in addition to forwarded LSP traffic.
+const (
+	MethodStatus = "wildcat/status"
+	MethodStop   = "wildcat/stop"
+)
+
+// ErrRunning is returned by Run when another daemon already serves the workspace.
+var ErrRunning = errors.New("daemon already running")
+
+// ErrNotRunning is returned by Connect when no daemon serves the workspace.
+var ErrNotRunning = errors.New("daemon not running")
+
+// Options configures a daemon.
+type Options struct {
+	WorkDir     string        // Workspace root
+	Language    string        // Language key from internal/servers
+	Server      string        // Server command, reported by status
+	IdleTimeout time.Duration // Exit after this long without clients (0 = never)
+	Ready       lsp.ReadyOptions
+
+	// WatchInterval is how often the workspace is scanned for changed
+	// files to report to the server (0 = default, negative = never).
+	WatchInterval time.Duration
+}
+
+// Daemon proxies LSP requests from many wildcat processes to one language server.
+type Daemon struct {
+	opts    Options
+	client  *lsp.Client
+	socket  string
+	started time.Time
+
+	lastUsed atomic.Int64 // unix nanoseconds
+	conns    atomic.Int32
+	requests atomic.Int64
+
+	readyCh   chan struct{} // closed once the server has finished indexing
+	readiness lsp.Readiness
+
+	mu       sync.Mutex // guards listener
+	listener net.Listener
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// New creates a daemon that forwards requests to an initialized client.
+func New(client *lsp.Client, opts Options) *Daemon {
+	d := &Daemon{
+		opts:    opts,
+		client:  client,
+		socket:  SocketPath(opts.WorkDir, opts.Language),
+		started: time.Now(),
+		readyCh: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	d.touch()
+	return d
+}
+
+// Run acquires the workspace lock, starts the language server and serves
+// clients until stopped, idle, or ctx is cancelled.
+// Returns ErrRunning if another daemon already holds the lock.
+func Run(ctx context.Context, config lsp.ServerConfig, opts Options) error {
+	if err := os.MkdirAll(runtimeDir(), 0700); err != nil {
+		return fmt.Errorf("creating runtime dir: %w", err)
+	}
+
+	lock, err := lockFile(lockPath(opts.WorkDir, opts.Language))
+	if err != nil {
+		return err
+	}
+	defer unlockFile(lock)
+
+	// We hold the lock, so any socket left behind belongs to a dead daemon.
+	socket := SocketPath(opts.WorkDir, opts.Language)
+	_ = os.Remove(socket)
+
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", socket, err)
+	}
+	defer os.Remove(socket)
+
+	client, err := lsp.NewClient(ctx, config)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("starting language server: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Initialize(ctx); err != nil {
+		listener.Close()
+		return fmt.Errorf("initializing language server: %w", err)
+	}
+	defer client.Stop()
+
+	d := New(client, opts)
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.Stop()
+		case <-d.done:
+		}
+	}()
+
+	return d.Serve(listener)
+}
+
+// Serve accepts client connections until the daemon is stopped.
+func (d *Daemon) Serve(l net.Listener) error {
+	d.mu.Lock()
+	d.listener = l
+	d.mu.Unlock()
+
+	// Stop may have run before the listener was set
+	select {
+	case <-d.done:
+		l.Close()
+		return nil
+	default:
+	}
+
+	go d.watchIdle()
+	go d.awaitReady()
+
+	// Scan before accepting, so any edit made after a client attaches is seen
+	if d.opts.WatchInterval >= 0 && d.opts.WorkDir != "" {
+		go d.watchFiles(scanFiles(d.opts.WorkDir))
+	}
+
+	for {
+		nc, err := l.Accept()
+		if err != nil {
+			select {
+			case <-d.done:
+				return nil
+			default:
+				return fmt.Errorf("accepting connection: %w", err)
+			}
+		}
+		go d.serveConn(nc)
+	}
+}
+
+// Stop stops accepting connections and causes Serve to return.
+func (d *Daemon) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.done)
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.listener != nil {
+			d.listener.Close()
+		}
+	})
+}
+
+// awaitReady waits for the server to finish indexing so clients attaching
+// later can skip their own wait.
+func (d *Daemon) awaitReady() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-d.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	readiness, err := d.client.WaitReady(ctx, d.opts.Ready)
+	if err != nil {
+		return
+	}
+	d.readiness = readiness
+	close(d.readyCh)
+}
+
+// waitReady blocks until the server is ready, the daemon stops, or timeout.
+func (d *Daemon) waitReady(timeout time.Duration) bool {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	select {
+	case <-d.readyCh:
+		return true
+	case <-d.done:
+		return false
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Status reports the daemon's current state.
+func (d *Daemon) Status() output.DaemonStatusResponse {
+	now := time.Now()
+	status := output.DaemonStatusResponse{
+		Running:            true,
+		PID:                os.Getpid(),
+		WorkDir:            d.opts.WorkDir,
+		Language:           d.opts.Language,
+		Server:             d.opts.Server,
+		Socket:             d.socket,
+		StartedAt:          d.started.UTC().Format(time.RFC3339),
+		UptimeSeconds:      int(now.Sub(d.started).Seconds()),
+		IdleSeconds:        int(now.Sub(time.Unix(0, d.lastUsed.Load())).Seconds()),
+		IdleTimeoutSeconds: int(d.opts.IdleTimeout.Seconds()),
+		Connections:        int(d.conns.Load()),
+		Requests:           d.requests.Load(),
+	}
+
+	select {
+	case <-d.readyCh:
+		status.Ready = true
+		status.IndexMS = d.readiness.Duration.Milliseconds()
+	default:
+	}
+
+	return status
+}
+
+// message is an incoming JSON-RPC request or notification from a client.
+type message struct {
+	ID     *int64          `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// serveConn reads requests from one client until it disconnects.
+func (d *Daemon) serveConn(nc net.Conn) {
+	defer nc.Close()
+
+	d.conns.Add(1)
+	defer func() {
+		d.touch()
+		d.conns.Add(-1)
+	}()
+
+	// Requests still in flight upstream are cancelled when the client
+	// disconnects or sends $/cancelRequest.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var inflightMu sync.Mutex
+	inflight := make(map[int64]context.CancelFunc)
+
+	reader := bufio.NewReader(nc)
+	var writeMu sync.Mutex
+	reply := func(id int64, result any, rerr *lsp.ResponseError) {
+		resp := lsp.Response{JSONRPC: "2.0", ID: id, Error: rerr}
+		if rerr == nil {
+			data, err := json.Marshal(result)
+			if err != nil {
+				resp.Error = &lsp.ResponseError{Code: lsp.CodeInternalError, Message: err.Error()}
+			} else {
+				resp.Result = data
+			}
+		}
+
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_ = lsp.WriteMessage(nc, resp)
+	}
+
+	for {
+		body, err := lsp.ReadMessage(reader)
+		if err != nil {
+			return
+		}
+		d.touch()
+
+		var msg message
+		if err := json.Unmarshal(body, &msg); err != nil {
+			continue
+		}
+
+		// Notifications
+		if msg.ID == nil {
+			switch msg.Method {
+			case "initialized", "exit":
+				// Lifecycle belongs to the daemon, not its clients
+			case "$/cancelRequest":
+				var p lsp.CancelParams
+				if err := json.Unmarshal(msg.Params, &p); err == nil {
+					inflightMu.Lock()
+					if cancelCall, ok := inflight[p.ID]; ok {
+						cancelCall()
+					}
+					inflightMu.Unlock()
+				}
+			default:
+				_ = d.client.Conn().Notify(msg.Method, msg.Params)
+			}
+			continue
+		}
+
+		id := *msg.ID
+		d.requests.Add(1)
+
+		switch msg.Method {
+		case "initialize":
+			// The upstream server is already initialized; pass on what it
+			// advertised so attached clients can check capabilities
+			reply(id, lsp.InitializeResult{
+				Capabilities: d.client.Capabilities(),
+				ServerInfo:   d.client.ServerInfo(),
+			}, nil)
+		case "shutdown":
+			reply(id, nil, nil)
+		case MethodStatus:
+			reply(id, d.Status(), nil)
+		case MethodStop:
+			reply(id, nil, nil)
+			d.Stop()
+		case lsp.MethodWaitReady:
+			go func(params json.RawMessage) {
+				var opts lsp.ReadyOptions
+				_ = json.Unmarshal(params, &opts)
+				if !d.waitReady(opts.Timeout) {
+					reply(id, nil, &lsp.ResponseError{Code: lsp.CodeInternalError, Message: "server not ready"})
+					return
+				}
+				reply(id, nil, nil)
+			}(msg.Params)
+		default:
+			callCtx, cancelCall := context.WithCancel(ctx)
+			inflightMu.Lock()
+			inflight[id] = cancelCall
+			inflightMu.Unlock()
+
+			go func(method string, params json.RawMessage) {
+				defer func() {
+					inflightMu.Lock()
+					delete(inflight, id)
+					inflightMu.Unlock()
+					cancelCall()
+				}()
+
+				var result json.RawMessage
+				if err := d.client.Conn().Call(callCtx, method, params, &result); err != nil {
+					var rerr *lsp.ResponseError
+					switch {
+					case errors.As(err, &rerr):
+					case callCtx.Err() != nil:
+						rerr = &lsp.ResponseError{Code: lsp.CodeRequestCancelled, Message: err.Error()}
+					default:
+						rerr = &lsp.ResponseError{Code: lsp.CodeInternalError, Message: err.Error()}
+					}
+					reply(id, nil, rerr)
+					return
+				}
+				if result == nil {
+					result = json.RawMessage("null")
+				}
+				reply(id, result, nil)
+			}(msg.Method, msg.Params)
+		}
+	}
+}
+
+// touch records client activity for idle accounting.
+func (d *Daemon) touch() {
+	d.lastUsed.Store(time.Now().UnixNano())
+}
+
+// watchIdle stops the daemon once it has had no clients for IdleTimeout.
+func (d *Daemon) watchIdle() {
+	if d.opts.IdleTimeout <= 0 {
+		return
+	}
+
+	interval := min(d.opts.IdleTimeout/4, 30*time.Second)
+	interval = max(interval, 10*time.Millisecond)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			idle := time.Since(time.Unix(0, d.lastUsed.Load()))
+			if d.conns.Load() == 0 && idle >= d.opts.IdleTimeout {
+				d.Stop()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,309 @@
+//go:build unix
+
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+)
+
+// fakeUpstream answers every request by echoing its method and params.
+func fakeUpstream(t *testing.T) *lsp.Client {
+	t.Helper()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+
+	go func() {
+		reader := bufio.NewReader(serverReader)
+		for {
+			body, err := lsp.ReadMessage(reader)
+			if err != nil {
+				return
+			}
+			var req struct {
+				ID     int64           `json:"id"`
+				Method string          `json:"method"`
+				Params json.RawMessage `json:"params"`
+			}
+			if err := json.Unmarshal(body, &req); err != nil || req.ID == 0 {
+				continue
+			}
+			result, _ := json.Marshal(map[string]any{"method": req.Method, "params": req.Params})
+			lsp.WriteMessage(serverWriter, lsp.Response{JSONRPC: "2.0", ID: req.ID, Result: result})
+		}
+	}()
+
+	rwc := struct {
+		io.Reader
+		io.Writer
+		io.Closer
+	}{clientReader, clientWriter, clientWriter}
+
+	return lsp.NewClientConn(rwc, "/tmp/upstream")
+}
+
+func startDaemon(t *testing.T, opts Options) (*Daemon, string, chan error) {
+	t.Helper()
+	return startDaemonWith(t, fakeUpstream(t), opts)
+}
+
+func startDaemonWith(t *testing.T, upstream *lsp.Client, opts Options) (*Daemon, string, chan error) {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "wc")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "d.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	d := New(upstream, opts)
+	served := make(chan error, 1)
+	go func() {
+		served <- d.Serve(listener)
+	}()
+
+	return d, socket, served
+}
+
+func TestDaemon_PassesCapabilities(t *testing.T) {
+	srv := lsptest.NewServer("/tmp/ws")
+	delete(srv.Capabilities, "typeHierarchyProvider")
+
+	d, socket, _ := startDaemonWith(t, srv.Client(t), Options{WorkDir: "/tmp/ws", Language: "go"})
+	defer d.Stop()
+
+	nc, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client := lsp.NewClientConn(nc, "/tmp/ws")
+	defer client.Close()
+
+	if err := client.Initialize(t.Context()); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	if !client.Supports("callHierarchy/incomingCalls") {
+		t.Error("attached client lost upstream call hierarchy support")
+	}
+	if client.Supports("typeHierarchy/supertypes") {
+		t.Error("attached client reports type hierarchy the upstream lacks")
+	}
+}
+
+func TestDaemon_Forwarding(t *testing.T) {
+	d, socket, served := startDaemon(t, Options{WorkDir: "/tmp/ws", Language: "go"})
+	defer d.Stop()
+
+	nc, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client := lsp.NewClientConn(nc, "/tmp/ws")
+	defer client.Close()
+
+	ctx := t.Context()
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	var echoed struct {
+		Method string            `json:"method"`
+		Params map[string]string `json:"params"`
+	}
+	if err := client.Conn().Call(ctx, "test/echo", map[string]string{"k": "v"}, &echoed); err != nil {
+		t.Fatalf("call: %v", err)
+	}
+	if echoed.Method != "test/echo" || echoed.Params["k"] != "v" {
+		t.Errorf("unexpected forwarded result: %+v", echoed)
+	}
+
+	var status struct {
+		Running     bool  `json:"running"`
+		Connections int   `json:"connections"`
+		Requests    int64 `json:"requests"`
+	}
+	if err := client.Conn().Call(ctx, MethodStatus, nil, &status); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !status.Running || status.Connections != 1 || status.Requests != 3 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	// Detaching a client must not stop the daemon
+	if err := client.Shutdown(ctx); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	select {
+	case err := <-served:
+		t.Fatalf("daemon exited after client shutdown: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	nc, err = net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("redial: %v", err)
+	}
+	client = lsp.NewClientConn(nc, "/tmp/ws")
+	if err := client.Conn().Call(ctx, MethodStop, nil, nil); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+}
+
+func TestDaemon_IdleTimeout(t *testing.T) {
+	_, _, served := startDaemon(t, Options{IdleTimeout: 50 * time.Millisecond})
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not exit when idle")
+	}
+}
+
+func TestDaemon_StopBeforeServe(t *testing.T) {
+	d := New(fakeUpstream(t), Options{})
+	d.Stop()
+
+	dir, err := os.MkdirTemp("", "wc")
+	if err != nil {
+		t.Fatalf("create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	listener, err := net.Listen("unix", filepath.Join(dir, "d.sock"))
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- d.Serve(listener) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Serve returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve ignored an earlier Stop")
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ws.lock")
+
+	first, err := lockFile(path)
+	if err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	if _, err := lockFile(path); err != ErrRunning {
+		t.Errorf("second lock error = %v, want ErrRunning", err)
+	}
+
+	unlockFile(first)
+
+	again, err := lockFile(path)
+	if err != nil {
+		t.Fatalf("lock after unlock: %v", err)
+	}
+	unlockFile(again)
+}
+
+func TestSocketPath(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	a := SocketPath("/home/user/proj", "go")
+	if a != SocketPath("/home/user/proj/", "go") {
+		t.Errorf("SocketPath should ignore trailing slash: %s", a)
+	}
+	if a == SocketPath("/home/user/proj", "python") {
+		t.Error("SocketPath should differ by language")
+	}
+	if a == SocketPath("/home/user/other", "go") {
+		t.Error("SocketPath should differ by workspace")
+	}
+	if filepath.Dir(a) != "/run/user/1000/wildcat" {
+		t.Errorf("SocketPath dir = %s", filepath.Dir(a))
+	}
+}
+
+func TestDaemon_ReportsFileChanges(t *testing.T) {
+	root := t.TempDir()
+	file := filepath.Join(root, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := lsptest.NewServer(root)
+	srv.Func("main", "main", "main.go", 3)
+	srv.Watch("**/*.go")
+	// Reindex the edited file
+	srv.OnChange = func(changes []lsp.FileEvent) {
+		for _, c := range changes {
+			if c.URI == srv.URI("main.go") && c.Type == lsp.FileChanged {
+				srv.Func("main", "reload", "main.go", 5)
+			}
+		}
+	}
+
+	d, socket, _ := startDaemonWith(t, srv.Client(t), Options{WorkDir: root, Language: "go", WatchInterval: 10 * time.Millisecond})
+	defer d.Stop()
+
+	nc, err := net.Dial("unix", socket)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	client := lsp.NewClientConn(nc, root)
+	defer client.Close()
+	if err := client.Initialize(t.Context()); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	found := func() bool {
+		syms, err := client.WorkspaceSymbol(t.Context(), "reload")
+		if err != nil {
+			t.Fatalf("workspace/symbol: %v", err)
+		}
+		return len(syms) > 0
+	}
+	if found() {
+		t.Fatal("found reload before it was written")
+	}
+
+	if err := os.WriteFile(file, []byte("package main\n\nfunc main() {}\n\nfunc reload() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !found() {
+		if time.Now().After(deadline) {
+			t.Fatal("the daemon never reported the edit to the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
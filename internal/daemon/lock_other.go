@@ -0,0 +1,23 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// lockFile is unsupported off unix; the daemon cannot run there.
+func lockFile(path string) (*os.File, error) {
+	return nil, errors.New("daemon is not supported on this platform")
+}
+
+// unlockFile releases a lock taken by lockFile.
+func unlockFile(f *os.File) {}
+
+// DetachAttr returns process attributes that detach a daemon from the
+// invoking terminal's session.
+func DetachAttr() *syscall.SysProcAttr {
+	return nil
+}
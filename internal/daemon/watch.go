@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+)
+
+// defaultWatchInterval is how often the workspace is scanned for changes.
+const defaultWatchInterval = 2 * time.Second
+
+// fileStamp identifies one version of a file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchFiles rescans the workspace, starting from the scan last, and
+// reports the changed files the server's file watchers select, so a warm
+// session does not answer from a stale index. Servers that register no
+// watchers watch for themselves.
+func (d *Daemon) watchFiles(last map[string]fileStamp) {
+	interval := d.opts.WatchInterval
+	if interval == 0 {
+		interval = defaultWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			// Changes made before the server registers are reported once it does
+			if !d.client.Watching() {
+				continue
+			}
+			files := scanFiles(d.opts.WorkDir)
+			if changes := fileChanges(last, files); len(changes) > 0 {
+				_ = d.client.DidChangeWatchedFiles(context.Background(), changes)
+			}
+			last = files
+		}
+	}
+}
+
+// scanFiles stamps every file under root, skipping hidden directories and
+// installed dependencies.
+func scanFiles(root string) map[string]fileStamp {
+	files := make(map[string]fileStamp)
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return files
+}
+
+// fileChanges returns the events that turn the before scan into after,
+// ordered by path.
+func fileChanges(before, after map[string]fileStamp) []lsp.FileEvent {
+	var changes []lsp.FileEvent
+	for path, stamp := range after {
+		old, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, lsp.FileEvent{URI: lsp.FileURI(path), Type: lsp.FileCreated})
+		case old.size != stamp.size || !old.modTime.Equal(stamp.modTime):
+			changes = append(changes, lsp.FileEvent{URI: lsp.FileURI(path), Type: lsp.FileChanged})
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, lsp.FileEvent{URI: lsp.FileURI(path), Type: lsp.FileDeleted})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].URI < changes[j].URI })
+	return changes
+}
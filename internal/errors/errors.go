@@ -3,6 +3,7 @@ package errors
 
 import (
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -20,6 +21,8 @@ const (
 	CodeLSPError        Code = "lsp_error"
 	CodeTimeout         Code = "timeout"
 	CodeServerNotFound  Code = "server_not_found"
+
+	CodeUnsupportedCapability Code = "unsupported_capability"
 )
 
 // WildcatError is a structured error with suggestions for self-correction.
@@ -94,7 +97,11 @@ func NewLoadError(patterns []string, err error) *WildcatError {
 }
 
 // NewLSPError creates an LSP error.
+// Timeouts are passed through unchanged so they keep their code.
 func NewLSPError(method string, err error) *WildcatError {
+	if we, ok := FromError(err); ok && we.Code == CodeTimeout {
+		return we
+	}
 	return &WildcatError{
 		Code:    CodeLSPError,
 		Message: fmt.Sprintf("LSP error in %s: %v", method, err),
@@ -111,6 +118,44 @@ func NewTimeout(operation string) *WildcatError {
 	}
 }
 
+// NewCallTimeout creates a timeout error for an LSP request that did not
+// complete before its deadline.
+func NewCallTimeout(method string) *WildcatError {
+	return &WildcatError{
+		Code:    CodeTimeout,
+		Message: fmt.Sprintf("LSP request '%s' timed out", method),
+		Context: map[string]any{"method": method},
+	}
+}
+
+// NewUnsupportedCapability creates an error for a feature the running
+// language server does not provide. supportedBy lists servers that do.
+func NewUnsupportedCapability(capability, server string, supportedBy []string) *WildcatError {
+	var suggestions []string
+	if len(supportedBy) > 0 {
+		suggestions = []string{fmt.Sprintf("Supported by: %s", strings.Join(supportedBy, ", "))}
+	}
+	return &WildcatError{
+		Code:        CodeUnsupportedCapability,
+		Message:     fmt.Sprintf("Language server %s does not support %s", server, capability),
+		Suggestions: suggestions,
+		Context: map[string]any{
+			"capability":   capability,
+			"server":       server,
+			"supported_by": supportedBy,
+		},
+	}
+}
+
+// FromError returns the first WildcatError in err's chain.
+func FromError(err error) (*WildcatError, bool) {
+	var we *WildcatError
+	if stderrors.As(err, &we) {
+		return we, true
+	}
+	return nil, false
+}
+
 // NewServerNotFound creates a server not found error.
 func NewServerNotFound(language, server string) *WildcatError {
 	return &WildcatError{
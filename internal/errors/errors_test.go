@@ -2,6 +2,7 @@ package errors
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -108,6 +109,43 @@ func TestNewLSPError(t *testing.T) {
 	}
 }
 
+func TestNewCallTimeout(t *testing.T) {
+	err := NewCallTimeout("callHierarchy/incomingCalls")
+	if err.Code != CodeTimeout {
+		t.Errorf("Code = %q, want %q", err.Code, CodeTimeout)
+	}
+	if err.Context["method"] != "callHierarchy/incomingCalls" {
+		t.Errorf("Context[method] = %v", err.Context["method"])
+	}
+}
+
+func TestNewUnsupportedCapability(t *testing.T) {
+	err := NewUnsupportedCapability("callHierarchy/incomingCalls", "pyright", []string{"gopls (go)", "clangd (c)"})
+	if err.Code != CodeUnsupportedCapability {
+		t.Errorf("Code = %q, want %q", err.Code, CodeUnsupportedCapability)
+	}
+	if len(err.Suggestions) != 1 || !strings.Contains(err.Suggestions[0], "gopls (go)") {
+		t.Errorf("Suggestions = %v, want supporting servers", err.Suggestions)
+	}
+}
+
+func TestFromError(t *testing.T) {
+	timeout := NewCallTimeout("workspace/symbol")
+	wrapped := fmt.Errorf("workspace/symbol: %w", timeout)
+
+	if got, ok := FromError(wrapped); !ok || got != timeout {
+		t.Errorf("FromError(wrapped) = %v, %v", got, ok)
+	}
+	if _, ok := FromError(fmt.Errorf("plain")); ok {
+		t.Error("FromError(plain) should not find a WildcatError")
+	}
+
+	// Timeouts keep their code through NewLSPError
+	if got := NewLSPError("workspace/symbol", wrapped); got.Code != CodeTimeout {
+		t.Errorf("NewLSPError(timeout).Code = %q, want %q", got.Code, CodeTimeout)
+	}
+}
+
 func TestSuggestSimilar(t *testing.T) {
 	candidates := []string{
 		"config.Load",
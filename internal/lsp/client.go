@@ -1,17 +1,89 @@
 package lsp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 // Client provides high-level access to LSP server functionality.
 type Client struct {
-	server      *Server
+	server      *Server            // nil when attached over an existing connection
+	rwc         io.ReadWriteCloser // set when attached over an existing connection
+	conn        *Conn
 	rootURI     string
 	initialized bool
+	initResult  InitializeResult // what the server advertised during initialize
+
+	progress      *progressTracker
+	initializedAt time.Time
+	ready         Readiness
+
+	callTimeout time.Duration // per-request deadline (0 = none beyond ctx)
+	retry       RetryPolicy
+	retries     atomic.Int64 // retries performed across all requests
+
+	emulator *callEmulator // set when call hierarchy is emulated
+	emulated atomic.Bool   // whether an emulated answer has been given
+
+	watchers fileWatchers // files the server wants change notifications for
+}
+
+// RetryPolicy configures retries of requests that fail with errors servers
+// return while they are still loading.
+type RetryPolicy struct {
+	MaxRetries int           // Retries per request (0 = never retry)
+	Backoff    time.Duration // Delay before the first retry, doubled each time
+	MaxBackoff time.Duration // Cap on the delay between retries
+	Codes      []int         // Error codes to retry (default TransientCodes)
+}
+
+// TransientCodes are the error codes retried by default: servers return
+// them while indexing, and the same request usually succeeds moments later.
+var TransientCodes = []int{
+	CodeContentModified,
+	CodeServerNotInitialized,
+	CodeRequestCancelled,
+}
+
+// DefaultRetryPolicy is applied to new clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	Backoff:    100 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// transient reports whether err is a server error the policy retries.
+func (p RetryPolicy) transient(err error) bool {
+	rerr, ok := err.(*ResponseError)
+	if !ok {
+		return false
+	}
+	codes := p.Codes
+	if codes == nil {
+		codes = TransientCodes
+	}
+	return slices.Contains(codes, rerr.Code)
+}
+
+// delay returns the backoff before the given retry (1-based).
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+	return d
 }
 
 // NewClient creates a new LSP client with the given server configuration.
@@ -23,10 +95,64 @@ func NewClient(ctx context.Context, config ServerConfig) (*Client, error) {
 
 	rootURI := "file://" + config.WorkDir
 
-	return &Client{
-		server:  server,
-		rootURI: rootURI,
-	}, nil
+	c := &Client{
+		server:   server,
+		conn:     server.Conn(),
+		rootURI:  rootURI,
+		progress: newProgressTracker(),
+		retry:    DefaultRetryPolicy,
+	}
+	c.registerHandlers()
+	return c, nil
+}
+
+// NewClientConn creates a client that speaks LSP over an existing connection,
+// such as the socket of a running wildcat daemon. Closing the client closes rwc.
+func NewClientConn(rwc io.ReadWriteCloser, workDir string) *Client {
+	conn := NewConn(rwc, rwc)
+	go conn.ReadLoop()
+
+	c := &Client{
+		rwc:      rwc,
+		conn:     conn,
+		rootURI:  "file://" + workDir,
+		progress: newProgressTracker(),
+		retry:    DefaultRetryPolicy,
+	}
+	c.registerHandlers()
+	return c
+}
+
+// registerHandlers tracks indexing progress and answers the
+// server-initiated requests that servers commonly block on. We accept
+// registrations and progress tokens, keeping file watchers, but have no
+// settings to offer, so configuration requests get a null per item.
+func (c *Client) registerHandlers() {
+	c.progress.subscribe(c.conn)
+
+	accept := func(json.RawMessage) (any, error) { return nil, nil }
+
+	c.conn.Handle("window/workDoneProgress/create", accept)
+	c.conn.Handle("client/registerCapability", c.watchers.register)
+	c.conn.Handle("client/unregisterCapability", c.watchers.unregister)
+	c.conn.Handle("window/showMessageRequest", accept)
+
+	c.conn.Handle("workspace/configuration", func(params json.RawMessage) (any, error) {
+		var p ConfigurationParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return make([]any, len(p.Items)), nil
+	})
+
+	c.conn.Handle("workspace/workspaceFolders", func(json.RawMessage) (any, error) {
+		return []WorkspaceFolder{{URI: c.rootURI, Name: filepath.Base(URIToPath(c.rootURI))}}, nil
+	})
+
+	c.conn.Handle("workspace/applyEdit", func(json.RawMessage) (any, error) {
+		// Wildcat is read-only
+		return ApplyWorkspaceEditResult{Applied: false, FailureReason: "wildcat does not apply edits"}, nil
+	})
 }
 
 // Initialize performs the LSP initialize handshake.
@@ -51,21 +177,48 @@ func (c *Client) Initialize(ctx context.Context) error {
 				Symbol: WorkspaceSymbolClientCapabilities{
 					DynamicRegistration: false,
 				},
+				Configuration:    true,
+				WorkspaceFolders: true,
+				// Only a daemon reports changes, but a registration is
+				// harmless for a client that exits after one query
+				DidChangeWatchedFiles: DidChangeWatchedFilesClientCapabilities{
+					DynamicRegistration:    true,
+					RelativePatternSupport: true,
+				},
+			},
+			Window: WindowClientCapabilities{
+				WorkDoneProgress: true,
+			},
+			Experimental: map[string]any{
+				// rust-analyzer reports indexing state via experimental/serverStatus
+				"serverStatusNotification": true,
 			},
 		},
 	}
 
 	var result InitializeResult
-	if err := c.server.Conn().Call("initialize", params, &result); err != nil {
-		return fmt.Errorf("initialize: %w", err)
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+	c.initResult = result
+
+	// Without a native call hierarchy, derive one from references and
+	// document symbols when the server has those
+	caps := result.Capabilities
+	if !caps.Supports("callHierarchy/incomingCalls") &&
+		caps.Supports("textDocument/references") &&
+		caps.Supports("textDocument/documentSymbol") &&
+		caps.Supports("textDocument/definition") {
+		c.emulator = newCallEmulator(c)
 	}
 
 	// Send initialized notification
-	if err := c.server.Conn().Notify("initialized", struct{}{}); err != nil {
+	if err := c.conn.Notify("initialized", struct{}{}); err != nil {
 		return fmt.Errorf("initialized notification: %w", err)
 	}
 
 	c.initialized = true
+	c.initializedAt = time.Now()
 	return nil
 }
 
@@ -76,21 +229,121 @@ func (c *Client) Shutdown(ctx context.Context) error {
 	}
 
 	// Send shutdown request
-	if err := c.server.Conn().Call("shutdown", nil, nil); err != nil {
-		return fmt.Errorf("shutdown: %w", err)
+	if err := c.call(ctx, "shutdown", nil, nil); err != nil {
+		return err
 	}
 
 	// Send exit notification
-	if err := c.server.Conn().Notify("exit", nil); err != nil {
+	if err := c.conn.Notify("exit", nil); err != nil {
 		return fmt.Errorf("exit notification: %w", err)
 	}
 
-	return c.server.Stop()
+	return c.Close()
+}
+
+// shutdownTimeout bounds how long Stop waits for the server to shut down.
+var shutdownTimeout = 5 * time.Second
+
+// Stop shuts the server down, waiting a few seconds at most for it to
+// answer, and closes the client if it did not.
+func (c *Client) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := c.Shutdown(ctx); err != nil {
+		c.Close()
+	}
 }
 
 // Close closes the client connection.
 func (c *Client) Close() error {
-	return c.server.Stop()
+	if c.server != nil {
+		return c.server.Stop()
+	}
+	c.conn.Close()
+	return c.rwc.Close()
+}
+
+// Conn returns the underlying JSON-RPC connection.
+func (c *Client) Conn() *Conn {
+	return c.conn
+}
+
+// Capabilities returns the capabilities the server advertised during
+// initialize. It is empty before Initialize.
+func (c *Client) Capabilities() ServerCapabilities {
+	return c.initResult.Capabilities
+}
+
+// Supports reports whether the server advertised support for method, or
+// the client emulates it.
+func (c *Client) Supports(method string) bool {
+	if c.emulator != nil && (strings.HasPrefix(method, "callHierarchy/") || method == "textDocument/prepareCallHierarchy") {
+		return true
+	}
+	return c.initResult.Capabilities.Supports(method)
+}
+
+// Emulated reports whether any call hierarchy answer so far was derived
+// from references rather than given by the server.
+func (c *Client) Emulated() bool {
+	return c.emulated.Load()
+}
+
+// Root returns the path of the workspace root.
+func (c *Client) Root() string {
+	return URIToPath(c.rootURI)
+}
+
+// ServerInfo returns the name and version the server reported, if any.
+func (c *Client) ServerInfo() *ServerInfo {
+	return c.initResult.ServerInfo
+}
+
+// SetCallTimeout bounds every subsequent request to d in addition to the
+// caller's context. Zero disables the per-request bound.
+func (c *Client) SetCallTimeout(d time.Duration) {
+	c.callTimeout = d
+}
+
+// SetRetryPolicy replaces the policy used to retry transient errors.
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	c.retry = p
+}
+
+// Retries returns how many requests have been retried so far.
+func (c *Client) Retries() int {
+	return int(c.retries.Load())
+}
+
+// call issues a request, applying the per-request timeout to each attempt
+// and retrying transient server errors according to the retry policy.
+func (c *Client) call(ctx context.Context, method string, params, result any) error {
+	for attempt := 0; ; attempt++ {
+		err := c.callOnce(ctx, method, params, result)
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.retry.MaxRetries || !c.retry.transient(err) || ctx.Err() != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+
+		c.retries.Add(1)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", method, err)
+		case <-time.After(c.retry.delay(attempt + 1)):
+		}
+	}
+}
+
+// callOnce issues a single attempt of a request.
+func (c *Client) callOnce(ctx context.Context, method string, params, result any) error {
+	if c.callTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.callTimeout)
+		defer cancel()
+	}
+	return c.conn.Call(ctx, method, params, result)
 }
 
 // WorkspaceSymbol searches for symbols in the workspace.
@@ -100,8 +353,8 @@ func (c *Client) WorkspaceSymbol(ctx context.Context, query string) ([]SymbolInf
 	}
 
 	var result []SymbolInformation
-	if err := c.server.Conn().Call("workspace/symbol", params, &result); err != nil {
-		return nil, fmt.Errorf("workspace/symbol: %w", err)
+	if err := c.call(ctx, "workspace/symbol", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -109,6 +362,11 @@ func (c *Client) WorkspaceSymbol(ctx context.Context, query string) ([]SymbolInf
 
 // PrepareCallHierarchy prepares a call hierarchy for a position.
 func (c *Client) PrepareCallHierarchy(ctx context.Context, uri string, pos Position) ([]CallHierarchyItem, error) {
+	if c.emulator != nil {
+		c.emulated.Store(true)
+		return c.emulator.prepare(ctx, uri, pos)
+	}
+
 	params := CallHierarchyPrepareParams{
 		TextDocumentPositionParams: TextDocumentPositionParams{
 			TextDocument: TextDocumentIdentifier{URI: uri},
@@ -117,8 +375,8 @@ func (c *Client) PrepareCallHierarchy(ctx context.Context, uri string, pos Posit
 	}
 
 	var result []CallHierarchyItem
-	if err := c.server.Conn().Call("textDocument/prepareCallHierarchy", params, &result); err != nil {
-		return nil, fmt.Errorf("textDocument/prepareCallHierarchy: %w", err)
+	if err := c.call(ctx, "textDocument/prepareCallHierarchy", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -126,13 +384,18 @@ func (c *Client) PrepareCallHierarchy(ctx context.Context, uri string, pos Posit
 
 // IncomingCalls returns the callers of a call hierarchy item.
 func (c *Client) IncomingCalls(ctx context.Context, item CallHierarchyItem) ([]CallHierarchyIncomingCall, error) {
+	if c.emulator != nil {
+		c.emulated.Store(true)
+		return c.emulator.incoming(ctx, item)
+	}
+
 	params := CallHierarchyIncomingCallsParams{
 		Item: item,
 	}
 
 	var result []CallHierarchyIncomingCall
-	if err := c.server.Conn().Call("callHierarchy/incomingCalls", params, &result); err != nil {
-		return nil, fmt.Errorf("callHierarchy/incomingCalls: %w", err)
+	if err := c.call(ctx, "callHierarchy/incomingCalls", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -140,13 +403,18 @@ func (c *Client) IncomingCalls(ctx context.Context, item CallHierarchyItem) ([]C
 
 // OutgoingCalls returns the callees of a call hierarchy item.
 func (c *Client) OutgoingCalls(ctx context.Context, item CallHierarchyItem) ([]CallHierarchyOutgoingCall, error) {
+	if c.emulator != nil {
+		c.emulated.Store(true)
+		return c.emulator.outgoing(ctx, item)
+	}
+
 	params := CallHierarchyOutgoingCallsParams{
 		Item: item,
 	}
 
 	var result []CallHierarchyOutgoingCall
-	if err := c.server.Conn().Call("callHierarchy/outgoingCalls", params, &result); err != nil {
-		return nil, fmt.Errorf("callHierarchy/outgoingCalls: %w", err)
+	if err := c.call(ctx, "callHierarchy/outgoingCalls", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -165,13 +433,93 @@ func (c *Client) References(ctx context.Context, uri string, pos Position, inclu
 	}
 
 	var result []Location
-	if err := c.server.Conn().Call("textDocument/references", params, &result); err != nil {
-		return nil, fmt.Errorf("textDocument/references: %w", err)
+	if err := c.call(ctx, "textDocument/references", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
+// Definition finds where the symbol at a position is declared. Servers may
+// answer with a single location, a list or location links; all are
+// returned as locations pointing at the declaration's name.
+func (c *Client) Definition(ctx context.Context, uri string, pos Position) ([]Location, error) {
+	params := DefinitionParams{
+		TextDocumentPositionParams: TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     pos,
+		},
+	}
+
+	var raw json.RawMessage
+	if err := c.call(ctx, "textDocument/definition", params, &raw); err != nil {
+		return nil, err
+	}
+
+	return decodeLocations(raw)
+}
+
+// decodeLocations normalizes a Location | Location[] | LocationLink[] result.
+func decodeLocations(raw json.RawMessage) ([]Location, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, nil
+	}
+
+	if raw[0] == '{' {
+		var loc Location
+		if err := json.Unmarshal(raw, &loc); err != nil {
+			return nil, fmt.Errorf("decoding location: %w", err)
+		}
+		return []Location{loc}, nil
+	}
+
+	var items []struct {
+		Location
+		LocationLink
+	}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("decoding locations: %w", err)
+	}
+
+	locs := make([]Location, len(items))
+	for i, item := range items {
+		if item.TargetURI != "" {
+			locs[i] = Location{URI: item.TargetURI, Range: item.TargetSelectionRange}
+		} else {
+			locs[i] = item.Location
+		}
+	}
+	return locs, nil
+}
+
+// DocumentSymbol lists the symbols declared in a document. Servers that
+// answer with flat SymbolInformation are normalized to DocumentSymbols
+// without children.
+func (c *Client) DocumentSymbol(ctx context.Context, uri string) ([]DocumentSymbol, error) {
+	params := DocumentSymbolParams{
+		TextDocument: TextDocumentIdentifier{URI: uri},
+	}
+
+	var raw []struct {
+		DocumentSymbol
+		Location *Location `json:"location"`
+	}
+	if err := c.call(ctx, "textDocument/documentSymbol", params, &raw); err != nil {
+		return nil, err
+	}
+
+	result := make([]DocumentSymbol, len(raw))
+	for i, r := range raw {
+		result[i] = r.DocumentSymbol
+		if r.Location != nil {
+			result[i].Range = r.Location.Range
+			result[i].SelectionRange = r.Location.Range
+		}
+	}
+	return result, nil
+}
+
 // Implementation finds the implementations of an interface or abstract method.
 func (c *Client) Implementation(ctx context.Context, uri string, pos Position) ([]Location, error) {
 	params := ImplementationParams{
@@ -182,8 +530,8 @@ func (c *Client) Implementation(ctx context.Context, uri string, pos Position) (
 	}
 
 	var result []Location
-	if err := c.server.Conn().Call("textDocument/implementation", params, &result); err != nil {
-		return nil, fmt.Errorf("textDocument/implementation: %w", err)
+	if err := c.call(ctx, "textDocument/implementation", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -199,8 +547,8 @@ func (c *Client) PrepareTypeHierarchy(ctx context.Context, uri string, pos Posit
 	}
 
 	var result []TypeHierarchyItem
-	if err := c.server.Conn().Call("textDocument/prepareTypeHierarchy", params, &result); err != nil {
-		return nil, fmt.Errorf("textDocument/prepareTypeHierarchy: %w", err)
+	if err := c.call(ctx, "textDocument/prepareTypeHierarchy", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -213,8 +561,8 @@ func (c *Client) Supertypes(ctx context.Context, item TypeHierarchyItem) ([]Type
 	}
 
 	var result []TypeHierarchyItem
-	if err := c.server.Conn().Call("typeHierarchy/supertypes", params, &result); err != nil {
-		return nil, fmt.Errorf("typeHierarchy/supertypes: %w", err)
+	if err := c.call(ctx, "typeHierarchy/supertypes", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -227,8 +575,8 @@ func (c *Client) Subtypes(ctx context.Context, item TypeHierarchyItem) ([]TypeHi
 	}
 
 	var result []TypeHierarchyItem
-	if err := c.server.Conn().Call("typeHierarchy/subtypes", params, &result); err != nil {
-		return nil, fmt.Errorf("typeHierarchy/subtypes: %w", err)
+	if err := c.call(ctx, "typeHierarchy/subtypes", params, &result); err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -245,7 +593,7 @@ func (c *Client) DidOpen(ctx context.Context, uri, languageID, text string) erro
 		},
 	}
 
-	return c.server.Conn().Notify("textDocument/didOpen", params)
+	return c.conn.Notify("textDocument/didOpen", params)
 }
 
 // DidClose notifies the server that a document was closed.
@@ -254,7 +602,7 @@ func (c *Client) DidClose(ctx context.Context, uri string) error {
 		TextDocument: TextDocumentIdentifier{URI: uri},
 	}
 
-	return c.server.Conn().Notify("textDocument/didClose", params)
+	return c.conn.Notify("textDocument/didClose", params)
 }
 
 // FileURI converts a file path to a file:// URI.
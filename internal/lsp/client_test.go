@@ -1,7 +1,13 @@
 package lsp
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"testing"
+	"time"
 )
 
 func TestFileURI(t *testing.T) {
@@ -55,3 +61,150 @@ func TestSymbolKindConstants(t *testing.T) {
 		t.Errorf("SymbolKindInterface = %d, want 11", SymbolKindInterface)
 	}
 }
+
+// answerWithErrors replies to each request with the given error codes in
+// turn, then with an empty result once they are exhausted.
+func answerWithErrors(t *testing.T, p *testPipe, codes ...int) {
+	t.Helper()
+	go func() {
+		for i := 0; ; i++ {
+			body, err := ReadMessage(p.reader)
+			if err != nil {
+				return
+			}
+			var req Request
+			if err := json.Unmarshal(body, &req); err != nil {
+				return
+			}
+			resp := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":[]}`, req.ID)
+			if i < len(codes) {
+				resp = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"error":{"code":%d,"message":"busy"}}`, req.ID, codes[i])
+			}
+			fmt.Fprintf(p.writer, "Content-Length: %d\r\n\r\n%s", len(resp), resp)
+		}
+	}()
+}
+
+func TestClient_RetryTransient(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond})
+
+	answerWithErrors(t, p, CodeContentModified, CodeServerNotInitialized, CodeRequestCancelled)
+
+	if _, err := client.WorkspaceSymbol(context.Background(), "x"); err != nil {
+		t.Fatalf("WorkspaceSymbol: %v", err)
+	}
+	if got := client.Retries(); got != 3 {
+		t.Errorf("Retries() = %d, want 3", got)
+	}
+}
+
+func TestClient_RetryExhausted(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 1, Backoff: time.Millisecond})
+
+	answerWithErrors(t, p, CodeContentModified, CodeContentModified)
+
+	_, err := client.WorkspaceSymbol(context.Background(), "x")
+	var rerr *ResponseError
+	if !errors.As(err, &rerr) || rerr.Code != CodeContentModified {
+		t.Fatalf("err = %v, want ContentModified", err)
+	}
+	if got := client.Retries(); got != 1 {
+		t.Errorf("Retries() = %d, want 1", got)
+	}
+}
+
+func TestClient_NoRetryOnPermanentError(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+	client.SetRetryPolicy(RetryPolicy{MaxRetries: 3, Backoff: time.Millisecond})
+
+	answerWithErrors(t, p, CodeInternalError)
+
+	if _, err := client.WorkspaceSymbol(context.Background(), "x"); err == nil {
+		t.Fatal("expected error")
+	}
+	if got := client.Retries(); got != 0 {
+		t.Errorf("Retries() = %d, want 0", got)
+	}
+}
+
+func TestRetryPolicy_Delay(t *testing.T) {
+	p := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, w := range want {
+		if got := p.delay(i + 1); got != w {
+			t.Errorf("delay(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
+
+func TestServerCapabilities_Supports(t *testing.T) {
+	var result InitializeResult
+	body := `{"capabilities":{"referencesProvider":true,"callHierarchyProvider":{"workDoneProgress":true},"typeHierarchyProvider":false,"workspaceSymbolProvider":{"resolveProvider":false}},"serverInfo":{"name":"fake","version":"1.0"}}`
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	tests := []struct {
+		method string
+		want   bool
+	}{
+		{"textDocument/references", true},
+		{"callHierarchy/incomingCalls", true},
+		{"textDocument/prepareCallHierarchy", true},
+		{"typeHierarchy/supertypes", false},
+		{"textDocument/implementation", false},
+		{"workspace/symbol", true},
+		{"initialize", true},
+	}
+	for _, tt := range tests {
+		if got := result.Capabilities.Supports(tt.method); got != tt.want {
+			t.Errorf("Supports(%q) = %v, want %v", tt.method, got, tt.want)
+		}
+	}
+	if result.ServerInfo == nil || result.ServerInfo.Name != "fake" {
+		t.Errorf("ServerInfo = %+v, want fake", result.ServerInfo)
+	}
+}
+
+func TestClient_StopWedgedServer(t *testing.T) {
+	defer func(d time.Duration) { shutdownTimeout = d }(shutdownTimeout)
+	shutdownTimeout = 50 * time.Millisecond
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	server := NewConn(serverReader, serverWriter)
+	wedged := make(chan struct{})
+	defer close(wedged)
+	server.Handle("initialize", func(json.RawMessage) (any, error) { return InitializeResult{}, nil })
+	server.Handle("shutdown", func(json.RawMessage) (any, error) {
+		<-wedged
+		return nil, nil
+	})
+	go server.ReadLoop()
+	defer server.Close()
+
+	client := NewClientConn(struct {
+		io.Reader
+		io.Writer
+		io.Closer
+	}{clientReader, clientWriter, clientWriter}, "/ws")
+	if err := client.Initialize(t.Context()); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		client.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop waited on a wedged server")
+	}
+}
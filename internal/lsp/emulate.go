@@ -0,0 +1,289 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// callEmulator answers call hierarchy requests for servers that lack
+// callHierarchyProvider but offer references and document symbols, such as
+// pyright. It produces the same item shapes as a native call hierarchy:
+//
+//   - prepare finds the function or method enclosing a position
+//   - incoming calls are the references to an item, grouped by the
+//     function enclosing each one
+//   - outgoing calls are the identifiers followed by "(" in an item's
+//     body, resolved with textDocument/definition
+//
+// References outside any function (imports, module-level statements) are
+// not calls from anywhere and are dropped. Call sites found in the source
+// are converted from byte offsets to the UTF-16 offsets LSP positions use.
+type callEmulator struct {
+	client *Client
+
+	mu   sync.Mutex
+	docs map[string]*emulatedDoc // by URI
+}
+
+// emulatedDoc caches what the emulator has read of one document, for as
+// long as the file is unchanged.
+type emulatedDoc struct {
+	modTime time.Time
+	symbols []DocumentSymbol // nil until requested
+	lines   []string         // nil until read
+}
+
+func newCallEmulator(c *Client) *callEmulator {
+	return &callEmulator{
+		client: c,
+		docs:   make(map[string]*emulatedDoc),
+	}
+}
+
+// doc returns the cache of uri, emptied if the file has been modified
+// since it was filled. The caller holds e.mu.
+func (e *callEmulator) doc(uri string) *emulatedDoc {
+	var modTime time.Time
+	if info, err := os.Stat(URIToPath(uri)); err == nil {
+		modTime = info.ModTime()
+	}
+	d, ok := e.docs[uri]
+	if !ok || !d.modTime.Equal(modTime) {
+		d = &emulatedDoc{modTime: modTime}
+		e.docs[uri] = d
+	}
+	return d
+}
+
+// prepare returns the function or method enclosing pos.
+func (e *callEmulator) prepare(ctx context.Context, uri string, pos Position) ([]CallHierarchyItem, error) {
+	item, ok, err := e.enclosing(ctx, uri, pos)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return []CallHierarchyItem{item}, nil
+}
+
+// incoming groups the references to item by their enclosing function.
+func (e *callEmulator) incoming(ctx context.Context, item CallHierarchyItem) ([]CallHierarchyIncomingCall, error) {
+	refs, err := e.client.References(ctx, item.URI, item.SelectionRange.Start, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []CallHierarchyIncomingCall
+	index := make(map[string]int)
+	for _, ref := range refs {
+		if ref.URI == item.URI && ref.Range.Start == item.SelectionRange.Start {
+			continue // the declaration itself
+		}
+
+		from, ok, err := e.enclosing(ctx, ref.URI, ref.Range.Start)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		key := itemKey(from)
+		i, seen := index[key]
+		if !seen {
+			i = len(result)
+			index[key] = i
+			result = append(result, CallHierarchyIncomingCall{From: from})
+		}
+		result[i].FromRanges = append(result[i].FromRanges, ref.Range)
+	}
+
+	return result, nil
+}
+
+// outgoing resolves the call sites in item's body to the functions they call.
+func (e *callEmulator) outgoing(ctx context.Context, item CallHierarchyItem) ([]CallHierarchyOutgoingCall, error) {
+	lines, err := e.source(item.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []CallHierarchyOutgoingCall
+	index := make(map[string]int)
+	for _, site := range callSites(lines, item) {
+		defs, err := e.client.Definition(ctx, item.URI, site.Start)
+		if err != nil {
+			return nil, err
+		}
+		if len(defs) == 0 {
+			continue
+		}
+
+		def := defs[0]
+		if def.URI == item.URI && def.Range.Contains(site.Start) {
+			continue // a nested declaration, not a call
+		}
+
+		to, ok, err := e.enclosing(ctx, def.URI, def.Range.Start)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || !to.SelectionRange.Contains(def.Range.Start) {
+			continue // resolved to something other than a function
+		}
+
+		key := itemKey(to)
+		i, seen := index[key]
+		if !seen {
+			i = len(result)
+			index[key] = i
+			result = append(result, CallHierarchyOutgoingCall{To: to})
+		}
+		result[i].FromRanges = append(result[i].FromRanges, site)
+	}
+
+	return result, nil
+}
+
+// enclosing finds the innermost function or method in uri containing pos.
+func (e *callEmulator) enclosing(ctx context.Context, uri string, pos Position) (CallHierarchyItem, bool, error) {
+	syms, err := e.documentSymbols(ctx, uri)
+	if err != nil {
+		return CallHierarchyItem{}, false, err
+	}
+
+	var best *DocumentSymbol
+	var walk func([]DocumentSymbol)
+	walk = func(syms []DocumentSymbol) {
+		for i := range syms {
+			s := &syms[i]
+			if !s.Range.Contains(pos) {
+				continue
+			}
+			if callable(s.Kind) && (best == nil || best.Range.Contains(s.Range.Start)) {
+				best = s
+			}
+			walk(s.Children)
+		}
+	}
+	walk(syms)
+
+	if best == nil {
+		return CallHierarchyItem{}, false, nil
+	}
+	return CallHierarchyItem{
+		Name:           best.Name,
+		Kind:           best.Kind,
+		Detail:         best.Detail,
+		URI:            uri,
+		Range:          best.Range,
+		SelectionRange: best.SelectionRange,
+	}, true, nil
+}
+
+// documentSymbols returns the cached symbols of a document.
+func (e *callEmulator) documentSymbols(ctx context.Context, uri string) ([]DocumentSymbol, error) {
+	e.mu.Lock()
+	d := e.doc(uri)
+	syms := d.symbols
+	e.mu.Unlock()
+	if syms != nil {
+		return syms, nil
+	}
+
+	syms, err := e.client.DocumentSymbol(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	d.symbols = syms
+	e.mu.Unlock()
+	return syms, nil
+}
+
+// source returns the cached lines of a document.
+func (e *callEmulator) source(uri string) ([]string, error) {
+	e.mu.Lock()
+	d := e.doc(uri)
+	lines := d.lines
+	e.mu.Unlock()
+	if lines != nil {
+		return lines, nil
+	}
+
+	data, err := os.ReadFile(URIToPath(uri))
+	if err != nil {
+		return nil, err
+	}
+	lines = strings.Split(string(data), "\n")
+
+	e.mu.Lock()
+	d.lines = lines
+	e.mu.Unlock()
+	return lines, nil
+}
+
+// callSites returns the ranges of identifiers followed by "(" within the
+// body of item, skipping its own name and common control keywords.
+func callSites(lines []string, item CallHierarchyItem) []Range {
+	var sites []Range
+	for ln := item.Range.Start.Line; ln <= item.Range.End.Line && ln < len(lines); ln++ {
+		line := lines[ln]
+		for i := 0; i < len(line); {
+			if !identStart(line[i]) {
+				i++
+				continue
+			}
+			start := i
+			for i < len(line) && identPart(line[i]) {
+				i++
+			}
+			name := line[start:i]
+
+			j := i
+			for j < len(line) && (line[j] == ' ' || line[j] == '\t') {
+				j++
+			}
+			if j >= len(line) || line[j] != '(' || nonCallKeywords[name] {
+				continue
+			}
+
+			pos := Position{Line: ln, Character: UTF16Offset(line, start)}
+			if item.SelectionRange.Contains(pos) {
+				continue
+			}
+			sites = append(sites, Range{Start: pos, End: Position{Line: ln, Character: UTF16Offset(line, i)}})
+		}
+	}
+	return sites
+}
+
+// nonCallKeywords are words commonly followed by "(" that are not calls.
+var nonCallKeywords = map[string]bool{
+	"if": true, "elif": true, "while": true, "for": true, "switch": true,
+	"return": true, "and": true, "or": true, "not": true, "in": true,
+	"def": true, "class": true, "lambda": true, "yield": true, "await": true,
+	"assert": true, "with": true, "except": true, "catch": true,
+}
+
+func identStart(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func identPart(b byte) bool {
+	return identStart(b) || (b >= '0' && b <= '9')
+}
+
+// callable reports whether a symbol kind can appear in a call hierarchy.
+func callable(kind SymbolKind) bool {
+	return kind == SymbolKindFunction || kind == SymbolKindMethod || kind == SymbolKindConstructor
+}
+
+// itemKey identifies an item by where it is declared.
+func itemKey(item CallHierarchyItem) string {
+	p := item.SelectionRange.Start
+	return fmt.Sprintf("%s#%s@%d:%d", item.URI, item.Name, p.Line, p.Character)
+}
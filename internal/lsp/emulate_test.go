@@ -0,0 +1,155 @@
+package lsp_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+)
+
+const emulatedSource = `def main():
+    run()
+
+def run():
+    load()
+    if (ready()):
+        load()
+
+def load():
+    pass
+
+def ready():
+    return True
+`
+
+// newEmulatedServer models emulatedSource on a server without call hierarchy.
+func newEmulatedServer(t *testing.T) (*lsptest.Server, map[string]*lsptest.Symbol) {
+	t.Helper()
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "app.py"), []byte(emulatedSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := lsptest.NewServer(root)
+	delete(srv.Capabilities, "callHierarchyProvider")
+
+	def := func(name string, line, end int) *lsptest.Symbol {
+		return srv.Add(lsptest.Symbol{Name: name, Kind: lsp.SymbolKindFunction, File: "app.py", Line: line, Column: 4, EndLine: end})
+	}
+	syms := map[string]*lsptest.Symbol{
+		"main":  def("main", 1, 2),
+		"run":   def("run", 4, 7),
+		"load":  def("load", 9, 10),
+		"ready": def("ready", 12, 13),
+	}
+	srv.Call(syms["main"], syms["run"], 2)
+	srv.Call(syms["run"], syms["load"], 5)
+	srv.Call(syms["run"], syms["ready"], 6)
+	srv.Call(syms["run"], syms["load"], 7)
+	return srv, syms
+}
+
+func TestEmulatedCallHierarchy(t *testing.T) {
+	srv, _ := newEmulatedServer(t)
+	client := srv.Client(t)
+	ctx := t.Context()
+
+	if !client.Supports("callHierarchy/incomingCalls") {
+		t.Fatal("client should emulate call hierarchy")
+	}
+
+	// Any position inside run's body prepares run
+	items, err := client.PrepareCallHierarchy(ctx, srv.URI("app.py"), lsp.Position{Line: 4, Character: 6})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("PrepareCallHierarchy = %v, %v", items, err)
+	}
+	run := items[0]
+	if run.Name != "run" || run.SelectionRange.Start != (lsp.Position{Line: 3, Character: 4}) {
+		t.Fatalf("prepared %+v, want run at 3:4", run)
+	}
+
+	incoming, err := client.IncomingCalls(ctx, run)
+	if err != nil {
+		t.Fatalf("IncomingCalls: %v", err)
+	}
+	if len(incoming) != 1 || incoming[0].From.Name != "main" || len(incoming[0].FromRanges) != 1 {
+		t.Errorf("incoming = %+v, want one call from main", incoming)
+	}
+
+	outgoing, err := client.OutgoingCalls(ctx, run)
+	if err != nil {
+		t.Fatalf("OutgoingCalls: %v", err)
+	}
+	var callees []string
+	for _, call := range outgoing {
+		callees = append(callees, call.To.Name)
+	}
+	if !slices.Equal(callees, []string{"load", "ready"}) {
+		t.Errorf("callees = %v, want [load ready]", callees)
+	}
+	if len(outgoing) > 0 && len(outgoing[0].FromRanges) != 2 {
+		t.Errorf("load called from %d sites, want 2", len(outgoing[0].FromRanges))
+	}
+
+	if !client.Emulated() {
+		t.Error("Emulated() = false after emulated answers")
+	}
+	if slices.Contains(srv.Requests(), "callHierarchy/incomingCalls") {
+		t.Error("emulating client sent call hierarchy requests to the server")
+	}
+}
+
+func TestNativeCallHierarchyNotEmulated(t *testing.T) {
+	srv := lsptest.NewServer("/ws")
+	f := srv.Func("main", "main", "main.go", 3)
+	client := srv.Client(t)
+
+	if _, err := client.PrepareCallHierarchy(t.Context(), srv.URI(f.File), lsp.Position{Line: 2}); err != nil {
+		t.Fatalf("PrepareCallHierarchy: %v", err)
+	}
+	if client.Emulated() {
+		t.Error("Emulated() = true with a native call hierarchy")
+	}
+}
+
+func TestEmulatedCallHierarchy_Edited(t *testing.T) {
+	srv, _ := newEmulatedServer(t)
+	client := srv.Client(t)
+	ctx := t.Context()
+
+	items, err := client.PrepareCallHierarchy(ctx, srv.URI("app.py"), lsp.Position{Line: 4, Character: 6})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("PrepareCallHierarchy = %v, %v", items, err)
+	}
+	site := func() lsp.Position {
+		t.Helper()
+		outgoing, err := client.OutgoingCalls(ctx, items[0])
+		if err != nil || len(outgoing) == 0 {
+			t.Fatalf("OutgoingCalls = %v, %v", outgoing, err)
+		}
+		return outgoing[0].FromRanges[0].Start
+	}
+	if got := site(); got != (lsp.Position{Line: 4, Character: 4}) {
+		t.Fatalf("load called at %+v, want 4:4", got)
+	}
+
+	// The edit is seen, and the column counts é as one UTF-16 unit
+	file := lsp.URIToPath(srv.URI("app.py"))
+	edited := strings.Replace(emulatedSource, "    load()\n    if", "    x = \"é\"; load()\n    if", 1)
+	if err := os.WriteFile(file, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := site(); got != (lsp.Position{Line: 4, Character: 13}) {
+		t.Errorf("load called at %+v after the edit, want 4:13", got)
+	}
+}
@@ -66,8 +66,10 @@ func hello() {
 		t.Fatalf("initialize: %v", err)
 	}
 
-	// Give gopls a moment to index
-	time.Sleep(500 * time.Millisecond)
+	// Wait for gopls to finish indexing
+	if _, err := client.WaitReady(ctx, lsp.ReadyOptions{}); err != nil {
+		t.Fatalf("wait ready: %v", err)
+	}
 
 	// Test workspace/symbol
 	t.Run("WorkspaceSymbol", func(t *testing.T) {
@@ -2,6 +2,7 @@ package lsp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,6 +10,8 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
 )
 
 // Request represents a JSON-RPC 2.0 request.
@@ -38,6 +41,43 @@ func (e *ResponseError) Error() string {
 	return fmt.Sprintf("LSP error %d: %s", e.Code, e.Message)
 }
 
+// JSON-RPC and LSP error codes.
+const (
+	CodeMethodNotFound       = -32601
+	CodeInternalError        = -32603
+	CodeServerNotInitialized = -32002
+	CodeRequestCancelled     = -32800
+	CodeContentModified      = -32801
+)
+
+// Handler answers a server-initiated request. The returned value is sent as
+// the result; a returned *ResponseError is sent as-is and any other error is
+// reported as an internal error.
+type Handler func(params json.RawMessage) (any, error)
+
+// NotificationHandler receives a server-initiated notification.
+// It runs on the read loop and must not block.
+type NotificationHandler func(params json.RawMessage)
+
+// message is any incoming JSON-RPC message: a response to one of our calls,
+// or a request or notification initiated by the server.
+type message struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *ResponseError  `json:"error,omitempty"`
+}
+
+// reply is a response to a server-initiated request. Server IDs may be
+// numbers or strings, so the ID is echoed back verbatim.
+type reply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *ResponseError  `json:"error,omitempty"`
+}
+
 // Conn handles JSON-RPC communication over stdio.
 type Conn struct {
 	reader *bufio.Reader
@@ -49,21 +89,71 @@ type Conn struct {
 	pending   map[int64]chan *Response
 	pendingMu sync.Mutex
 
+	// handlers answer server-initiated requests by method
+	handlers map[string]Handler
+	// subscribers receive server-initiated notifications by method
+	subscribers map[string][]*subscriber
+	handlersMu  sync.RWMutex
+
+	// recorder, when set, receives a copy of every message
+	recorder atomic.Pointer[Recorder]
+
 	closed atomic.Bool
 }
 
+// subscriber wraps a NotificationHandler so it can be removed by identity.
+type subscriber struct {
+	fn NotificationHandler
+}
+
 // NewConn creates a new JSON-RPC connection.
 func NewConn(r io.Reader, w io.Writer) *Conn {
 	c := &Conn{
-		reader:  bufio.NewReader(r),
-		writer:  w,
-		pending: make(map[int64]chan *Response),
+		reader:      bufio.NewReader(r),
+		writer:      w,
+		pending:     make(map[int64]chan *Response),
+		handlers:    make(map[string]Handler),
+		subscribers: make(map[string][]*subscriber),
 	}
 	return c
 }
 
-// Call sends a request and waits for the response.
-func (c *Conn) Call(method string, params any, result any) error {
+// Handle registers h to answer server-initiated requests for method,
+// replacing any previous handler. Requests without a handler are answered
+// with a MethodNotFound error.
+func (c *Conn) Handle(method string, h Handler) {
+	c.handlersMu.Lock()
+	defer c.handlersMu.Unlock()
+	c.handlers[method] = h
+}
+
+// Subscribe registers fn to receive notifications for method and returns a
+// function that removes it. Multiple subscribers receive every notification
+// in registration order.
+func (c *Conn) Subscribe(method string, fn NotificationHandler) func() {
+	sub := &subscriber{fn: fn}
+
+	c.handlersMu.Lock()
+	c.subscribers[method] = append(c.subscribers[method], sub)
+	c.handlersMu.Unlock()
+
+	return func() {
+		c.handlersMu.Lock()
+		defer c.handlersMu.Unlock()
+		subs := c.subscribers[method]
+		for i, s := range subs {
+			if s == sub {
+				c.subscribers[method] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Call sends a request and waits for the response or for ctx to end.
+// When ctx ends first the server is sent $/cancelRequest for the abandoned
+// request, and a deadline surfaces as a timeout error naming the method.
+func (c *Conn) Call(ctx context.Context, method string, params any, result any) error {
 	if c.closed.Load() {
 		return fmt.Errorf("connection closed")
 	}
@@ -95,7 +185,17 @@ func (c *Conn) Call(method string, params any, result any) error {
 	}
 
 	// Wait for response
-	resp := <-respCh
+	var resp *Response
+	select {
+	case resp = <-respCh:
+	case <-ctx.Done():
+		// Tell the server to stop working on a request nobody is waiting for
+		_ = c.Notify("$/cancelRequest", CancelParams{ID: id})
+		if ctx.Err() == context.DeadlineExceeded {
+			return errors.NewCallTimeout(method)
+		}
+		return fmt.Errorf("%s: %w", method, ctx.Err())
+	}
 	if resp == nil {
 		return fmt.Errorf("connection closed while waiting for response")
 	}
@@ -128,7 +228,8 @@ func (c *Conn) Notify(method string, params any) error {
 	return c.send(req)
 }
 
-// ReadLoop reads responses and dispatches them to waiting callers.
+// ReadLoop reads incoming messages, dispatching responses to waiting callers,
+// server requests to handlers and notifications to subscribers.
 // This should be called in a goroutine.
 func (c *Conn) ReadLoop() error {
 	for {
@@ -136,30 +237,94 @@ func (c *Conn) ReadLoop() error {
 			return nil
 		}
 
-		resp, err := c.readResponse()
+		msg, err := c.readMessage()
 		if err != nil {
 			if c.closed.Load() {
 				return nil
 			}
-			return fmt.Errorf("reading response: %w", err)
+			return fmt.Errorf("reading message: %w", err)
 		}
 
-		// Dispatch to waiting caller
-		c.pendingMu.Lock()
-		ch, ok := c.pending[resp.ID]
-		c.pendingMu.Unlock()
+		switch {
+		case msg.Method != "" && len(msg.ID) > 0:
+			go c.handleRequest(msg)
+		case msg.Method != "":
+			c.notifySubscribers(msg)
+		default:
+			c.dispatchResponse(msg)
+		}
+	}
+}
+
+// dispatchResponse hands a response to the caller waiting on its ID.
+func (c *Conn) dispatchResponse(msg *message) {
+	id, err := strconv.ParseInt(string(msg.ID), 10, 64)
+	if err != nil {
+		return // Not one of ours; we only issue numeric IDs
+	}
+
+	c.pendingMu.Lock()
+	ch, ok := c.pending[id]
+	c.pendingMu.Unlock()
+
+	if ok {
+		ch <- &Response{JSONRPC: "2.0", ID: id, Result: msg.Result, Error: msg.Error}
+	}
+	// Ignore responses for unknown IDs
+}
 
-		if ok {
-			ch <- resp
+// handleRequest answers a server-initiated request.
+func (c *Conn) handleRequest(msg *message) {
+	c.handlersMu.RLock()
+	h, ok := c.handlers[msg.Method]
+	c.handlersMu.RUnlock()
+
+	resp := reply{JSONRPC: "2.0", ID: msg.ID}
+	if !ok {
+		resp.Error = &ResponseError{
+			Code:    CodeMethodNotFound,
+			Message: fmt.Sprintf("method not found: %s", msg.Method),
 		}
-		// Ignore responses for unknown IDs (could be notifications we don't handle)
+	} else if result, err := h(msg.Params); err != nil {
+		rerr, ok := err.(*ResponseError)
+		if !ok {
+			rerr = &ResponseError{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Error = rerr
+	} else if result == nil {
+		resp.Result = json.RawMessage("null") // result is required on success
+	} else {
+		resp.Result = result
+	}
+
+	_ = c.send(resp)
+}
+
+// notifySubscribers fans a notification out to every subscriber of its method.
+func (c *Conn) notifySubscribers(msg *message) {
+	c.handlersMu.RLock()
+	subs := append([]*subscriber(nil), c.subscribers[msg.Method]...)
+	c.handlersMu.RUnlock()
+
+	for _, sub := range subs {
+		sub.fn(msg.Params)
 	}
 }
 
+// Record tees every message sent or received from now on into r.
+// The recorder is closed along with the connection.
+func (c *Conn) Record(r *Recorder) {
+	c.recorder.Store(r)
+}
+
 // Close marks the connection as closed.
 func (c *Conn) Close() {
 	c.closed.Store(true)
 
+	if r := c.recorder.Load(); r != nil {
+		_ = r.Close()
+	}
+
 	// Close all pending channels
 	c.pendingMu.Lock()
 	for _, ch := range c.pending {
@@ -171,31 +336,62 @@ func (c *Conn) Close() {
 
 // send writes a message with LSP headers.
 func (c *Conn) send(msg any) error {
-	data, err := json.Marshal(msg)
+	body, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("marshaling message: %w", err)
 	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if r := c.recorder.Load(); r != nil {
+		r.record(DirSend, body)
+	}
+	return WriteMessage(c.writer, json.RawMessage(body))
+}
+
+// readMessage reads a single incoming message of any kind.
+func (c *Conn) readMessage() (*message, error) {
+	body, err := ReadMessage(c.reader)
+	if err != nil {
+		return nil, err
+	}
+	if r := c.recorder.Load(); r != nil {
+		r.record(DirRecv, body)
+	}
+
+	var msg message
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("unmarshaling message: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// WriteMessage marshals msg and writes it with LSP headers.
+// Callers sharing a writer must serialize calls themselves.
+func WriteMessage(w io.Writer, msg any) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshaling message: %w", err)
+	}
 
 	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(data))
-	if _, err := io.WriteString(c.writer, header); err != nil {
+	if _, err := io.WriteString(w, header); err != nil {
 		return fmt.Errorf("writing header: %w", err)
 	}
-	if _, err := c.writer.Write(data); err != nil {
+	if _, err := w.Write(data); err != nil {
 		return fmt.Errorf("writing body: %w", err)
 	}
 
 	return nil
 }
 
-// readResponse reads a single LSP response.
-func (c *Conn) readResponse() (*Response, error) {
+// ReadMessage reads the body of a single LSP message.
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
 	// Read headers
 	var contentLength int
 	for {
-		line, err := c.reader.ReadString('\n')
+		line, err := r.ReadString('\n')
 		if err != nil {
 			return nil, fmt.Errorf("reading header line: %w", err)
 		}
@@ -220,14 +416,9 @@ func (c *Conn) readResponse() (*Response, error) {
 
 	// Read body
 	body := make([]byte, contentLength)
-	if _, err := io.ReadFull(c.reader, body); err != nil {
+	if _, err := io.ReadFull(r, body); err != nil {
 		return nil, fmt.Errorf("reading body: %w", err)
 	}
 
-	var resp Response
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("unmarshaling response: %w", err)
-	}
-
-	return &resp, nil
+	return body, nil
 }
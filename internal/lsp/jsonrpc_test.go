@@ -3,12 +3,15 @@ package lsp
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
 )
 
 func TestConn_SendReceive(t *testing.T) {
@@ -92,7 +95,7 @@ func TestConn_SendReceive(t *testing.T) {
 	done := make(chan error, 1)
 	var result map[string]string
 	go func() {
-		done <- conn.Call("test/method", map[string]string{"msg": "hello"}, &result)
+		done <- conn.Call(context.Background(), "test/method", map[string]string{"msg": "hello"}, &result)
 	}()
 
 	// Wait for result with timeout
@@ -116,13 +119,11 @@ func TestConn_ReadResponse(t *testing.T) {
 	tests := []struct {
 		name    string
 		input   string
-		wantID  int64
 		wantErr bool
 	}{
 		{
-			name:   "valid response",
-			input:  "Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":null}",
-			wantID: 1,
+			name:  "valid response",
+			input: "Content-Length: 38\r\n\r\n{\"jsonrpc\":\"2.0\",\"id\":1,\"result\":\"ok\"}",
 		},
 		{
 			name:    "missing content length",
@@ -131,30 +132,54 @@ func TestConn_ReadResponse(t *testing.T) {
 		},
 		{
 			name:    "invalid json",
-			input:   "Content-Length: 10\r\n\r\n{invalid}",
+			input:   "Content-Length: 9\r\n\r\n{invalid}",
 			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			reader := strings.NewReader(tt.input)
-			conn := NewConn(reader, &bytes.Buffer{})
+			serverReader, clientWriter := io.Pipe()
+			clientReader, serverWriter := io.Pipe()
+			defer serverReader.Close()
+			defer serverWriter.Close()
+
+			conn := NewConn(clientReader, clientWriter)
+			defer conn.Close()
+			loopErr := make(chan error, 1)
+			go func() { loopErr <- conn.ReadLoop() }()
+
+			// Answer the request with the input once it arrives
+			go func() {
+				if _, err := ReadMessage(bufio.NewReader(serverReader)); err != nil {
+					return
+				}
+				io.WriteString(serverWriter, tt.input)
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			done := make(chan error, 1)
+			var result string
+			go func() { done <- conn.Call(ctx, "test/method", nil, &result) }()
 
-			resp, err := conn.readResponse()
 			if tt.wantErr {
-				if err == nil {
-					t.Error("expected error, got nil")
+				select {
+				case err := <-loopErr:
+					if err == nil {
+						t.Error("expected error, got nil")
+					}
+				case err := <-done:
+					t.Errorf("Call returned %v, want the read loop to fail", err)
 				}
 				return
 			}
 
-			if err != nil {
+			if err := <-done; err != nil {
 				t.Fatalf("unexpected error: %v", err)
 			}
-
-			if resp.ID != tt.wantID {
-				t.Errorf("got ID %d, want %d", resp.ID, tt.wantID)
+			if result != "ok" {
+				t.Errorf("result = %q, want %q", result, "ok")
 			}
 		})
 	}
@@ -215,3 +240,303 @@ func TestResponseError(t *testing.T) {
 		t.Errorf("error string should contain message: %s", errStr)
 	}
 }
+
+// testPipe connects a Conn to an in-memory fake server.
+type testPipe struct {
+	conn   *Conn
+	reader *bufio.Reader // what the conn sent
+	writer io.Writer     // what the conn receives
+}
+
+func newTestPipe(t *testing.T) *testPipe {
+	t.Helper()
+
+	serverReader, clientWriter := io.Pipe()
+	clientReader, serverWriter := io.Pipe()
+	t.Cleanup(func() {
+		serverReader.Close()
+		clientWriter.Close()
+		clientReader.Close()
+		serverWriter.Close()
+	})
+
+	conn := NewConn(clientReader, clientWriter)
+	go conn.ReadLoop()
+	t.Cleanup(conn.Close)
+
+	return &testPipe{
+		conn:   conn,
+		reader: bufio.NewReader(serverReader),
+		writer: serverWriter,
+	}
+}
+
+// serverSend writes a raw JSON message from the fake server.
+func (p *testPipe) serverSend(t *testing.T, body string) {
+	t.Helper()
+	header := fmt.Sprintf("Content-Length: %d\r\n\r\n", len(body))
+	if _, err := io.WriteString(p.writer, header+body); err != nil {
+		t.Fatalf("server write: %v", err)
+	}
+}
+
+// serverRead reads the next message the conn sent to the fake server.
+func (p *testPipe) serverRead(t *testing.T) map[string]any {
+	t.Helper()
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		body, err := ReadMessage(p.reader)
+		done <- result{body, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatalf("server read: %v", r.err)
+		}
+		var msg map[string]any
+		if err := json.Unmarshal(r.body, &msg); err != nil {
+			t.Fatalf("server unmarshal: %v", err)
+		}
+		return msg
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for client message")
+		return nil
+	}
+}
+
+func TestConn_HandleServerRequest(t *testing.T) {
+	p := newTestPipe(t)
+
+	p.conn.Handle("workspace/configuration", func(params json.RawMessage) (any, error) {
+		var cp ConfigurationParams
+		if err := json.Unmarshal(params, &cp); err != nil {
+			return nil, err
+		}
+		return make([]any, len(cp.Items)), nil
+	})
+	p.conn.Handle("test/fail", func(json.RawMessage) (any, error) {
+		return nil, &ResponseError{Code: -32000, Message: "nope"}
+	})
+	p.conn.Handle("test/null", func(json.RawMessage) (any, error) {
+		return nil, nil
+	})
+
+	tests := []struct {
+		name      string
+		request   string
+		wantID    any
+		wantCode  float64
+		wantNulls int
+	}{
+		{
+			name:      "handled with string id",
+			request:   `{"jsonrpc":"2.0","id":"cfg-1","method":"workspace/configuration","params":{"items":[{"section":"gopls"},{"section":"go"}]}}`,
+			wantID:    "cfg-1",
+			wantNulls: 2,
+		},
+		{
+			name:     "handler error",
+			request:  `{"jsonrpc":"2.0","id":7,"method":"test/fail"}`,
+			wantID:   float64(7),
+			wantCode: -32000,
+		},
+		{
+			name:     "unknown method",
+			request:  `{"jsonrpc":"2.0","id":8,"method":"client/unknown"}`,
+			wantID:   float64(8),
+			wantCode: CodeMethodNotFound,
+		},
+		{
+			name:    "null result",
+			request: `{"jsonrpc":"2.0","id":9,"method":"test/null"}`,
+			wantID:  float64(9),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.serverSend(t, tt.request)
+			msg := p.serverRead(t)
+
+			if msg["id"] != tt.wantID {
+				t.Errorf("reply id = %v, want %v", msg["id"], tt.wantID)
+			}
+
+			if tt.wantCode != 0 {
+				errObj, ok := msg["error"].(map[string]any)
+				if !ok {
+					t.Fatalf("expected error reply, got %v", msg)
+				}
+				if errObj["code"] != tt.wantCode {
+					t.Errorf("error code = %v, want %v", errObj["code"], tt.wantCode)
+				}
+				if _, ok := msg["result"]; ok {
+					t.Error("error reply must not carry a result")
+				}
+				return
+			}
+
+			result, ok := msg["result"]
+			if !ok {
+				t.Fatalf("expected result in reply, got %v", msg)
+			}
+			if tt.wantNulls > 0 {
+				items, ok := result.([]any)
+				if !ok || len(items) != tt.wantNulls {
+					t.Errorf("result = %v, want %d nulls", result, tt.wantNulls)
+				}
+			}
+		})
+	}
+}
+
+func TestConn_Subscribe(t *testing.T) {
+	p := newTestPipe(t)
+
+	first := make(chan string, 4)
+	second := make(chan string, 4)
+
+	p.conn.Subscribe("window/logMessage", func(params json.RawMessage) {
+		var lp LogMessageParams
+		json.Unmarshal(params, &lp)
+		first <- lp.Message
+	})
+	unsubscribe := p.conn.Subscribe("window/logMessage", func(params json.RawMessage) {
+		var lp LogMessageParams
+		json.Unmarshal(params, &lp)
+		second <- lp.Message
+	})
+
+	receive := func(ch chan string) string {
+		select {
+		case m := <-ch:
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for notification")
+			return ""
+		}
+	}
+
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":3,"message":"one"}}`)
+	if got := receive(first); got != "one" {
+		t.Errorf("first subscriber got %q", got)
+	}
+	if got := receive(second); got != "one" {
+		t.Errorf("second subscriber got %q", got)
+	}
+
+	unsubscribe()
+
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"window/logMessage","params":{"type":3,"message":"two"}}`)
+	if got := receive(first); got != "two" {
+		t.Errorf("first subscriber got %q", got)
+	}
+	select {
+	case m := <-second:
+		t.Errorf("unsubscribed handler received %q", m)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestConn_NotificationsDoNotDisturbCalls(t *testing.T) {
+	p := newTestPipe(t)
+
+	progress := make(chan struct{}, 1)
+	p.conn.Subscribe("$/progress", func(json.RawMessage) {
+		progress <- struct{}{}
+	})
+
+	done := make(chan error, 1)
+	var result string
+	go func() {
+		done <- p.conn.Call(context.Background(), "test/method", nil, &result)
+	}()
+
+	req := p.serverRead(t)
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"$/progress","params":{"token":"t","value":{"kind":"begin"}}}`)
+	p.serverSend(t, fmt.Sprintf(`{"jsonrpc":"2.0","id":%v,"result":"ok"}`, req["id"]))
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Call failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Call timed out")
+	}
+
+	if result != "ok" {
+		t.Errorf("result = %q, want ok", result)
+	}
+
+	select {
+	case <-progress:
+	case <-time.After(5 * time.Second):
+		t.Error("progress notification not delivered")
+	}
+}
+
+func TestConn_CallTimeout(t *testing.T) {
+	p := newTestPipe(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.conn.Call(ctx, "callHierarchy/incomingCalls", nil, nil)
+	}()
+
+	// The server reads the request and never answers it
+	req := p.serverRead(t)
+
+	cancelMsg := p.serverRead(t)
+	if cancelMsg["method"] != "$/cancelRequest" {
+		t.Fatalf("expected $/cancelRequest, got %v", cancelMsg)
+	}
+	params, _ := cancelMsg["params"].(map[string]any)
+	if params["id"] != req["id"] {
+		t.Errorf("cancelled id = %v, want %v", params["id"], req["id"])
+	}
+
+	err := <-done
+	we, ok := errors.FromError(err)
+	if !ok || we.Code != errors.CodeTimeout {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+	if we.Context["method"] != "callHierarchy/incomingCalls" {
+		t.Errorf("timeout method = %v", we.Context["method"])
+	}
+}
+
+func TestConn_CallCancelled(t *testing.T) {
+	p := newTestPipe(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		done <- p.conn.Call(ctx, "workspace/symbol", nil, nil)
+	}()
+
+	p.serverRead(t)
+	cancel()
+
+	if msg := p.serverRead(t); msg["method"] != "$/cancelRequest" {
+		t.Fatalf("expected $/cancelRequest, got %v", msg)
+	}
+
+	err := <-done
+	if _, ok := errors.FromError(err); ok {
+		t.Errorf("cancellation should not be reported as a WildcatError: %v", err)
+	}
+	if !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("err = %v, want context canceled", err)
+	}
+}
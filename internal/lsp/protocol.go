@@ -1,18 +1,46 @@
 // Package lsp provides a client for communicating with Language Server Protocol servers.
 package lsp
 
+import (
+	"encoding/json"
+	"unicode/utf16"
+)
+
 // Position in a text document expressed as zero-based line and character offset.
 type Position struct {
 	Line      int `json:"line"`
 	Character int `json:"character"`
 }
 
+// UTF16Offset converts byte offset n in line to the UTF-16 offset LSP
+// positions count in. Offsets past the end of line are clamped to it.
+func UTF16Offset(line string, n int) int {
+	if n < len(line) {
+		line = line[:n]
+	}
+	return len(utf16.Encode([]rune(line)))
+}
+
 // Range in a text document expressed as start and end positions.
 type Range struct {
 	Start Position `json:"start"`
 	End   Position `json:"end"`
 }
 
+// Contains reports whether pos lies within r, inclusive of both ends.
+func (r Range) Contains(pos Position) bool {
+	if pos.Line < r.Start.Line || pos.Line > r.End.Line {
+		return false
+	}
+	if pos.Line == r.Start.Line && pos.Character < r.Start.Character {
+		return false
+	}
+	if pos.Line == r.End.Line && pos.Character > r.End.Character {
+		return false
+	}
+	return true
+}
+
 // Location represents a location inside a resource, such as a line inside a text file.
 type Location struct {
 	URI   string `json:"uri"`
@@ -132,6 +160,34 @@ type ImplementationParams struct {
 	TextDocumentPositionParams
 }
 
+// DefinitionParams is the parameter for textDocument/definition.
+type DefinitionParams struct {
+	TextDocumentPositionParams
+}
+
+// LocationLink is a definition target with the range that linked to it.
+type LocationLink struct {
+	OriginSelectionRange *Range `json:"originSelectionRange,omitempty"`
+	TargetURI            string `json:"targetUri"`
+	TargetRange          Range  `json:"targetRange"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// DocumentSymbolParams is the parameter for textDocument/documentSymbol.
+type DocumentSymbolParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// DocumentSymbol is a symbol declared in a document, with its children.
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           SymbolKind       `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
 // TypeHierarchyPrepareParams is the parameter for textDocument/prepareTypeHierarchy.
 type TypeHierarchyPrepareParams struct {
 	TextDocumentPositionParams
@@ -170,6 +226,13 @@ type InitializeParams struct {
 type Capabilities struct {
 	TextDocument TextDocumentClientCapabilities `json:"textDocument,omitempty"`
 	Workspace    WorkspaceClientCapabilities    `json:"workspace,omitempty"`
+	Window       WindowClientCapabilities       `json:"window,omitempty"`
+	Experimental map[string]any                 `json:"experimental,omitempty"`
+}
+
+// WindowClientCapabilities defines capabilities for window features.
+type WindowClientCapabilities struct {
+	WorkDoneProgress bool `json:"workDoneProgress,omitempty"`
 }
 
 // TextDocumentClientCapabilities defines capabilities for text document features.
@@ -190,7 +253,16 @@ type ReferencesClientCapabilities struct {
 
 // WorkspaceClientCapabilities defines capabilities for workspace features.
 type WorkspaceClientCapabilities struct {
-	Symbol WorkspaceSymbolClientCapabilities `json:"symbol,omitempty"`
+	Symbol                WorkspaceSymbolClientCapabilities       `json:"symbol,omitempty"`
+	Configuration         bool                                    `json:"configuration,omitempty"`
+	WorkspaceFolders      bool                                    `json:"workspaceFolders,omitempty"`
+	DidChangeWatchedFiles DidChangeWatchedFilesClientCapabilities `json:"didChangeWatchedFiles,omitempty"`
+}
+
+// DidChangeWatchedFilesClientCapabilities defines capabilities for file watching.
+type DidChangeWatchedFilesClientCapabilities struct {
+	DynamicRegistration    bool `json:"dynamicRegistration,omitempty"`
+	RelativePatternSupport bool `json:"relativePatternSupport,omitempty"`
 }
 
 // WorkspaceSymbolClientCapabilities defines capabilities for workspace symbols.
@@ -201,14 +273,101 @@ type WorkspaceSymbolClientCapabilities struct {
 // InitializeResult is the result of the initialize request.
 type InitializeResult struct {
 	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   *ServerInfo        `json:"serverInfo,omitempty"`
+}
+
+// ServerInfo identifies the server, as reported during initialize.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
 }
 
 // ServerCapabilities describes the capabilities provided by the server.
+// Providers are either a boolean or an options object; use Supports to
+// test for a feature rather than inspecting them directly.
 type ServerCapabilities struct {
-	CallHierarchyProvider   any  `json:"callHierarchyProvider,omitempty"`
-	ReferencesProvider      any  `json:"referencesProvider,omitempty"`
-	ImplementationProvider  any  `json:"implementationProvider,omitempty"`
-	WorkspaceSymbolProvider bool `json:"workspaceSymbolProvider,omitempty"`
+	PositionEncoding                 string `json:"positionEncoding,omitempty"`
+	TextDocumentSync                 any    `json:"textDocumentSync,omitempty"`
+	NotebookDocumentSync             any    `json:"notebookDocumentSync,omitempty"`
+	CompletionProvider               any    `json:"completionProvider,omitempty"`
+	HoverProvider                    any    `json:"hoverProvider,omitempty"`
+	SignatureHelpProvider            any    `json:"signatureHelpProvider,omitempty"`
+	DeclarationProvider              any    `json:"declarationProvider,omitempty"`
+	DefinitionProvider               any    `json:"definitionProvider,omitempty"`
+	TypeDefinitionProvider           any    `json:"typeDefinitionProvider,omitempty"`
+	ImplementationProvider           any    `json:"implementationProvider,omitempty"`
+	ReferencesProvider               any    `json:"referencesProvider,omitempty"`
+	DocumentHighlightProvider        any    `json:"documentHighlightProvider,omitempty"`
+	DocumentSymbolProvider           any    `json:"documentSymbolProvider,omitempty"`
+	CodeActionProvider               any    `json:"codeActionProvider,omitempty"`
+	CodeLensProvider                 any    `json:"codeLensProvider,omitempty"`
+	DocumentLinkProvider             any    `json:"documentLinkProvider,omitempty"`
+	ColorProvider                    any    `json:"colorProvider,omitempty"`
+	DocumentFormattingProvider       any    `json:"documentFormattingProvider,omitempty"`
+	DocumentRangeFormattingProvider  any    `json:"documentRangeFormattingProvider,omitempty"`
+	DocumentOnTypeFormattingProvider any    `json:"documentOnTypeFormattingProvider,omitempty"`
+	RenameProvider                   any    `json:"renameProvider,omitempty"`
+	FoldingRangeProvider             any    `json:"foldingRangeProvider,omitempty"`
+	ExecuteCommandProvider           any    `json:"executeCommandProvider,omitempty"`
+	SelectionRangeProvider           any    `json:"selectionRangeProvider,omitempty"`
+	LinkedEditingRangeProvider       any    `json:"linkedEditingRangeProvider,omitempty"`
+	CallHierarchyProvider            any    `json:"callHierarchyProvider,omitempty"`
+	SemanticTokensProvider           any    `json:"semanticTokensProvider,omitempty"`
+	MonikerProvider                  any    `json:"monikerProvider,omitempty"`
+	TypeHierarchyProvider            any    `json:"typeHierarchyProvider,omitempty"`
+	InlineValueProvider              any    `json:"inlineValueProvider,omitempty"`
+	InlayHintProvider                any    `json:"inlayHintProvider,omitempty"`
+	DiagnosticProvider               any    `json:"diagnosticProvider,omitempty"`
+	WorkspaceSymbolProvider          any    `json:"workspaceSymbolProvider,omitempty"`
+	Workspace                        any    `json:"workspace,omitempty"`
+	Experimental                     any    `json:"experimental,omitempty"`
+}
+
+// Supports reports whether the server advertises the provider behind an
+// LSP request method. Methods not governed by a provider, such as
+// initialize, are always supported.
+func (c ServerCapabilities) Supports(method string) bool {
+	var provider any
+	switch method {
+	case "textDocument/hover":
+		provider = c.HoverProvider
+	case "textDocument/declaration":
+		provider = c.DeclarationProvider
+	case "textDocument/definition":
+		provider = c.DefinitionProvider
+	case "textDocument/typeDefinition":
+		provider = c.TypeDefinitionProvider
+	case "textDocument/implementation":
+		provider = c.ImplementationProvider
+	case "textDocument/references":
+		provider = c.ReferencesProvider
+	case "textDocument/documentSymbol":
+		provider = c.DocumentSymbolProvider
+	case "textDocument/rename":
+		provider = c.RenameProvider
+	case "textDocument/prepareCallHierarchy", "callHierarchy/incomingCalls", "callHierarchy/outgoingCalls":
+		provider = c.CallHierarchyProvider
+	case "textDocument/prepareTypeHierarchy", "typeHierarchy/supertypes", "typeHierarchy/subtypes":
+		provider = c.TypeHierarchyProvider
+	case "workspace/symbol":
+		provider = c.WorkspaceSymbolProvider
+	default:
+		return true
+	}
+	return provided(provider)
+}
+
+// provided interprets a provider field: true or an options object enable
+// the feature, false or absence disable it.
+func provided(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	default:
+		return true
+	}
 }
 
 // TextDocumentItem is an item to transfer a text document from the client to the server.
@@ -228,3 +387,130 @@ type DidOpenTextDocumentParams struct {
 type DidCloseTextDocumentParams struct {
 	TextDocument TextDocumentIdentifier `json:"textDocument"`
 }
+
+// ConfigurationItem identifies a configuration section requested by the server.
+type ConfigurationItem struct {
+	ScopeURI string `json:"scopeUri,omitempty"`
+	Section  string `json:"section,omitempty"`
+}
+
+// ConfigurationParams is the parameter for workspace/configuration.
+type ConfigurationParams struct {
+	Items []ConfigurationItem `json:"items"`
+}
+
+// WorkspaceFolder is a workspace root known to the client.
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// RegistrationParams is the parameter for client/registerCapability.
+type RegistrationParams struct {
+	Registrations []Registration `json:"registrations"`
+}
+
+// Registration registers a capability the server wants dynamically.
+type Registration struct {
+	ID              string          `json:"id"`
+	Method          string          `json:"method"`
+	RegisterOptions json.RawMessage `json:"registerOptions,omitempty"`
+}
+
+// UnregistrationParams is the parameter for client/unregisterCapability.
+// The misspelt field name is the protocol's.
+type UnregistrationParams struct {
+	Unregistrations []Unregistration `json:"unregisterations"`
+}
+
+// Unregistration withdraws an earlier registration.
+type Unregistration struct {
+	ID     string `json:"id"`
+	Method string `json:"method"`
+}
+
+// DidChangeWatchedFilesRegistrationOptions lists the files a server
+// wants change notifications for.
+type DidChangeWatchedFilesRegistrationOptions struct {
+	Watchers []FileSystemWatcher `json:"watchers"`
+}
+
+// FileSystemWatcher selects files by glob and the kinds of change to report.
+// GlobPattern is a pattern string or a RelativePattern object.
+type FileSystemWatcher struct {
+	GlobPattern json.RawMessage `json:"globPattern"`
+	Kind        int             `json:"kind,omitempty"` // WatchKind bits (default all)
+}
+
+// RelativePattern is a glob matched relative to a base directory.
+type RelativePattern struct {
+	BaseURI json.RawMessage `json:"baseUri"` // URI or WorkspaceFolder
+	Pattern string          `json:"pattern"`
+}
+
+// WatchKind bits select the changes a watcher reports.
+const (
+	WatchKindCreate = 1
+	WatchKindChange = 2
+	WatchKindDelete = 4
+)
+
+// FileChangeType is the kind of change in a FileEvent.
+type FileChangeType int
+
+// File change types.
+const (
+	FileCreated FileChangeType = 1
+	FileChanged FileChangeType = 2
+	FileDeleted FileChangeType = 3
+)
+
+// FileEvent reports a change to one file.
+type FileEvent struct {
+	URI  string         `json:"uri"`
+	Type FileChangeType `json:"type"`
+}
+
+// DidChangeWatchedFilesParams is the parameter for workspace/didChangeWatchedFiles.
+type DidChangeWatchedFilesParams struct {
+	Changes []FileEvent `json:"changes"`
+}
+
+// ApplyWorkspaceEditResult is the result of workspace/applyEdit.
+type ApplyWorkspaceEditResult struct {
+	Applied       bool   `json:"applied"`
+	FailureReason string `json:"failureReason,omitempty"`
+}
+
+// ProgressParams is the parameter for $/progress notifications.
+type ProgressParams struct {
+	Token any             `json:"token"`
+	Value json.RawMessage `json:"value"`
+}
+
+// LogMessageParams is the parameter for window/logMessage notifications.
+type LogMessageParams struct {
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
+
+// Diagnostic represents a compiler error or warning reported by the server.
+type Diagnostic struct {
+	Range    Range  `json:"range"`
+	Severity int    `json:"severity,omitempty"`
+	Code     any    `json:"code,omitempty"`
+	Source   string `json:"source,omitempty"`
+	Message  string `json:"message"`
+}
+
+// PublishDiagnosticsParams is the parameter for textDocument/publishDiagnostics.
+type PublishDiagnosticsParams struct {
+	URI         string       `json:"uri"`
+	Version     int          `json:"version,omitempty"`
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+// CancelParams is the parameter for $/cancelRequest.
+type CancelParams struct {
+	ID int64 `json:"id"`
+}
@@ -0,0 +1,246 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MethodWaitReady is answered by LSP proxies such as the wildcat daemon,
+// which track readiness for the server they front. Plain servers reply
+// MethodNotFound and the client falls back to its own detection.
+const MethodWaitReady = "wildcat/waitReady"
+
+// Ways a server can be judged ready.
+const (
+	ReadyViaProgress     = "progress"      // all $/progress tokens ended
+	ReadyViaServerStatus = "server_status" // experimental/serverStatus reported quiescent
+	ReadyViaProbe        = "probe"         // workspace/symbol results stabilised
+	ReadyViaProxy        = "proxy"         // an attached proxy reported ready
+	ReadyViaTimeout      = "timeout"       // gave up at the ceiling
+)
+
+// Readiness describes how long a server took to finish indexing.
+type Readiness struct {
+	Duration time.Duration `json:"duration"`
+	Via      string        `json:"via"`
+}
+
+// ReadyOptions configures WaitReady. Zero values select defaults.
+type ReadyOptions struct {
+	Timeout       time.Duration // Ceiling on waiting (default 30s)
+	Grace         time.Duration // Wait for a first progress report before probing (default 500ms)
+	Settle        time.Duration // Quiet period after progress ends (default 100ms)
+	ProbeInterval time.Duration // Delay between workspace/symbol probes (default 200ms)
+	ProbeQuery    string        // workspace/symbol query used to probe
+}
+
+func (o ReadyOptions) withDefaults() ReadyOptions {
+	if o.Timeout <= 0 {
+		o.Timeout = 30 * time.Second
+	}
+	if o.Grace <= 0 {
+		o.Grace = 500 * time.Millisecond
+	}
+	if o.Settle <= 0 {
+		o.Settle = 100 * time.Millisecond
+	}
+	if o.ProbeInterval <= 0 {
+		o.ProbeInterval = 200 * time.Millisecond
+	}
+	return o
+}
+
+// readyPollInterval is how often WaitReady re-checks progress state.
+const readyPollInterval = 20 * time.Millisecond
+
+// WaitReady blocks until the server has finished indexing, the ceiling in
+// opts is reached, or ctx is cancelled. Hitting the ceiling is not an error;
+// the returned Readiness reports it via ReadyViaTimeout.
+//
+// Servers that report $/progress are ready once every token has ended.
+// rust-analyzer's experimental/serverStatus takes precedence when present.
+// Servers that report nothing are probed with workspace/symbol until two
+// consecutive answers agree. Servers answer nothing while they index, so
+// empty answers never agree.
+func (c *Client) WaitReady(ctx context.Context, opts ReadyOptions) (Readiness, error) {
+	opts = opts.withDefaults()
+
+	start := c.initializedAt
+	if start.IsZero() {
+		start = time.Now()
+	}
+	finish := func(via string) (Readiness, error) {
+		c.ready = Readiness{Duration: time.Since(start), Via: via}
+		return c.ready, nil
+	}
+
+	if c.rwc != nil {
+		if err := c.conn.Call(ctx, MethodWaitReady, opts, nil); err == nil {
+			return finish(ReadyViaProxy)
+		}
+	}
+
+	deadline := time.NewTimer(opts.Timeout)
+	defer deadline.Stop()
+
+	graceEnd := time.Now().Add(opts.Grace)
+	lastProbe := time.Time{}
+	probeCount := -1
+
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if via, ok := c.progress.ready(opts.Settle); ok {
+			return finish(via)
+		}
+
+		if !c.progress.active() && time.Now().After(graceEnd) && time.Since(lastProbe) >= opts.ProbeInterval {
+			lastProbe = time.Now()
+			symbols, err := c.WorkspaceSymbol(ctx, opts.ProbeQuery)
+			if err == nil {
+				if len(symbols) > 0 && len(symbols) == probeCount {
+					return finish(ReadyViaProbe)
+				}
+				probeCount = len(symbols)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return Readiness{}, ctx.Err()
+		case <-deadline.C:
+			return finish(ReadyViaTimeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// Readiness returns the result of the last WaitReady call.
+func (c *Client) Readiness() Readiness {
+	return c.ready
+}
+
+// progressTracker follows the notifications servers use to report indexing.
+type progressTracker struct {
+	mu         sync.Mutex
+	tokens     map[string]string // active token -> title
+	seen       bool              // any progress reported
+	lastChange time.Time
+	quiescent  *bool // from experimental/serverStatus, when the server sends it
+	finished   bool  // server logged a known "done indexing" message
+}
+
+// progressValue is the value of a $/progress notification.
+type progressValue struct {
+	Kind    string `json:"kind"` // begin, report, end
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// serverStatusParams is rust-analyzer's experimental/serverStatus notification.
+type serverStatusParams struct {
+	Health    string `json:"health"`
+	Quiescent bool   `json:"quiescent"`
+	Message   string `json:"message,omitempty"`
+}
+
+// finishedMessages are log lines servers emit once their initial load is done.
+var finishedMessages = []string{
+	"Finished loading packages", // gopls
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{tokens: make(map[string]string)}
+}
+
+// subscribe attaches the tracker to a connection's notifications.
+func (p *progressTracker) subscribe(conn *Conn) {
+	conn.Subscribe("$/progress", p.onProgress)
+	conn.Subscribe("experimental/serverStatus", p.onServerStatus)
+	conn.Subscribe("window/logMessage", p.onMessage)
+	conn.Subscribe("window/showMessage", p.onMessage)
+}
+
+func (p *progressTracker) onProgress(params json.RawMessage) {
+	var pp ProgressParams
+	if err := json.Unmarshal(params, &pp); err != nil {
+		return
+	}
+	var v progressValue
+	if err := json.Unmarshal(pp.Value, &v); err != nil {
+		return
+	}
+
+	token := fmt.Sprint(pp.Token)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.seen = true
+	p.lastChange = time.Now()
+	switch v.Kind {
+	case "begin":
+		p.tokens[token] = v.Title
+	case "end":
+		delete(p.tokens, token)
+	}
+}
+
+func (p *progressTracker) onServerStatus(params json.RawMessage) {
+	var sp serverStatusParams
+	if err := json.Unmarshal(params, &sp); err != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.quiescent = &sp.Quiescent
+	p.lastChange = time.Now()
+}
+
+func (p *progressTracker) onMessage(params json.RawMessage) {
+	var lp LogMessageParams
+	if err := json.Unmarshal(params, &lp); err != nil {
+		return
+	}
+	for _, m := range finishedMessages {
+		if strings.Contains(lp.Message, m) {
+			p.mu.Lock()
+			p.finished = true
+			p.lastChange = time.Now()
+			p.mu.Unlock()
+			return
+		}
+	}
+}
+
+// active reports whether the server has said anything about its progress.
+func (p *progressTracker) active() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.seen || p.quiescent != nil || p.finished
+}
+
+// ready reports whether progress notifications say indexing is complete.
+func (p *progressTracker) ready(settle time.Duration) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.quiescent != nil {
+		return ReadyViaServerStatus, *p.quiescent
+	}
+	if len(p.tokens) > 0 {
+		return "", false
+	}
+	if p.finished {
+		return ReadyViaProgress, true
+	}
+	if p.seen && time.Since(p.lastChange) >= settle {
+		return ReadyViaProgress, true
+	}
+	return "", false
+}
@@ -0,0 +1,246 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient wraps a test pipe's conn in a Client tracking progress.
+func newTestClient(p *testPipe) *Client {
+	c := &Client{
+		conn:          p.conn,
+		rootURI:       "file:///tmp/ws",
+		progress:      newProgressTracker(),
+		initializedAt: time.Now(),
+	}
+	c.registerHandlers()
+	return c
+}
+
+func TestProgressTracker_Ready(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(p *progressTracker)
+		wantVia string
+		wantOK  bool
+	}{
+		{
+			name:   "nothing reported",
+			setup:  func(p *progressTracker) {},
+			wantOK: false,
+		},
+		{
+			name: "token still active",
+			setup: func(p *progressTracker) {
+				p.onProgress([]byte(`{"token":1,"value":{"kind":"begin","title":"Loading packages"}}`))
+			},
+			wantOK: false,
+		},
+		{
+			name: "token ended",
+			setup: func(p *progressTracker) {
+				p.onProgress([]byte(`{"token":1,"value":{"kind":"begin","title":"Loading packages"}}`))
+				p.onProgress([]byte(`{"token":1,"value":{"kind":"end"}}`))
+			},
+			wantVia: ReadyViaProgress,
+			wantOK:  true,
+		},
+		{
+			name: "one of two tokens ended",
+			setup: func(p *progressTracker) {
+				p.onProgress([]byte(`{"token":"a","value":{"kind":"begin"}}`))
+				p.onProgress([]byte(`{"token":"b","value":{"kind":"begin"}}`))
+				p.onProgress([]byte(`{"token":"a","value":{"kind":"end"}}`))
+			},
+			wantOK: false,
+		},
+		{
+			name: "server status busy",
+			setup: func(p *progressTracker) {
+				p.onServerStatus([]byte(`{"health":"ok","quiescent":false}`))
+			},
+			wantOK: false,
+		},
+		{
+			name: "server status quiescent",
+			setup: func(p *progressTracker) {
+				p.onProgress([]byte(`{"token":1,"value":{"kind":"begin"}}`))
+				p.onServerStatus([]byte(`{"health":"ok","quiescent":true}`))
+			},
+			wantVia: ReadyViaServerStatus,
+			wantOK:  true,
+		},
+		{
+			name: "gopls finished message",
+			setup: func(p *progressTracker) {
+				p.onMessage([]byte(`{"type":3,"message":"2024/01/01 Finished loading packages."}`))
+			},
+			wantVia: ReadyViaProgress,
+			wantOK:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newProgressTracker()
+			tt.setup(p)
+
+			via, ok := p.ready(0)
+			if ok != tt.wantOK {
+				t.Errorf("ready = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && via != tt.wantVia {
+				t.Errorf("via = %q, want %q", via, tt.wantVia)
+			}
+		})
+	}
+}
+
+func TestClient_WaitReady_Progress(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"$/progress","params":{"token":1,"value":{"kind":"begin","title":"Loading packages"}}}`)
+
+	done := make(chan Readiness, 1)
+	go func() {
+		r, err := client.WaitReady(context.Background(), ReadyOptions{Settle: 10 * time.Millisecond})
+		if err != nil {
+			t.Errorf("WaitReady: %v", err)
+		}
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		t.Fatalf("ready before progress ended: %+v", r)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"$/progress","params":{"token":1,"value":{"kind":"end"}}}`)
+
+	select {
+	case r := <-done:
+		if r.Via != ReadyViaProgress {
+			t.Errorf("via = %q, want %q", r.Via, ReadyViaProgress)
+		}
+		if client.Readiness() != r {
+			t.Errorf("Readiness() = %+v, want %+v", client.Readiness(), r)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("WaitReady did not return after progress ended")
+	}
+}
+
+// answerProbes answers each workspace/symbol probe, the ith with
+// count(i) symbols, and returns the number answered so far.
+func answerProbes(p *testPipe, count func(i int) int) *atomic.Int32 {
+	var answered atomic.Int32
+	go func() {
+		for i := 0; ; i++ {
+			body, err := ReadMessage(p.reader)
+			if err != nil {
+				return
+			}
+			var req Request
+			if err := json.Unmarshal(body, &req); err != nil {
+				return
+			}
+			syms := "["
+			for j := 0; j < count(i); j++ {
+				if j > 0 {
+					syms += ","
+				}
+				syms += fmt.Sprintf(`{"name":"s%d","kind":12,"location":{"uri":"file:///a.go","range":{"start":{"line":0,"character":0},"end":{"line":0,"character":0}}}}`, j)
+			}
+			syms += "]"
+			answered.Add(1)
+			resp := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, syms)
+			fmt.Fprintf(p.writer, "Content-Length: %d\r\n\r\n%s", len(resp), resp)
+		}
+	}()
+	return &answered
+}
+
+func TestClient_WaitReady_Probe(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+
+	// Answer each probe with one more symbol until the index "settles" at two
+	answerProbes(p, func(i int) int { return min(i, 2) })
+
+	r, err := client.WaitReady(context.Background(), ReadyOptions{
+		Grace:         time.Millisecond,
+		ProbeInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	if r.Via != ReadyViaProbe {
+		t.Errorf("via = %q, want %q", r.Via, ReadyViaProbe)
+	}
+}
+
+func TestClient_WaitReady_ProbeIndexing(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+
+	// Answer nothing while "indexing", then settle at three
+	const indexing = 5
+	answered := answerProbes(p, func(i int) int {
+		if i < indexing {
+			return 0
+		}
+		return 3
+	})
+
+	r, err := client.WaitReady(context.Background(), ReadyOptions{
+		Grace:         time.Millisecond,
+		ProbeInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	if r.Via != ReadyViaProbe {
+		t.Errorf("via = %q, want %q", r.Via, ReadyViaProbe)
+	}
+	if n := answered.Load(); n < indexing+2 {
+		t.Errorf("ready after %d probes, want the empty answers ignored", n)
+	}
+}
+
+func TestClient_WaitReady_Ceiling(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"$/progress","params":{"token":1,"value":{"kind":"begin"}}}`)
+
+	// Give the read loop a moment to record the token
+	time.Sleep(20 * time.Millisecond)
+
+	r, err := client.WaitReady(context.Background(), ReadyOptions{Timeout: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("WaitReady: %v", err)
+	}
+	if r.Via != ReadyViaTimeout {
+		t.Errorf("via = %q, want %q", r.Via, ReadyViaTimeout)
+	}
+}
+
+func TestClient_WaitReady_Cancelled(t *testing.T) {
+	p := newTestPipe(t)
+	client := newTestClient(p)
+
+	p.serverSend(t, `{"jsonrpc":"2.0","method":"$/progress","params":{"token":1,"value":{"kind":"begin"}}}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.WaitReady(ctx, ReadyOptions{}); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
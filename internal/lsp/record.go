@@ -0,0 +1,152 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TranscriptVersion is the format version written in transcript headers.
+const TranscriptVersion = 1
+
+// Transcript message directions, from the client's point of view.
+const (
+	DirSend = "send" // client to server
+	DirRecv = "recv" // server to client
+)
+
+// TranscriptHeader is the first line of a transcript.
+type TranscriptHeader struct {
+	Version int    `json:"wildcat_transcript"`
+	Root    string `json:"root"` // Workspace directory at recording time
+}
+
+// TranscriptEntry is one recorded JSON-RPC message.
+type TranscriptEntry struct {
+	Dir     string          `json:"dir"`  // DirSend or DirRecv
+	TimeMS  int64           `json:"t_ms"` // Milliseconds since recording started
+	Message json.RawMessage `json:"msg"`
+}
+
+// Transcript is a recorded LSP session.
+type Transcript struct {
+	Header  TranscriptHeader
+	Entries []TranscriptEntry
+}
+
+// Recorder writes a transcript of a session as JSON lines: a header
+// followed by one TranscriptEntry per message.
+type Recorder struct {
+	mu     sync.Mutex
+	w      io.Writer
+	enc    *json.Encoder
+	start  time.Time
+	closed bool
+}
+
+// NewRecorder starts a transcript on w for a session rooted at workDir.
+func NewRecorder(w io.Writer, workDir string) (*Recorder, error) {
+	root, err := filepath.Abs(workDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving workspace: %w", err)
+	}
+
+	r := &Recorder{w: w, enc: json.NewEncoder(w), start: time.Now()}
+	if err := r.enc.Encode(TranscriptHeader{Version: TranscriptVersion, Root: root}); err != nil {
+		return nil, fmt.Errorf("writing transcript header: %w", err)
+	}
+	return r, nil
+}
+
+// CreateRecorder creates the transcript file at path. Closing the recorder
+// closes the file.
+func CreateRecorder(path, workDir string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("creating transcript: %w", err)
+	}
+	r, err := NewRecorder(f, workDir)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// record appends a message. Write errors are dropped so a full disk never
+// fails the session being recorded.
+func (r *Recorder) record(dir string, body []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	_ = r.enc.Encode(TranscriptEntry{
+		Dir:     dir,
+		TimeMS:  time.Since(r.start).Milliseconds(),
+		Message: json.RawMessage(body),
+	})
+}
+
+// Close stops recording and closes the underlying writer if it is a Closer.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if c, ok := r.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// LoadTranscript reads a transcript written by a Recorder.
+func LoadTranscript(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening transcript: %w", err)
+	}
+	defer f.Close()
+	return ReadTranscript(f)
+}
+
+// ReadTranscript parses a transcript from r.
+func ReadTranscript(r io.Reader) (*Transcript, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("reading transcript: %w", err)
+		}
+		return nil, fmt.Errorf("empty transcript")
+	}
+
+	var t Transcript
+	if err := json.Unmarshal(scanner.Bytes(), &t.Header); err != nil {
+		return nil, fmt.Errorf("parsing transcript header: %w", err)
+	}
+	if t.Header.Version != TranscriptVersion {
+		return nil, fmt.Errorf("unsupported transcript version %d", t.Header.Version)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		var e TranscriptEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("parsing transcript line %d: %w", line, err)
+		}
+		t.Entries = append(t.Entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading transcript: %w", err)
+	}
+
+	return &t, nil
+}
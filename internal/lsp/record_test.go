@@ -0,0 +1,129 @@
+package lsp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRecordedServer answers a minimal session: initialize, a round of
+// progress once initialized, and workspace/symbol for files under root.
+func fakeRecordedServer(p *testPipe, root string) {
+	go func() {
+		for {
+			body, err := ReadMessage(p.reader)
+			if err != nil {
+				return
+			}
+			var msg message
+			if err := json.Unmarshal(body, &msg); err != nil {
+				return
+			}
+
+			var out []string
+			switch msg.Method {
+			case "initialize":
+				out = append(out, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"capabilities":{}}}`, msg.ID))
+			case "initialized":
+				out = append(out,
+					`{"jsonrpc":"2.0","method":"$/progress","params":{"token":"load","value":{"kind":"begin"}}}`,
+					`{"jsonrpc":"2.0","method":"$/progress","params":{"token":"load","value":{"kind":"end"}}}`)
+			case "workspace/symbol":
+				var params WorkspaceSymbolParams
+				_ = json.Unmarshal(msg.Params, &params)
+				out = append(out, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":[{"name":%q,"kind":12,"location":{"uri":"file://%s/a.go","range":{"start":{"line":3,"character":5},"end":{"line":3,"character":8}}}}]}`, msg.ID, params.Query, root))
+			case "shutdown":
+				out = append(out, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":null}`, msg.ID))
+			}
+			for _, o := range out {
+				fmt.Fprintf(p.writer, "Content-Length: %d\r\n\r\n%s", len(o), o)
+			}
+		}
+	}()
+}
+
+func TestRecordReplay(t *testing.T) {
+	const recordedRoot = "/recorded/ws"
+	ctx := context.Background()
+
+	// Record a session against the fake server
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf, recordedRoot)
+	if err != nil {
+		t.Fatalf("NewRecorder: %v", err)
+	}
+
+	p := newTestPipe(t)
+	p.conn.Record(rec)
+	fakeRecordedServer(p, recordedRoot)
+
+	client := newTestClient(p)
+	if err := client.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if _, err := client.WorkspaceSymbol(ctx, "Foo"); err != nil {
+		t.Fatalf("WorkspaceSymbol: %v", err)
+	}
+	if _, err := client.WorkspaceSymbol(ctx, "Bar"); err != nil {
+		t.Fatalf("WorkspaceSymbol: %v", err)
+	}
+	rec.Close()
+
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replay it from a different checkout
+	replayRoot := t.TempDir()
+	replayed, err := NewClient(ctx, ServerConfig{WorkDir: replayRoot, Replay: path})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer replayed.Close()
+
+	if err := replayed.Initialize(ctx); err != nil {
+		t.Fatalf("replay Initialize: %v", err)
+	}
+	r, err := replayed.WaitReady(ctx, ReadyOptions{Timeout: 5 * time.Second, Grace: time.Millisecond})
+	if err != nil {
+		t.Fatalf("replay WaitReady: %v", err)
+	}
+	if r.Via != ReadyViaProgress {
+		t.Errorf("ready via = %q, want %q", r.Via, ReadyViaProgress)
+	}
+
+	// Requests are matched by params, not order
+	syms, err := replayed.WorkspaceSymbol(ctx, "Bar")
+	if err != nil {
+		t.Fatalf("replay WorkspaceSymbol: %v", err)
+	}
+	if len(syms) != 1 || syms[0].Name != "Bar" {
+		t.Fatalf("replay symbols = %+v, want Bar", syms)
+	}
+	if want := "file://" + replayRoot + "/a.go"; syms[0].Location.URI != want {
+		t.Errorf("uri = %q, want %q", syms[0].Location.URI, want)
+	}
+
+	_, err = replayed.References(ctx, syms[0].Location.URI, syms[0].Location.Range.Start, false)
+	if err == nil || !strings.Contains(err.Error(), "textDocument/references") {
+		t.Errorf("unrecorded request err = %v, want method not found", err)
+	}
+
+	if err := replayed.Shutdown(ctx); err != nil {
+		t.Errorf("replay Shutdown: %v", err)
+	}
+}
+
+func TestReadTranscript_BadVersion(t *testing.T) {
+	_, err := ReadTranscript(strings.NewReader(`{"wildcat_transcript":99,"root":"/x"}` + "\n"))
+	if err == nil {
+		t.Fatal("expected error for unknown version")
+	}
+}
@@ -0,0 +1,181 @@
+package lsp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+)
+
+// replayServer answers requests from a recorded transcript in place of a
+// real language server. Requests are matched by method and params; when
+// the params differ from every recording (e.g. initialize, which carries
+// the process ID) the method alone is used. Repeated requests are answered
+// with successive recorded responses, the last one repeating once the
+// recording runs out.
+type replayServer struct {
+	conn  *Conn
+	pipes []io.Closer
+
+	mu       sync.Mutex
+	byParams map[string][]*message
+	byMethod map[string][]*message
+
+	// notifications the server sent during the recording, replayed once
+	// the client sends "initialized"
+	notifications []*message
+}
+
+// startReplay serves the transcript at config.Replay over an in-memory pipe.
+func startReplay(config ServerConfig) (*Server, error) {
+	t, err := LoadTranscript(config.Replay)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := filepath.Abs(config.WorkDir)
+	if err != nil {
+		return nil, fmt.Errorf("resolving workspace: %w", err)
+	}
+
+	rs, err := newReplayServer(t, root)
+	if err != nil {
+		return nil, err
+	}
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	rs.pipes = []io.Closer{clientReader, serverWriter, serverReader, clientWriter}
+
+	rs.conn = NewConn(serverReader, serverWriter)
+	rs.register()
+	go func() { _ = rs.conn.ReadLoop() }()
+
+	conn := NewConn(clientReader, clientWriter)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- conn.ReadLoop()
+	}()
+
+	return &Server{
+		config:  config,
+		conn:    conn,
+		readErr: readErr,
+		replay:  rs,
+	}, nil
+}
+
+// newReplayServer indexes a transcript's responses by the request they
+// answered. Paths under the recorded workspace are rewritten to root so
+// transcripts replay from any checkout.
+func newReplayServer(t *Transcript, root string) (*replayServer, error) {
+	rs := &replayServer{
+		byParams: make(map[string][]*message),
+		byMethod: make(map[string][]*message),
+	}
+
+	rewrite := func(body []byte) []byte {
+		if t.Header.Root == "" || t.Header.Root == root {
+			return body
+		}
+		return bytes.ReplaceAll(body, []byte(t.Header.Root), []byte(root))
+	}
+
+	// Outstanding client requests by raw ID
+	requests := make(map[string]*message)
+
+	for i, e := range t.Entries {
+		var msg message
+		if err := json.Unmarshal(rewrite(e.Message), &msg); err != nil {
+			return nil, fmt.Errorf("transcript entry %d: %w", i+1, err)
+		}
+
+		switch {
+		case e.Dir == DirSend && msg.Method != "" && len(msg.ID) > 0:
+			requests[string(msg.ID)] = &msg
+		case e.Dir == DirRecv && msg.Method == "" && len(msg.ID) > 0:
+			req, ok := requests[string(msg.ID)]
+			if !ok {
+				continue
+			}
+			delete(requests, string(msg.ID))
+			key := replayKey(req.Method, req.Params)
+			rs.byParams[key] = append(rs.byParams[key], &msg)
+			rs.byMethod[req.Method] = append(rs.byMethod[req.Method], &msg)
+		case e.Dir == DirRecv && msg.Method != "" && len(msg.ID) == 0:
+			rs.notifications = append(rs.notifications, &msg)
+		}
+		// Server-initiated requests and the client's answers to them are
+		// not replayed; the client handles them locally.
+	}
+
+	return rs, nil
+}
+
+// register installs handlers for every recorded method.
+func (rs *replayServer) register() {
+	for method := range rs.byMethod {
+		rs.conn.Handle(method, func(params json.RawMessage) (any, error) {
+			return rs.answer(method, params)
+		})
+	}
+
+	if _, ok := rs.byMethod["shutdown"]; !ok {
+		rs.conn.Handle("shutdown", func(json.RawMessage) (any, error) { return nil, nil })
+	}
+
+	rs.conn.Subscribe("initialized", func(json.RawMessage) {
+		for _, n := range rs.notifications {
+			_ = rs.conn.Notify(n.Method, n.Params)
+		}
+	})
+}
+
+// answer returns the next recorded response for a request.
+func (rs *replayServer) answer(method string, params json.RawMessage) (any, error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	queues := []map[string][]*message{rs.byParams, rs.byMethod}
+	keys := []string{replayKey(method, params), method}
+
+	for i, q := range queues {
+		msgs := q[keys[i]]
+		if len(msgs) == 0 {
+			continue
+		}
+		msg := msgs[0]
+		if len(msgs) > 1 {
+			q[keys[i]] = msgs[1:]
+		}
+		if msg.Error != nil {
+			return nil, msg.Error
+		}
+		return msg.Result, nil
+	}
+
+	return nil, &ResponseError{
+		Code:    CodeInternalError,
+		Message: fmt.Sprintf("replay: no recorded response for %s", method),
+	}
+}
+
+// close tears down the replay connection.
+func (rs *replayServer) close() {
+	rs.conn.Close()
+	for _, p := range rs.pipes {
+		_ = p.Close()
+	}
+}
+
+// replayKey identifies a request by method and canonicalized params.
+func replayKey(method string, params json.RawMessage) string {
+	var v any
+	if err := json.Unmarshal(params, &v); err != nil {
+		return method + "\x00" + string(params)
+	}
+	canon, _ := json.Marshal(v) // map keys are sorted
+	return method + "\x00" + string(canon)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
 )
 
 // ServerConfig describes how to start an LSP server.
@@ -12,6 +13,7 @@ type ServerConfig struct {
 	Command string   // e.g., "gopls", "rust-analyzer"
 	Args    []string // e.g., ["serve"] for gopls
 	WorkDir string   // Working directory for the server
+	Replay  string   // Transcript to serve instead of starting Command
 }
 
 // Server manages an LSP server process.
@@ -20,10 +22,17 @@ type Server struct {
 	cmd     *exec.Cmd
 	conn    *Conn
 	readErr chan error
+	replay  *replayServer // set when serving a transcript
 }
 
 // StartServer starts an LSP server with the given configuration.
+// When config.Replay is set, no process is started and requests are
+// answered from the recorded transcript.
 func StartServer(ctx context.Context, config ServerConfig) (*Server, error) {
+	if config.Replay != "" {
+		return startReplay(config)
+	}
+
 	cmd := exec.CommandContext(ctx, config.Command, config.Args...)
 	cmd.Dir = config.WorkDir
 	cmd.Stderr = os.Stderr // Pass through server errors for debugging
@@ -63,10 +72,18 @@ func (s *Server) Conn() *Conn {
 	return s.conn
 }
 
+// stopGrace is how long Stop waits for an interrupted server to exit.
+const stopGrace = 2 * time.Second
+
 // Stop gracefully stops the LSP server.
 func (s *Server) Stop() error {
 	s.conn.Close()
 
+	if s.replay != nil {
+		s.replay.close()
+		return nil
+	}
+
 	// Wait for the process to exit
 	if err := s.cmd.Process.Signal(os.Interrupt); err != nil {
 		// If interrupt fails, kill it
@@ -74,13 +91,27 @@ func (s *Server) Stop() error {
 	}
 
 	// Wait returns an error if the process exits with non-zero or signal,
-	// but that's expected during shutdown so we ignore it.
-	_ = s.cmd.Wait()
+	// but that's expected during shutdown so we ignore it. A server that
+	// ignores the interrupt is killed.
+	exited := make(chan struct{})
+	go func() {
+		_ = s.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(stopGrace):
+		_ = s.cmd.Process.Kill()
+		<-exited
+	}
 	return nil
 }
 
 // Wait waits for the server process to exit and returns any error.
 func (s *Server) Wait() error {
+	if s.replay != nil {
+		return nil
+	}
 	return s.cmd.Wait()
 }
 
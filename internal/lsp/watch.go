@@ -0,0 +1,211 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// fileWatchers holds the file watchers a server registered through
+// client/registerCapability, by registration ID.
+type fileWatchers struct {
+	mu   sync.Mutex
+	byID map[string][]fileWatcher
+}
+
+// fileWatcher selects the changed files one watcher asked for.
+type fileWatcher struct {
+	base string         // Directory a relative pattern is matched in, else ""
+	glob *regexp.Regexp // The pattern, over absolute or base-relative paths
+	kind int            // WatchKind bits
+}
+
+// register records the file watchers among a server's registrations.
+// Other registrations are accepted and ignored.
+func (w *fileWatchers) register(params json.RawMessage) (any, error) {
+	var p RegistrationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, r := range p.Registrations {
+		if r.Method != "workspace/didChangeWatchedFiles" {
+			continue
+		}
+		var opts DidChangeWatchedFilesRegistrationOptions
+		if err := json.Unmarshal(r.RegisterOptions, &opts); err != nil {
+			continue
+		}
+		var watchers []fileWatcher
+		for _, fw := range opts.Watchers {
+			if watcher, ok := newFileWatcher(fw); ok {
+				watchers = append(watchers, watcher)
+			}
+		}
+		if w.byID == nil {
+			w.byID = make(map[string][]fileWatcher)
+		}
+		w.byID[r.ID] = watchers
+	}
+	return nil, nil
+}
+
+// unregister drops withdrawn file watchers.
+func (w *fileWatchers) unregister(params json.RawMessage) (any, error) {
+	var p UnregistrationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, u := range p.Unregistrations {
+		delete(w.byID, u.ID)
+	}
+	return nil, nil
+}
+
+// registered reports whether any watchers are registered.
+func (w *fileWatchers) registered() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.byID) > 0
+}
+
+// wants reports whether a registered watcher selects the change to path.
+func (w *fileWatchers) wants(path string, change FileChangeType) bool {
+	kind := map[FileChangeType]int{
+		FileCreated: WatchKindCreate,
+		FileChanged: WatchKindChange,
+		FileDeleted: WatchKindDelete,
+	}[change]
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, watchers := range w.byID {
+		for _, fw := range watchers {
+			if fw.kind&kind != 0 && fw.match(path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match reports whether the watcher's pattern selects path.
+func (fw fileWatcher) match(path string) bool {
+	if fw.base != "" {
+		rel, err := filepath.Rel(fw.base, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return false
+		}
+		path = filepath.ToSlash(rel)
+	}
+	return fw.glob.MatchString(path)
+}
+
+// newFileWatcher compiles a watcher's glob pattern, given as a string or
+// a RelativePattern.
+func newFileWatcher(fw FileSystemWatcher) (fileWatcher, bool) {
+	watcher := fileWatcher{kind: fw.Kind}
+	if watcher.kind == 0 {
+		watcher.kind = WatchKindCreate | WatchKindChange | WatchKindDelete
+	}
+
+	var pattern string
+	if err := json.Unmarshal(fw.GlobPattern, &pattern); err != nil {
+		var rel RelativePattern
+		if err := json.Unmarshal(fw.GlobPattern, &rel); err != nil {
+			return watcher, false
+		}
+		var base string
+		if err := json.Unmarshal(rel.BaseURI, &base); err != nil {
+			var folder WorkspaceFolder
+			if err := json.Unmarshal(rel.BaseURI, &folder); err != nil {
+				return watcher, false
+			}
+			base = folder.URI
+		}
+		watcher.base = URIToPath(base)
+		pattern = rel.Pattern
+	}
+
+	glob, err := globRegexp(pattern)
+	if err != nil {
+		return watcher, false
+	}
+	watcher.glob = glob
+	return watcher, true
+}
+
+// globRegexp translates an LSP glob pattern to a regular expression:
+// * and ? match within a path segment, ** matches any number of
+// segments, {a,b} matches either alternative and [a-z] or [!a-z] match
+// a character in or out of a range.
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	group := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '{' && !group:
+			b.WriteString("(?:")
+			group = true
+		case c == '}' && group:
+			b.WriteString(")")
+			group = false
+		case c == ',' && group:
+			b.WriteString("|")
+		case c == '[' && strings.IndexByte(glob[i:], ']') > 1:
+			end := i + strings.IndexByte(glob[i:], ']')
+			class := glob[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// Watching reports whether the server has registered file watchers, and
+// so relies on the client to report changes to the files they select.
+func (c *Client) Watching() bool {
+	return c.watchers.registered()
+}
+
+// DidChangeWatchedFiles tells the server about the changes its file
+// watchers select. Changes no watcher selects are dropped.
+func (c *Client) DidChangeWatchedFiles(ctx context.Context, changes []FileEvent) error {
+	var watched []FileEvent
+	for _, change := range changes {
+		if c.watchers.wants(URIToPath(change.URI), change.Type) {
+			watched = append(watched, change)
+		}
+	}
+	if len(watched) == 0 {
+		return nil
+	}
+
+	return c.conn.Notify("workspace/didChangeWatchedFiles", DidChangeWatchedFilesParams{Changes: watched})
+}
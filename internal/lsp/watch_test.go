@@ -0,0 +1,79 @@
+package lsp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		glob string
+		path string
+		want bool
+	}{
+		{glob: "**/*.go", path: "/ws/main.go", want: true},
+		{glob: "**/*.go", path: "/ws/internal/lsp/client.go", want: true},
+		{glob: "**/*.go", path: "/ws/main.gox", want: false},
+		{glob: "**/*.{go,mod,sum}", path: "/ws/go.sum", want: true},
+		{glob: "**/*.{go,mod,sum}", path: "/ws/README.md", want: false},
+		{glob: "*.py", path: "pkg/mod.py", want: false},
+		{glob: "*.py", path: "mod.py", want: true},
+		{glob: "src/**", path: "src/a/b.rs", want: true},
+		{glob: "file?.ts", path: "file1.ts", want: true},
+		{glob: "[!a]*.ts", path: "app.ts", want: false},
+		{glob: "[a-c]*.ts", path: "app.ts", want: true},
+	}
+
+	for _, tt := range tests {
+		re, err := globRegexp(tt.glob)
+		if err != nil {
+			t.Fatalf("globRegexp(%q): %v", tt.glob, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("%q matches %q = %v, want %v", tt.glob, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFileWatchers(t *testing.T) {
+	var w fileWatchers
+	if w.registered() {
+		t.Fatal("registered before any registration")
+	}
+
+	params := `{"registrations": [
+		{"id": "1", "method": "workspace/didChangeWatchedFiles", "registerOptions": {"watchers": [
+			{"globPattern": "**/*.go"},
+			{"globPattern": {"baseUri": "file:///ws", "pattern": "go.mod"}, "kind": 2}
+		]}},
+		{"id": "2", "method": "textDocument/formatting"}
+	]}`
+	if _, err := w.register(json.RawMessage(params)); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		change FileChangeType
+		want   bool
+	}{
+		{path: "/ws/a/main.go", change: FileCreated, want: true},
+		{path: "/ws/a/main.go", change: FileDeleted, want: true},
+		{path: "/ws/go.mod", change: FileChanged, want: true},
+		{path: "/ws/go.mod", change: FileDeleted, want: false},
+		{path: "/ws/sub/go.mod", change: FileChanged, want: false},
+		{path: "/other/go.mod", change: FileChanged, want: false},
+	}
+	for _, tt := range tests {
+		if got := w.wants(tt.path, tt.change); got != tt.want {
+			t.Errorf("wants(%s, %d) = %v, want %v", tt.path, tt.change, got, tt.want)
+		}
+	}
+
+	if _, err := w.unregister(json.RawMessage(`{"unregisterations": [{"id": "1", "method": "workspace/didChangeWatchedFiles"}]}`)); err != nil {
+		t.Fatalf("unregister: %v", err)
+	}
+	if w.registered() || w.wants("/ws/main.go", FileChanged) {
+		t.Error("watchers still registered after unregister")
+	}
+}
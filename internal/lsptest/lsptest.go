@@ -0,0 +1,633 @@
+// Package lsptest provides a programmable fake language server for tests.
+//
+// Symbols, call edges, references and type relationships are declared in
+// Go and the server answers the LSP requests wildcat issues from that
+// model, so resolution and traversal can be tested without gopls:
+//
+//	srv := lsptest.NewServer("/ws")
+//	main := srv.Func("main", "main", "main.go", 3)
+//	run := srv.Func("main", "run", "main.go", 10)
+//	srv.Call(main, run, 5)
+//	client := srv.Client(t)
+package lsptest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+)
+
+// Symbol is a declaration known to the fake server.
+type Symbol struct {
+	Name      string         // Name reported by workspace/symbol
+	Container string         // containerName, e.g. the package or receiver
+	Kind      lsp.SymbolKind // Function, Method, Interface, ...
+	File      string         // Path relative to the workspace root
+	Line      int            // 1-based line of the declaration
+	Column    int            // 0-based column of the name on that line
+	EndLine   int            // 1-based last line (default Line)
+	Parent    *Symbol        // Symbol listed as its parent in documentSymbol, if any
+}
+
+// Server is a fake language server. Declare the model before calling
+// Client; the server reads it concurrently once connected.
+type Server struct {
+	root string
+
+	mu       sync.Mutex
+	symbols  []*Symbol
+	calls    []call
+	refs     []ref
+	impls    []impl
+	failures map[string][]*lsp.ResponseError
+	broken   map[*Symbol]int
+	requests []string
+	watch    []string
+
+	// Capabilities is the capabilities object returned from initialize.
+	// The default advertises every provider the server implements.
+	Capabilities map[string]any
+
+	// Latency delays every response, for exercising timeouts.
+	Latency time.Duration
+
+	// SymbolLimit caps workspace/symbol results, as gopls does. Zero
+	// means no limit.
+	SymbolLimit int
+
+	// OnChange receives the file changes the client reports for the globs
+	// given to Watch, so tests can model reindexing. It runs on the
+	// connection's read loop.
+	OnChange func(changes []lsp.FileEvent)
+}
+
+type call struct {
+	from, to *Symbol
+	line     int
+}
+
+type ref struct {
+	to   *Symbol
+	file string
+	line int
+}
+
+type impl struct {
+	sub, super *Symbol
+}
+
+// NewServer returns an empty fake server for the workspace at root.
+func NewServer(root string) *Server {
+	return &Server{
+		root:     root,
+		failures: make(map[string][]*lsp.ResponseError),
+		broken:   make(map[*Symbol]int),
+		Capabilities: map[string]any{
+			"callHierarchyProvider":   true,
+			"referencesProvider":      true,
+			"implementationProvider":  true,
+			"typeHierarchyProvider":   true,
+			"workspaceSymbolProvider": true,
+			"definitionProvider":      true,
+			"documentSymbolProvider":  true,
+		},
+	}
+}
+
+// Add declares a symbol and returns it for use in relationships.
+func (s *Server) Add(sym Symbol) *Symbol {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sym.EndLine < sym.Line {
+		sym.EndLine = sym.Line
+	}
+	p := &sym
+	s.symbols = append(s.symbols, p)
+	return p
+}
+
+// Func declares a function in pkg spanning a single line.
+func (s *Server) Func(pkg, name, file string, line int) *Symbol {
+	return s.Add(Symbol{Name: name, Container: pkg, Kind: lsp.SymbolKindFunction, File: file, Line: line})
+}
+
+// Method declares a method on recv.
+func (s *Server) Method(recv, name, file string, line int) *Symbol {
+	return s.Add(Symbol{Name: name, Container: recv, Kind: lsp.SymbolKindMethod, File: file, Line: line})
+}
+
+// Interface declares an interface type in pkg.
+func (s *Server) Interface(pkg, name, file string, line int) *Symbol {
+	return s.Add(Symbol{Name: name, Container: pkg, Kind: lsp.SymbolKindInterface, File: file, Line: line})
+}
+
+// Struct declares a struct type in pkg.
+func (s *Server) Struct(pkg, name, file string, line int) *Symbol {
+	return s.Add(Symbol{Name: name, Container: pkg, Kind: lsp.SymbolKindStruct, File: file, Line: line})
+}
+
+// Call records that from calls to at the given line of from's file. Each
+// call site is also a reference to to.
+func (s *Server) Call(from, to *Symbol, line int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call{from: from, to: to, line: line})
+}
+
+// Reference records a non-call reference to sym.
+func (s *Server) Reference(sym *Symbol, file string, line int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs = append(s.refs, ref{to: sym, file: file, line: line})
+}
+
+// Implements records that sub implements or embeds super. It answers
+// textDocument/implementation and the type hierarchy both ways.
+func (s *Server) Implements(sub, super *Symbol) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.impls = append(s.impls, impl{sub: sub, super: super})
+}
+
+// Fail makes the next request for method fail with code. Repeated calls
+// queue further failures.
+func (s *Server) Fail(method string, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[method] = append(s.failures[method], &lsp.ResponseError{
+		Code:    code,
+		Message: fmt.Sprintf("lsptest: injected failure for %s", method),
+	})
+}
+
+// FailCalls makes every incoming and outgoing calls request for sym fail
+// with code, leaving the rest of the call graph intact.
+func (s *Server) FailCalls(sym *Symbol, code int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.broken[sym] = code
+}
+
+// Watch makes the server register a file watcher for each glob once the
+// client is initialized, as gopls does for its source files.
+func (s *Server) Watch(globs ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watch = append(s.watch, globs...)
+}
+
+// Requests returns the methods received so far, in order.
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.requests)
+}
+
+// URI returns the document URI of a workspace-relative file.
+func (s *Server) URI(file string) string {
+	return "file://" + filepath.Join(s.root, file)
+}
+
+// Client connects a new lsp.Client to the server over an in-memory pipe.
+// The client is initialized and closed when the test ends.
+func (s *Server) Client(t testing.TB) *lsp.Client {
+	t.Helper()
+
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	conn := lsp.NewConn(serverReader, serverWriter)
+	s.register(conn)
+	go func() { _ = conn.ReadLoop() }()
+
+	client := lsp.NewClientConn(&pipe{
+		Reader:  clientReader,
+		Writer:  clientWriter,
+		closers: []io.Closer{clientReader, clientWriter, serverReader, serverWriter},
+	}, s.root)
+	t.Cleanup(func() {
+		client.Close()
+		conn.Close()
+	})
+
+	if err := client.Initialize(t.Context()); err != nil {
+		t.Fatalf("lsptest: initialize: %v", err)
+	}
+	return client
+}
+
+// pipe joins the two halves of an in-memory connection.
+type pipe struct {
+	io.Reader
+	io.Writer
+	closers []io.Closer
+}
+
+func (p *pipe) Close() error {
+	for _, c := range p.closers {
+		_ = c.Close()
+	}
+	return nil
+}
+
+// register installs a handler for every method the server answers.
+func (s *Server) register(conn *lsp.Conn) {
+	handlers := map[string]func(json.RawMessage) (any, error){
+		"initialize":                        s.initialize,
+		"shutdown":                          func(json.RawMessage) (any, error) { return nil, nil },
+		lsp.MethodWaitReady:                 func(json.RawMessage) (any, error) { return nil, nil },
+		"workspace/symbol":                  s.workspaceSymbol,
+		"textDocument/prepareCallHierarchy": s.prepareCallHierarchy,
+		"callHierarchy/incomingCalls":       s.incomingCalls,
+		"callHierarchy/outgoingCalls":       s.outgoingCalls,
+		"textDocument/references":           s.references,
+		"textDocument/definition":           s.definition,
+		"textDocument/documentSymbol":       s.documentSymbol,
+		"textDocument/implementation":       s.implementation,
+		"textDocument/prepareTypeHierarchy": s.prepareTypeHierarchy,
+		"typeHierarchy/supertypes":          s.supertypes,
+		"typeHierarchy/subtypes":            s.subtypes,
+	}
+
+	conn.Subscribe("initialized", func(json.RawMessage) {
+		s.mu.Lock()
+		globs := slices.Clone(s.watch)
+		s.mu.Unlock()
+		if len(globs) > 0 {
+			go s.registerWatchers(conn, globs)
+		}
+	})
+	conn.Subscribe("workspace/didChangeWatchedFiles", func(params json.RawMessage) {
+		var p lsp.DidChangeWatchedFilesParams
+		if err := json.Unmarshal(params, &p); err == nil && s.OnChange != nil {
+			s.OnChange(p.Changes)
+		}
+	})
+
+	for method, h := range handlers {
+		conn.Handle(method, func(params json.RawMessage) (any, error) {
+			time.Sleep(s.Latency)
+			s.mu.Lock()
+			s.requests = append(s.requests, method)
+			if queued := s.failures[method]; len(queued) > 0 {
+				s.failures[method] = queued[1:]
+				s.mu.Unlock()
+				return nil, queued[0]
+			}
+			s.mu.Unlock()
+			return h(params)
+		})
+	}
+}
+
+// registerWatchers asks the client to report changes to files matching globs.
+func (s *Server) registerWatchers(conn *lsp.Conn, globs []string) {
+	var opts lsp.DidChangeWatchedFilesRegistrationOptions
+	for _, glob := range globs {
+		pattern, _ := json.Marshal(glob)
+		opts.Watchers = append(opts.Watchers, lsp.FileSystemWatcher{GlobPattern: pattern})
+	}
+	options, _ := json.Marshal(opts)
+	_ = conn.Call(context.Background(), "client/registerCapability", lsp.RegistrationParams{
+		Registrations: []lsp.Registration{{ID: "lsptest-watch", Method: "workspace/didChangeWatchedFiles", RegisterOptions: options}},
+	}, nil)
+}
+
+func (s *Server) initialize(json.RawMessage) (any, error) {
+	return map[string]any{"capabilities": s.Capabilities}, nil
+}
+
+func (s *Server) workspaceSymbol(params json.RawMessage) (any, error) {
+	var p lsp.WorkspaceSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := strings.ToLower(p.Query)
+	results := []lsp.SymbolInformation{}
+	for _, sym := range s.symbols {
+		// Like gopls, match the qualified name
+		if strings.Contains(strings.ToLower(sym.Container+"."+sym.Name), query) {
+			results = append(results, lsp.SymbolInformation{
+				Name:          sym.Name,
+				Kind:          sym.Kind,
+				Location:      lsp.Location{URI: s.URI(sym.File), Range: selectionRange(sym)},
+				ContainerName: sym.Container,
+			})
+		}
+		if s.SymbolLimit > 0 && len(results) == s.SymbolLimit {
+			break
+		}
+	}
+	return results, nil
+}
+
+func (s *Server) prepareCallHierarchy(params json.RawMessage) (any, error) {
+	sym, err := s.symbolAtPosition(params)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	return []lsp.CallHierarchyItem{s.callItem(sym)}, nil
+}
+
+func (s *Server) incomingCalls(params json.RawMessage) (any, error) {
+	var p lsp.CallHierarchyIncomingCallsParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.symbolForItem(p.Item.URI, p.Item.SelectionRange.Start)
+	if code, ok := s.broken[target]; ok {
+		return nil, &lsp.ResponseError{Code: code, Message: "lsptest: injected failure for " + target.Name}
+	}
+	results := []lsp.CallHierarchyIncomingCall{}
+	index := make(map[*Symbol]int)
+	for _, c := range s.calls {
+		if c.to != target {
+			continue
+		}
+		i, ok := index[c.from]
+		if !ok {
+			i = len(results)
+			index[c.from] = i
+			results = append(results, lsp.CallHierarchyIncomingCall{From: s.callItem(c.from)})
+		}
+		results[i].FromRanges = append(results[i].FromRanges, lineRange(c.line))
+	}
+	return results, nil
+}
+
+func (s *Server) outgoingCalls(params json.RawMessage) (any, error) {
+	var p lsp.CallHierarchyOutgoingCallsParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	source := s.symbolForItem(p.Item.URI, p.Item.SelectionRange.Start)
+	if code, ok := s.broken[source]; ok {
+		return nil, &lsp.ResponseError{Code: code, Message: "lsptest: injected failure for " + source.Name}
+	}
+	results := []lsp.CallHierarchyOutgoingCall{}
+	index := make(map[*Symbol]int)
+	for _, c := range s.calls {
+		if c.from != source {
+			continue
+		}
+		i, ok := index[c.to]
+		if !ok {
+			i = len(results)
+			index[c.to] = i
+			results = append(results, lsp.CallHierarchyOutgoingCall{To: s.callItem(c.to)})
+		}
+		results[i].FromRanges = append(results[i].FromRanges, lineRange(c.line))
+	}
+	return results, nil
+}
+
+func (s *Server) references(params json.RawMessage) (any, error) {
+	var p lsp.ReferenceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.symbolAt(p.TextDocument.URI, p.Position)
+	results := []lsp.Location{}
+	if target == nil {
+		return results, nil
+	}
+	if p.Context.IncludeDeclaration {
+		results = append(results, lsp.Location{URI: s.URI(target.File), Range: selectionRange(target)})
+	}
+	for _, c := range s.calls {
+		if c.to == target {
+			results = append(results, lsp.Location{URI: s.URI(c.from.File), Range: lineRange(c.line)})
+		}
+	}
+	for _, r := range s.refs {
+		if r.to == target {
+			results = append(results, lsp.Location{URI: s.URI(r.file), Range: lineRange(r.line)})
+		}
+	}
+	return results, nil
+}
+
+// definition resolves a call site to its callee by line, and a
+// declaration to itself.
+func (s *Server) definition(params json.RawMessage) (any, error) {
+	var p lsp.DefinitionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.calls {
+		if s.URI(c.from.File) == p.TextDocument.URI && c.line == p.Position.Line+1 {
+			return []lsp.Location{{URI: s.URI(c.to.File), Range: selectionRange(c.to)}}, nil
+		}
+	}
+	for _, sym := range s.symbols {
+		if s.URI(sym.File) == p.TextDocument.URI && sym.Line == p.Position.Line+1 {
+			return []lsp.Location{{URI: s.URI(sym.File), Range: selectionRange(sym)}}, nil
+		}
+	}
+	return []lsp.Location{}, nil
+}
+
+// documentSymbol lists a file's symbols in declaration order, nested
+// under their Parent if they have one.
+func (s *Server) documentSymbol(params json.RawMessage) (any, error) {
+	var p lsp.DocumentSymbolParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var children func(parent *Symbol) []lsp.DocumentSymbol
+	children = func(parent *Symbol) []lsp.DocumentSymbol {
+		results := []lsp.DocumentSymbol{}
+		for _, sym := range s.symbols {
+			if s.URI(sym.File) == p.TextDocument.URI && sym.Parent == parent {
+				results = append(results, lsp.DocumentSymbol{
+					Name:           sym.Name,
+					Detail:         sym.Container,
+					Kind:           sym.Kind,
+					Range:          fullRange(sym),
+					SelectionRange: selectionRange(sym),
+					Children:       children(sym),
+				})
+			}
+		}
+		return results
+	}
+	return children(nil), nil
+}
+
+func (s *Server) implementation(params json.RawMessage) (any, error) {
+	var p lsp.ImplementationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.symbolAt(p.TextDocument.URI, p.Position)
+	results := []lsp.Location{}
+	for _, i := range s.impls {
+		switch target {
+		case i.super:
+			results = append(results, lsp.Location{URI: s.URI(i.sub.File), Range: selectionRange(i.sub)})
+		case i.sub:
+			// Like gopls, a concrete symbol reports what it implements
+			results = append(results, lsp.Location{URI: s.URI(i.super.File), Range: selectionRange(i.super)})
+		}
+	}
+	return results, nil
+}
+
+func (s *Server) prepareTypeHierarchy(params json.RawMessage) (any, error) {
+	sym, err := s.symbolAtPosition(params)
+	if err != nil || sym == nil {
+		return nil, err
+	}
+	return []lsp.TypeHierarchyItem{s.typeItem(sym)}, nil
+}
+
+func (s *Server) supertypes(params json.RawMessage) (any, error) {
+	return s.relatedTypes(params, func(i impl) (*Symbol, *Symbol) { return i.sub, i.super })
+}
+
+func (s *Server) subtypes(params json.RawMessage) (any, error) {
+	return s.relatedTypes(params, func(i impl) (*Symbol, *Symbol) { return i.super, i.sub })
+}
+
+// relatedTypes walks one direction of the implements relation.
+func (s *Server) relatedTypes(params json.RawMessage, edge func(impl) (from, to *Symbol)) (any, error) {
+	var p lsp.TypeHierarchySupertypesParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := s.symbolForItem(p.Item.URI, p.Item.SelectionRange.Start)
+	if code, ok := s.broken[target]; ok {
+		return nil, &lsp.ResponseError{Code: code, Message: "lsptest: injected failure for " + target.Name}
+	}
+	results := []lsp.TypeHierarchyItem{}
+	for _, i := range s.impls {
+		if from, to := edge(i); from == target {
+			results = append(results, s.typeItem(to))
+		}
+	}
+	return results, nil
+}
+
+// symbolAtPosition decodes text document position params and finds the
+// declaration there.
+func (s *Server) symbolAtPosition(params json.RawMessage) (*Symbol, error) {
+	var p lsp.TextDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.symbolAt(p.TextDocument.URI, p.Position), nil
+}
+
+// symbolAt returns the innermost symbol whose range contains pos.
+func (s *Server) symbolAt(uri string, pos lsp.Position) *Symbol {
+	var best *Symbol
+	for _, sym := range s.symbols {
+		if s.URI(sym.File) != uri || pos.Line < sym.Line-1 || pos.Line > sym.EndLine-1 {
+			continue
+		}
+		if best == nil || sym.EndLine-sym.Line < best.EndLine-best.Line {
+			best = sym
+		}
+	}
+	return best
+}
+
+// symbolForItem finds the symbol a hierarchy item was built from.
+func (s *Server) symbolForItem(uri string, start lsp.Position) *Symbol {
+	for _, sym := range s.symbols {
+		if s.URI(sym.File) == uri && selectionRange(sym).Start == start {
+			return sym
+		}
+	}
+	return nil
+}
+
+func (s *Server) callItem(sym *Symbol) lsp.CallHierarchyItem {
+	return lsp.CallHierarchyItem{
+		Name:           sym.Name,
+		Kind:           sym.Kind,
+		Detail:         sym.Container,
+		URI:            s.URI(sym.File),
+		Range:          fullRange(sym),
+		SelectionRange: selectionRange(sym),
+	}
+}
+
+func (s *Server) typeItem(sym *Symbol) lsp.TypeHierarchyItem {
+	return lsp.TypeHierarchyItem{
+		Name:           sym.Name,
+		Kind:           sym.Kind,
+		Detail:         sym.Container,
+		URI:            s.URI(sym.File),
+		Range:          fullRange(sym),
+		SelectionRange: selectionRange(sym),
+	}
+}
+
+// selectionRange covers the symbol's name on its declaration line.
+func selectionRange(sym *Symbol) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: sym.Line - 1, Character: sym.Column},
+		End:   lsp.Position{Line: sym.Line - 1, Character: sym.Column + len(sym.Name)},
+	}
+}
+
+// fullRange covers the whole declaration.
+func fullRange(sym *Symbol) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: sym.Line - 1, Character: 0},
+		End:   lsp.Position{Line: sym.EndLine - 1, Character: 1},
+	}
+}
+
+// lineRange covers the start of a 1-based line.
+func lineRange(line int) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: line - 1, Character: 0},
+		End:   lsp.Position{Line: line - 1, Character: 1},
+	}
+}
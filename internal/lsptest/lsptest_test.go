@@ -0,0 +1,86 @@
+package lsptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+)
+
+func TestServer_References(t *testing.T) {
+	srv := NewServer("/ws")
+	load := srv.Func("config", "Load", "config.go", 10)
+	main := srv.Func("main", "main", "main.go", 3)
+	srv.Call(main, load, 4)
+	srv.Reference(load, "vars.go", 7)
+	client := srv.Client(t)
+
+	locs, err := client.References(t.Context(), srv.URI("config.go"), lsp.Position{Line: 9}, true)
+	if err != nil {
+		t.Fatalf("References: %v", err)
+	}
+
+	want := []string{"config.go:10", "main.go:4", "vars.go:7"}
+	if len(locs) != len(want) {
+		t.Fatalf("got %d locations, want %d", len(locs), len(want))
+	}
+	for i, loc := range locs {
+		got := fmt.Sprintf("%s:%d", strings.TrimPrefix(loc.URI, "file:///ws/"), loc.Range.Start.Line+1)
+		if got != want[i] {
+			t.Errorf("location %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestServer_TypeHierarchy(t *testing.T) {
+	srv := NewServer("/ws")
+	reader := srv.Interface("io", "Reader", "io.go", 5)
+	file := srv.Struct("os", "File", "file.go", 12)
+	srv.Implements(file, reader)
+	client := srv.Client(t)
+
+	impls, err := client.Implementation(t.Context(), srv.URI("io.go"), lsp.Position{Line: 4})
+	if err != nil {
+		t.Fatalf("Implementation: %v", err)
+	}
+	if len(impls) != 1 || impls[0].URI != srv.URI("file.go") {
+		t.Errorf("implementations = %+v, want file.go", impls)
+	}
+
+	items, err := client.PrepareTypeHierarchy(t.Context(), srv.URI("file.go"), lsp.Position{Line: 11})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("PrepareTypeHierarchy = %v, %v", items, err)
+	}
+	supers, err := client.Supertypes(t.Context(), items[0])
+	if err != nil {
+		t.Fatalf("Supertypes: %v", err)
+	}
+	if len(supers) != 1 || supers[0].Name != "Reader" {
+		t.Errorf("supertypes = %+v, want Reader", supers)
+	}
+}
+
+func TestServer_Fail(t *testing.T) {
+	srv := NewServer("/ws")
+	srv.Func("config", "Load", "config.go", 10)
+	srv.Fail("workspace/symbol", lsp.CodeContentModified)
+	client := srv.Client(t)
+	client.SetRetryPolicy(lsp.RetryPolicy{MaxRetries: 1})
+
+	syms, err := client.WorkspaceSymbol(t.Context(), "Load")
+	if err != nil {
+		t.Fatalf("WorkspaceSymbol: %v", err)
+	}
+	if len(syms) != 1 {
+		t.Errorf("got %d symbols, want 1", len(syms))
+	}
+	if client.Retries() != 1 {
+		t.Errorf("Retries() = %d, want 1", client.Retries())
+	}
+
+	reqs := srv.Requests()
+	if len(reqs) != 3 || reqs[1] != "workspace/symbol" || reqs[2] != "workspace/symbol" {
+		t.Errorf("requests = %v, want initialize then two workspace/symbol", reqs)
+	}
+}
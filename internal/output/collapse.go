@@ -0,0 +1,127 @@
+package output
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+// CollapseTree aggregates the nodes of a tree into package or file groups.
+func CollapseTree(tree *TreeResponse, by string) *CollapsedTreeResponse {
+	members := make([]member, 0, len(tree.Nodes))
+	for id, n := range tree.Nodes {
+		members = append(members, member{id: id, pkg: n.Package, file: n.File, truncated: n.Truncated || n.Error != "", depth: n.Depth})
+	}
+	nodes, edges, groupOf := collapse(members, tree.Edges, by)
+
+	query := tree.Query
+	if g, ok := groupOf[query.RootID]; ok {
+		query.RootID = g
+	}
+	return &CollapsedTreeResponse{
+		Query:    query,
+		Collapse: by,
+		Nodes:    nodes,
+		Edges:    edges,
+		Summary:  tree.Summary,
+		Meta:     tree.Meta,
+	}
+}
+
+// CollapseGraph aggregates the nodes of a graph into package or file groups.
+func CollapseGraph(graph *GraphResponse, by string) *CollapsedGraphResponse {
+	members := make([]member, 0, len(graph.Nodes))
+	for id, n := range graph.Nodes {
+		members = append(members, member{id: id, pkg: n.Package, file: n.File, truncated: n.Truncated || n.Error != ""})
+	}
+	nodes, edges, _ := collapse(members, graph.Edges, by)
+
+	return &CollapsedGraphResponse{
+		Query:    graph.Query,
+		Collapse: by,
+		Nodes:    nodes,
+		Edges:    edges,
+		Summary:  graph.Summary,
+		Meta:     graph.Meta,
+	}
+}
+
+// member is a function being collapsed into its group.
+type member struct {
+	id        string
+	pkg       string
+	file      string
+	truncated bool
+	depth     int
+}
+
+// collapse groups members by package or file and sums the calls between
+// groups. Edges keep the order in which each pair first appears. It also
+// returns the group of each member.
+func collapse(members []member, calls []TreeEdge, by string) (map[string]GroupNode, []GroupEdge, map[string]string) {
+	nodes := make(map[string]GroupNode)
+	groupOf := make(map[string]string, len(members))
+	for _, m := range members {
+		id, name := groupKey(m, by)
+		groupOf[m.id] = id
+		g, ok := nodes[id]
+		if !ok || nearer(m.depth, g.Depth) {
+			g.Depth = m.depth
+		}
+		if !ok {
+			g.ID, g.Name = id, name
+		}
+		g.Functions = append(g.Functions, m.id)
+		g.Truncated = g.Truncated || m.truncated
+		nodes[id] = g
+	}
+	for id, g := range nodes {
+		sort.Strings(g.Functions)
+		nodes[id] = g
+	}
+
+	edges := []GroupEdge{}
+	index := make(map[[2]string]int)
+	for _, c := range calls {
+		pair := [2]string{groupOf[c.From], groupOf[c.To]}
+		i, ok := index[pair]
+		if !ok {
+			i = len(edges)
+			index[pair] = i
+			edges = append(edges, GroupEdge{From: pair[0], To: pair[1]})
+		}
+		edges[i].Weight++
+	}
+	return nodes, edges, groupOf
+}
+
+// nearer reports whether depth a is nearer the root than b. Callers win
+// ties, so a group drawn in both directions sits on the callers' side.
+func nearer(a, b int) bool {
+	if abs(a) != abs(b) {
+		return abs(a) < abs(b)
+	}
+	return a < b
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// groupKey returns the ID and display name of a member's group: its
+// package and the package's last element, or its file and the file with
+// its directory's last element. Members without a package are grouped by
+// directory.
+func groupKey(m member, by string) (id, name string) {
+	if by == CollapseFile {
+		return m.file, filepath.Join(filepath.Base(filepath.Dir(m.file)), filepath.Base(m.file))
+	}
+	if m.pkg == "" {
+		dir := filepath.Dir(m.file)
+		return dir, filepath.Base(dir)
+	}
+	return m.pkg, path.Base(m.pkg)
+}
@@ -0,0 +1,101 @@
+package output
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func collapseTree() *TreeResponse {
+	return &TreeResponse{
+		Query: TreeQuery{Command: "tree", Root: "main", RootID: "app/cmd.main"},
+		Nodes: map[string]TreeNode{
+			"app/cmd.main":      {Package: "app/cmd", File: "/app/cmd/main.go"},
+			"app/cmd.run":       {Package: "app/cmd", File: "/app/cmd/run.go"},
+			"app/db.Query":      {Package: "app/db", File: "/app/db/query.go"},
+			"app/db.exec":       {Package: "app/db", File: "/app/db/query.go", Truncated: true},
+			"app/server.Handle": {Package: "app/server", File: "/app/server/main.go"},
+		},
+		Edges: []TreeEdge{
+			{From: "app/cmd.main", To: "app/cmd.run"},
+			{From: "app/cmd.run", To: "app/db.Query"},
+			{From: "app/cmd.run", To: "app/server.Handle"},
+			{From: "app/server.Handle", To: "app/db.Query"},
+			{From: "app/server.Handle", To: "app/db.Query"},
+			{From: "app/db.Query", To: "app/db.exec"},
+		},
+	}
+}
+
+func TestCollapseTree_Package(t *testing.T) {
+	got := CollapseTree(collapseTree(), CollapsePackage)
+
+	if got.Collapse != CollapsePackage || got.Query.RootID != "app/cmd" {
+		t.Errorf("collapse, root = %q, %q, want package, app/cmd", got.Collapse, got.Query.RootID)
+	}
+	if len(got.Nodes) != 3 {
+		t.Fatalf("got %d groups, want 3", len(got.Nodes))
+	}
+	db := got.Nodes["app/db"]
+	if db.Name != "db" || !slices.Equal(db.Functions, []string{"app/db.Query", "app/db.exec"}) || !db.Truncated {
+		t.Errorf("db group = %+v", db)
+	}
+
+	want := []GroupEdge{
+		{From: "app/cmd", To: "app/cmd", Weight: 1},
+		{From: "app/cmd", To: "app/db", Weight: 1},
+		{From: "app/cmd", To: "app/server", Weight: 1},
+		{From: "app/server", To: "app/db", Weight: 2},
+		{From: "app/db", To: "app/db", Weight: 1},
+	}
+	if !slices.Equal(got.Edges, want) {
+		t.Errorf("edges = %v, want %v", got.Edges, want)
+	}
+}
+
+func TestCollapseTree_File(t *testing.T) {
+	got := CollapseTree(collapseTree(), CollapseFile)
+
+	if len(got.Nodes) != 4 {
+		t.Fatalf("got %d groups, want 4", len(got.Nodes))
+	}
+	// Same base name, told apart by directory
+	if name := got.Nodes["/app/cmd/main.go"].Name; name != "cmd/main.go" {
+		t.Errorf("name = %q, want cmd/main.go", name)
+	}
+	if name := got.Nodes["/app/server/main.go"].Name; name != "server/main.go" {
+		t.Errorf("name = %q, want server/main.go", name)
+	}
+	if got.Query.RootID != "/app/cmd/main.go" {
+		t.Errorf("root = %q, want /app/cmd/main.go", got.Query.RootID)
+	}
+}
+
+func TestCollapseTree_Depth(t *testing.T) {
+	tree := &TreeResponse{
+		Query: TreeQuery{Command: "tree", Root: "run", RootID: "app/cmd.run", Direction: "both"},
+		Nodes: map[string]TreeNode{
+			"app/cmd.main":      {Package: "app/cmd", Depth: -1},
+			"app/cmd.run":       {Package: "app/cmd"},
+			"app/server.Handle": {Package: "app/server", Depth: 1},
+			"app/db.Query":      {Package: "app/db", Depth: 2},
+			"app/db.exec":       {Package: "app/db", Depth: 3},
+		},
+	}
+	got := CollapseTree(tree, CollapsePackage)
+
+	for id, want := range map[string]int{"app/cmd": 0, "app/server": 1, "app/db": 2} {
+		if d := got.Nodes[id].Depth; d != want {
+			t.Errorf("%s depth = %d, want %d", id, d, want)
+		}
+	}
+
+	// Each depth gets its own rank rather than one row for every group
+	out, err := (&DotFormatter{}).Format(got)
+	if err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+	if n := strings.Count(string(out), "rank=same"); n != 3 {
+		t.Errorf("got %d ranks, want 3:\n%s", n, out)
+	}
+}
@@ -0,0 +1,35 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteGraphCSV(t *testing.T) {
+	g := &GraphResponse{
+		Nodes: map[string]GraphNode{
+			"ws.run":      {ID: "ws.run", Name: "ws.run", Kind: "function", Package: "ws", File: "/ws/main.go", Line: 8},
+			"fmt.Println": {ID: "fmt.Println", Name: "fmt.Println", Kind: "function", Package: "fmt", File: "/go/src/fmt/print.go", Line: 10, Stdlib: true},
+		},
+		Edges: []TreeEdge{
+			{From: "ws.run", To: "fmt.Println", File: "/ws/main.go", Line: 9},
+		},
+	}
+
+	var nodes, edges bytes.Buffer
+	if err := WriteGraphCSV(&nodes, &edges, g); err != nil {
+		t.Fatalf("WriteGraphCSV: %v", err)
+	}
+
+	wantNodes := "id,name,kind,package,file,line,test,stdlib,external,truncated,error\n" +
+		"fmt.Println,fmt.Println,function,fmt,/go/src/fmt/print.go,10,false,true,false,false,\n" +
+		"ws.run,ws.run,function,ws,/ws/main.go,8,false,false,false,false,\n"
+	if nodes.String() != wantNodes {
+		t.Errorf("nodes:\n%s\nwant:\n%s", nodes.String(), wantNodes)
+	}
+	wantEdges := "from,to,file,line,dynamic\n" +
+		"ws.run,fmt.Println,/ws/main.go,9,false\n"
+	if edges.String() != wantEdges {
+		t.Errorf("edges:\n%s\nwant:\n%s", edges.String(), wantEdges)
+	}
+}
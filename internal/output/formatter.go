@@ -3,10 +3,14 @@ package output
 import (
 	"bytes"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"text/template"
@@ -40,6 +44,7 @@ func NewRegistry() *Registry {
 	r.Register(&YAMLFormatter{})
 	r.Register(&DotFormatter{})
 	r.Register(&MarkdownFormatter{})
+	r.Register(&GraphMLFormatter{})
 	return r
 }
 
@@ -212,49 +217,175 @@ func (f *DotFormatter) Format(result any) ([]byte, error) {
 		return nil, err
 	}
 
+	// A tree in both directions is drawn top to bottom, callers above
+	// the root and callees below it, one rank per depth
+	both := false
+	if query, ok := data["query"].(map[string]any); ok {
+		both = query["direction"] == "both"
+	}
+
 	var buf bytes.Buffer
 	buf.WriteString("digraph callgraph {\n")
-	buf.WriteString("  rankdir=LR;\n")
+	if both {
+		buf.WriteString("  rankdir=TB;\n")
+	} else {
+		buf.WriteString("  rankdir=LR;\n")
+	}
 	buf.WriteString("  node [shape=box, fontname=\"Courier\"];\n")
 
+	// Declare tree nodes by ID, labelled with their display names
+	if nodes, ok := data["nodes"].(map[string]any); ok {
+		ranks := make(map[int][]string)
+		for _, id := range sortedKeys(nodes) {
+			n, _ := nodes[id].(map[string]any)
+			name, _ := n["name"].(string)
+			if name == "" {
+				name = id
+			}
+			label := dotEscape(name)
+			if functions, ok := n["functions"].([]any); ok {
+				label += fmt.Sprintf("\\n%d functions", len(functions))
+			}
+			attrs := fmt.Sprintf("label=\"%s\"", label)
+			if errMsg, _ := n["error"].(string); errMsg != "" {
+				attrs += ", color=red"
+			} else if truncated, _ := n["truncated"].(bool); truncated {
+				attrs += ", style=dashed"
+			}
+			if n["stdlib"] == true || n["external"] == true {
+				attrs += ", fontcolor=grey"
+			}
+			buf.WriteString(fmt.Sprintf("  \"%s\" [%s];\n", dotEscape(id), attrs))
+
+			depth, _ := n["depth"].(float64)
+			ranks[int(depth)] = append(ranks[int(depth)], id)
+		}
+
+		if both {
+			depths := make([]int, 0, len(ranks))
+			for d := range ranks {
+				depths = append(depths, d)
+			}
+			sort.Ints(depths)
+			for _, d := range depths {
+				buf.WriteString("  { rank=same;")
+				for _, id := range ranks[d] {
+					buf.WriteString(fmt.Sprintf(" \"%s\";", dotEscape(id)))
+				}
+				buf.WriteString(" }\n")
+			}
+		}
+	}
+
 	// Extract edges from tree response
 	if edges, ok := data["edges"].([]any); ok {
 		for _, edge := range edges {
 			if e, ok := edge.(map[string]any); ok {
 				from, _ := e["from"].(string)
 				to, _ := e["to"].(string)
+				var attrs []string
+				if dynamic, _ := e["dynamic"].(bool); dynamic {
+					attrs = append(attrs, "style=dashed")
+				}
+				if back, _ := e["back_edge"].(bool); back {
+					attrs = append(attrs, "color=orange", "constraint=false")
+				}
+				if weight, ok := e["weight"].(float64); ok {
+					attrs = append(attrs, fmt.Sprintf("label=\"%.0f\"", weight))
+				}
 				if from != "" && to != "" {
-					safeFrom := strings.ReplaceAll(from, "\"", "\\\"")
-					safeTo := strings.ReplaceAll(to, "\"", "\\\"")
-					buf.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\";\n", safeFrom, safeTo))
+					buf.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\"%s;\n", dotEscape(from), dotEscape(to), dotAttrs(attrs)))
+				}
+			}
+		}
+	}
+
+	// Draw call paths, highlighting the shortest and both endpoints
+	if paths, ok := data["paths"].([]any); ok {
+		if len(paths) > 0 {
+			first, _ := paths[0].(map[string]any)
+			if symbols, _ := first["symbols"].([]any); len(symbols) > 0 {
+				for _, end := range []any{symbols[0], symbols[len(symbols)-1]} {
+					name, _ := end.(string)
+					buf.WriteString(fmt.Sprintf("  \"%s\" [style=filled, fillcolor=lightgrey];\n", dotEscape(name)))
 				}
 			}
 		}
+
+		drawn := make(map[[2]string]bool)
+		for i, p := range paths {
+			path, _ := p.(map[string]any)
+			hops, _ := path["hops"].([]any)
+			for _, h := range hops {
+				hop, _ := h.(map[string]any)
+				from, _ := hop["from"].(string)
+				to, _ := hop["to"].(string)
+				if drawn[[2]string{from, to}] {
+					continue
+				}
+				drawn[[2]string{from, to}] = true
+
+				attrs := ""
+				if i == 0 {
+					attrs = " [color=red, penwidth=2]"
+				}
+				buf.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\"%s;\n", dotEscape(from), dotEscape(to), attrs))
+			}
+		}
 	}
 
-	// Also handle callers/callees results as simple node lists
-	if results, ok := data["results"].([]any); ok {
+	// Also handle callers/callees results as simple node lists, with one
+	// section per target for pattern queries
+	sections := []any{data}
+	if targets, ok := data["targets"].([]any); ok {
+		sections = targets
+	}
+	cmd := ""
+	if query, ok := data["query"].(map[string]any); ok {
+		cmd, _ = query["command"].(string)
+	}
+	seen := make(map[string]bool)
+	edge := func(from, to string) {
+		line := fmt.Sprintf("  \"%s\" -> \"%s\";\n", dotEscape(from), dotEscape(to))
+		if !seen[line] {
+			seen[line] = true
+			buf.WriteString(line)
+		}
+	}
+	for _, sec := range sections {
+		section, _ := sec.(map[string]any)
+		results := resultRows(section)
 		targetName := ""
-		if target, ok := data["target"].(map[string]any); ok {
+		if target, ok := section["target"].(map[string]any); ok {
 			targetName, _ = target["symbol"].(string)
 		}
+
 		for _, r := range results {
-			if result, ok := r.(map[string]any); ok {
-				symbol, _ := result["symbol"].(string)
-				if symbol != "" && targetName != "" {
-					safeSymbol := strings.ReplaceAll(symbol, "\"", "\\\"")
-					safeTarget := strings.ReplaceAll(targetName, "\"", "\\\"")
-					// For callers, the symbol calls the target
-					// For callees, the target calls the symbol
-					if query, ok := data["query"].(map[string]any); ok {
-						cmd, _ := query["command"].(string)
-						if cmd == "callees" {
-							buf.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\";\n", safeTarget, safeSymbol))
-						} else {
-							buf.WriteString(fmt.Sprintf("  \"%s\" -> \"%s\";\n", safeSymbol, safeTarget))
-						}
-					}
+			result, ok := r.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			// Transitive results carry the whole path, already in call order
+			if chain, ok := result["chain"].([]any); ok && len(chain) > 1 {
+				for i := 1; i < len(chain); i++ {
+					from, _ := chain[i-1].(string)
+					to, _ := chain[i].(string)
+					edge(from, to)
 				}
+				continue
+			}
+
+			symbol, _ := result["symbol"].(string)
+			if symbol == "" || targetName == "" || cmd == "" {
+				continue
+			}
+			// For callers, the symbol calls the target
+			// For callees, the target calls the symbol
+			if cmd == "callees" {
+				edge(targetName, symbol)
+			} else {
+				edge(symbol, targetName)
 			}
 		}
 	}
@@ -263,6 +394,181 @@ func (f *DotFormatter) Format(result any) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// resultRows returns the results of a response, flattening groups.
+func resultRows(data map[string]any) []any {
+	results, _ := data["results"].([]any)
+	if groups, ok := data["groups"].([]any); ok {
+		for _, g := range groups {
+			if group, ok := g.(map[string]any); ok {
+				rows, _ := group["results"].([]any)
+				results = append(results, rows...)
+			}
+		}
+	}
+	return results
+}
+
+// dotAttrs formats an attribute list, or "" if there are none.
+func dotAttrs(attrs []string) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(attrs, ", ") + "]"
+}
+
+// dotEscape quotes s for use inside a DOT string.
+func dotEscape(s string) string {
+	return strings.ReplaceAll(s, "\"", "\\\"")
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GraphMLFormatter outputs the nodes and edges of a tree or graph as
+// GraphML. Every scalar field becomes a data attribute, and every list a
+// space-separated string.
+type GraphMLFormatter struct{}
+
+func (f *GraphMLFormatter) Name() string        { return "graphml" }
+func (f *GraphMLFormatter) Description() string { return "GraphML (for call trees and graphs)" }
+
+func (f *GraphMLFormatter) Format(result any) ([]byte, error) {
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	var data map[string]any
+	if err := json.Unmarshal(jsonBytes, &data); err != nil {
+		return nil, err
+	}
+
+	nodes, _ := data["nodes"].(map[string]any)
+	edges, _ := data["edges"].([]any)
+	var edgeRows []map[string]any
+	for _, e := range edges {
+		if edge, ok := e.(map[string]any); ok {
+			edgeRows = append(edgeRows, edge)
+		}
+	}
+	nodeRows := make([]map[string]any, 0, len(nodes))
+	for _, id := range sortedKeys(nodes) {
+		n, _ := nodes[id].(map[string]any)
+		if n == nil {
+			n = map[string]any{}
+		}
+		n["id"] = id
+		nodeRows = append(nodeRows, n)
+	}
+
+	nodeKeys := graphMLKeys(nodeRows, "id")
+	edgeKeys := graphMLKeys(edgeRows, "from", "to")
+
+	var buf bytes.Buffer
+	buf.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	buf.WriteString("<graphml xmlns=\"http://graphml.graphdrawing.org/xmlns\">\n")
+	for _, k := range nodeKeys {
+		buf.WriteString(fmt.Sprintf("  <key id=\"n_%s\" for=\"node\" attr.name=\"%s\" attr.type=\"%s\"/>\n", k.name, k.name, k.typ))
+	}
+	for _, k := range edgeKeys {
+		buf.WriteString(fmt.Sprintf("  <key id=\"e_%s\" for=\"edge\" attr.name=\"%s\" attr.type=\"%s\"/>\n", k.name, k.name, k.typ))
+	}
+	buf.WriteString("  <graph id=\"callgraph\" edgedefault=\"directed\">\n")
+	for _, n := range nodeRows {
+		id, _ := n["id"].(string)
+		buf.WriteString(fmt.Sprintf("    <node id=\"%s\">\n", xmlEscape(id)))
+		writeGraphMLData(&buf, "n_", nodeKeys, n)
+		buf.WriteString("    </node>\n")
+	}
+	for _, e := range edgeRows {
+		from, _ := e["from"].(string)
+		to, _ := e["to"].(string)
+		buf.WriteString(fmt.Sprintf("    <edge source=\"%s\" target=\"%s\">\n", xmlEscape(from), xmlEscape(to)))
+		writeGraphMLData(&buf, "e_", edgeKeys, e)
+		buf.WriteString("    </edge>\n")
+	}
+	buf.WriteString("  </graph>\n")
+	buf.WriteString("</graphml>\n")
+
+	return buf.Bytes(), nil
+}
+
+// graphMLKey declares one data attribute.
+type graphMLKey struct {
+	name string
+	typ  string // GraphML attr.type
+}
+
+// graphMLKeys returns the scalar fields found in rows, sorted by name,
+// leaving out the ones named in skip.
+func graphMLKeys(rows []map[string]any, skip ...string) []graphMLKey {
+	types := make(map[string]string)
+	for _, row := range rows {
+		for name, v := range row {
+			if slices.Contains(skip, name) {
+				continue
+			}
+			switch v := v.(type) {
+			case string:
+				types[name] = "string"
+			case bool:
+				types[name] = "boolean"
+			case []any:
+				types[name] = "string" // written space-separated
+			case float64:
+				if types[name] != "double" {
+					types[name] = "int"
+					if v != float64(int64(v)) {
+						types[name] = "double"
+					}
+				}
+			}
+		}
+	}
+
+	keys := make([]graphMLKey, 0, len(types))
+	for name, typ := range types {
+		keys = append(keys, graphMLKey{name: name, typ: typ})
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].name < keys[j].name })
+	return keys
+}
+
+// writeGraphMLData writes the data elements of one node or edge.
+func writeGraphMLData(buf *bytes.Buffer, prefix string, keys []graphMLKey, row map[string]any) {
+	for _, k := range keys {
+		v, ok := row[k.name]
+		if !ok {
+			continue
+		}
+		text := fmt.Sprint(v)
+		switch v := v.(type) {
+		case float64:
+			text = strconv.FormatFloat(v, 'f', -1, 64)
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			text = strings.Join(items, " ")
+		}
+		buf.WriteString(fmt.Sprintf("      <data key=\"%s%s\">%s</data>\n", prefix, k.name, xmlEscape(text)))
+	}
+}
+
+// xmlEscape escapes s for use in XML text and attributes.
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
 // MarkdownFormatter outputs Markdown tables and lists.
 type MarkdownFormatter struct{}
 
@@ -286,26 +592,117 @@ func (f *MarkdownFormatter) Format(result any) ([]byte, error) {
 		buf.WriteString(fmt.Sprintf("# %s: %s\n\n", strings.Title(cmd), target))
 	}
 
-	// Format results as table
+	// Format results as table, one per depth when grouped
 	if results, ok := data["results"].([]any); ok && len(results) > 0 {
-		buf.WriteString("| Symbol | File | Line |\n")
-		buf.WriteString("|--------|------|------|\n")
+		writeMarkdownResults(&buf, results)
+	}
+	if groups, ok := data["groups"].([]any); ok {
+		for _, g := range groups {
+			group, _ := g.(map[string]any)
+			depth, _ := group["depth"].(float64)
+			results, _ := group["results"].([]any)
+			buf.WriteString(fmt.Sprintf("## Depth %.0f\n\n", depth))
+			writeMarkdownResults(&buf, results)
+		}
+	}
 
-		for _, r := range results {
-			if row, ok := r.(map[string]any); ok {
-				symbol, _ := row["symbol"].(string)
-				file, _ := row["file"].(string)
-				line, _ := row["line"].(float64)
-
-				// Shorten file path
-				if len(file) > 40 {
-					file = "..." + file[len(file)-37:]
-				}
+	// Format one table per target of a pattern query
+	if targets, ok := data["targets"].([]any); ok {
+		for _, t := range targets {
+			section, _ := t.(map[string]any)
+			target, _ := section["target"].(map[string]any)
+			symbol, _ := target["symbol"].(string)
+			buf.WriteString(fmt.Sprintf("## %s\n\n", symbol))
+			if rows := resultRows(section); len(rows) > 0 {
+				writeMarkdownResults(&buf, rows)
+			}
+		}
+	}
 
-				buf.WriteString(fmt.Sprintf("| %s | %s | %.0f |\n", symbol, file, line))
+	// Format collapsed groups with the functions each contains
+	collapsed, _ := data["collapse"].(string)
+	if nodes, ok := data["nodes"].(map[string]any); ok && len(nodes) > 0 && collapsed != "" {
+		names := make(map[string]string, len(nodes))
+		buf.WriteString("## Groups\n\n")
+		buf.WriteString(fmt.Sprintf("| %s | Functions |\n", strings.Title(collapsed)))
+		buf.WriteString("|------|-----------|\n")
+		for _, id := range sortedKeys(nodes) {
+			n, _ := nodes[id].(map[string]any)
+			name, _ := n["name"].(string)
+			names[id] = name
+			var functions []string
+			list, _ := n["functions"].([]any)
+			for _, f := range list {
+				s, _ := f.(string)
+				functions = append(functions, s)
 			}
+			buf.WriteString(fmt.Sprintf("| %s | %s |\n", name, strings.Join(functions, ", ")))
 		}
 		buf.WriteString("\n")
+
+		if edges, ok := data["edges"].([]any); ok && len(edges) > 0 {
+			buf.WriteString("## Edges\n\n")
+			for _, edge := range edges {
+				e, _ := edge.(map[string]any)
+				from, _ := e["from"].(string)
+				to, _ := e["to"].(string)
+				weight, _ := e["weight"].(float64)
+				buf.WriteString(fmt.Sprintf("- `%s` → `%s` (%.0f calls)\n", names[from], names[to], weight))
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	// Format call graph nodes and edges, named by display name
+	if nodes, ok := data["nodes"].(map[string]any); ok && len(nodes) > 0 && collapsed == "" {
+		names := make(map[string]string, len(nodes))
+		buf.WriteString("## Nodes\n\n")
+		buf.WriteString("| Node | File | Line |\n")
+		buf.WriteString("|------|------|------|\n")
+		for _, id := range sortedKeys(nodes) {
+			n, _ := nodes[id].(map[string]any)
+			name, _ := n["name"].(string)
+			if name == "" {
+				name = id
+			}
+			names[id] = name
+			file, _ := n["file"].(string)
+			line, _ := n["line"].(float64)
+			buf.WriteString(fmt.Sprintf("| %s | %s | %.0f |\n", name, filepath.Base(file), line))
+		}
+		buf.WriteString("\n")
+
+		if edges, ok := data["edges"].([]any); ok && len(edges) > 0 {
+			buf.WriteString("## Edges\n\n")
+			for _, edge := range edges {
+				e, _ := edge.(map[string]any)
+				from, _ := e["from"].(string)
+				to, _ := e["to"].(string)
+				file, _ := e["file"].(string)
+				line, _ := e["line"].(float64)
+				buf.WriteString(fmt.Sprintf("- `%s` → `%s` (%s:%.0f)\n", names[from], names[to], filepath.Base(file), line))
+			}
+			buf.WriteString("\n")
+		}
+	}
+
+	// Format call paths, one list of hops per path
+	if paths, ok := data["paths"].([]any); ok {
+		for i, p := range paths {
+			path, _ := p.(map[string]any)
+			length, _ := path["length"].(float64)
+			buf.WriteString(fmt.Sprintf("## Path %d (%.0f calls)\n\n", i+1, length))
+			hops, _ := path["hops"].([]any)
+			for _, h := range hops {
+				hop, _ := h.(map[string]any)
+				from, _ := hop["from"].(string)
+				to, _ := hop["to"].(string)
+				file, _ := hop["file"].(string)
+				line, _ := hop["line"].(float64)
+				buf.WriteString(fmt.Sprintf("- `%s` → `%s` (%s:%.0f)\n", from, to, filepath.Base(file), line))
+			}
+			buf.WriteString("\n")
+		}
 	}
 
 	// Format tree if present
@@ -326,6 +723,27 @@ func (f *MarkdownFormatter) Format(result any) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+func writeMarkdownResults(buf *bytes.Buffer, results []any) {
+	buf.WriteString("| Symbol | File | Line |\n")
+	buf.WriteString("|--------|------|------|\n")
+
+	for _, r := range results {
+		if row, ok := r.(map[string]any); ok {
+			symbol, _ := row["symbol"].(string)
+			file, _ := row["file"].(string)
+			line, _ := row["line"].(float64)
+
+			// Shorten file path
+			if len(file) > 40 {
+				file = "..." + file[len(file)-37:]
+			}
+
+			buf.WriteString(fmt.Sprintf("| %s | %s | %.0f |\n", symbol, file, line))
+		}
+	}
+	buf.WriteString("\n")
+}
+
 func writeMarkdownTree(buf *bytes.Buffer, node map[string]any, depth int) {
 	name, _ := node["name"].(string)
 	file, _ := node["file"].(string)
@@ -91,6 +91,167 @@ func TestDotFormatter(t *testing.T) {
 	}
 }
 
+func TestDotFormatter_TreeNodes(t *testing.T) {
+	f := &DotFormatter{}
+
+	// Two functions named New, told apart by ID but labelled by name
+	tree := TreeResponse{
+		Nodes: map[string]TreeNode{
+			"example.com/a.New": {ID: "example.com/a.New", Name: "a.New"},
+			"example.com/b.New": {ID: "example.com/b.New", Name: "b.New", Truncated: true},
+		},
+		Edges: []TreeEdge{
+			{From: "example.com/a.New", To: "example.com/b.New"},
+			{From: "example.com/b.New", To: "example.com/a.New", Dynamic: true, BackEdge: true},
+		},
+	}
+	result, err := f.Format(tree)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	output := string(result)
+	for _, want := range []string{
+		`"example.com/a.New" [label="a.New"];`,
+		`"example.com/b.New" [label="b.New", style=dashed];`,
+		`"example.com/a.New" -> "example.com/b.New";`,
+		`"example.com/b.New" -> "example.com/a.New" [style=dashed, color=orange, constraint=false];`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Missing %s, got: %s", want, output)
+		}
+	}
+}
+
+func TestDotFormatter_BothDirections(t *testing.T) {
+	f := &DotFormatter{}
+
+	tree := TreeResponse{
+		Query: TreeQuery{Command: "tree", Root: "load", Direction: "both"},
+		Nodes: map[string]TreeNode{
+			"main":  {ID: "main", Name: "main", Depth: -2},
+			"run":   {ID: "run", Name: "run", Depth: -1},
+			"load":  {ID: "load", Name: "load"},
+			"parse": {ID: "parse", Name: "parse", Depth: 1},
+			"lex":   {ID: "lex", Name: "lex", Depth: 1},
+		},
+	}
+	result, err := f.Format(tree)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	output := string(result)
+	if !strings.Contains(output, "rankdir=TB;") {
+		t.Errorf("Missing rankdir=TB, got: %s", output)
+	}
+	// Ranks run from the furthest caller down to the callees
+	last := -1
+	for _, want := range []string{
+		`{ rank=same; "main"; }`,
+		`{ rank=same; "run"; }`,
+		`{ rank=same; "load"; }`,
+		`{ rank=same; "lex"; "parse"; }`,
+	} {
+		i := strings.Index(output, want)
+		if i < 0 {
+			t.Errorf("Missing %s, got: %s", want, output)
+			continue
+		}
+		if i < last {
+			t.Errorf("%s out of order, got: %s", want, output)
+		}
+		last = i
+	}
+
+	// One-way trees keep their left-to-right layout
+	tree.Query.Direction = "down"
+	result, err = f.Format(tree)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if output := string(result); !strings.Contains(output, "rankdir=LR;") || strings.Contains(output, "rank=same") {
+		t.Errorf("one-way tree should not be ranked, got: %s", output)
+	}
+}
+
+func TestGraphMLFormatter(t *testing.T) {
+	f := &GraphMLFormatter{}
+
+	g := GraphResponse{
+		Nodes: map[string]GraphNode{
+			"ws.run":      {ID: "ws.run", Name: "ws.run", Kind: "function", File: "/ws/main.go", Line: 8},
+			"ws.<lambda>": {ID: "ws.<lambda>", Name: "ws.<lambda>", Kind: "function", Test: true},
+		},
+		Edges: []TreeEdge{
+			{From: "ws.run", To: "ws.<lambda>", File: "/ws/main.go", Line: 9, Dynamic: true},
+		},
+	}
+	result, err := f.Format(g)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	output := string(result)
+	for _, want := range []string{
+		`<key id="n_line" for="node" attr.name="line" attr.type="int"/>`,
+		`<key id="n_test" for="node" attr.name="test" attr.type="boolean"/>`,
+		`<key id="e_dynamic" for="edge" attr.name="dynamic" attr.type="boolean"/>`,
+		`<graph id="callgraph" edgedefault="directed">`,
+		`<node id="ws.&lt;lambda&gt;">`,
+		`<data key="n_file">/ws/main.go</data>`,
+		`<edge source="ws.run" target="ws.&lt;lambda&gt;">`,
+		`<data key="e_line">9</data>`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Missing %s, got: %s", want, output)
+		}
+	}
+	if strings.Contains(output, `"n_id"`) || strings.Contains(output, `"e_from"`) {
+		t.Errorf("identity fields repeated as data, got: %s", output)
+	}
+}
+
+func TestFormatters_Collapsed(t *testing.T) {
+	collapsed := CollapsedTreeResponse{
+		Query:    TreeQuery{Command: "tree", Root: "main"},
+		Collapse: CollapsePackage,
+		Nodes: map[string]GroupNode{
+			"app/cmd": {ID: "app/cmd", Name: "cmd", Functions: []string{"app/cmd.main", "app/cmd.run"}},
+			"app/db":  {ID: "app/db", Name: "db", Functions: []string{"app/db.Query"}},
+		},
+		Edges: []GroupEdge{{From: "app/cmd", To: "app/db", Weight: 3}},
+	}
+
+	for _, tt := range []struct {
+		f    Formatter
+		want []string
+	}{
+		{&DotFormatter{}, []string{
+			`"app/cmd" [label="cmd\n2 functions"];`,
+			`"app/cmd" -> "app/db" [label="3"];`,
+		}},
+		{&MarkdownFormatter{}, []string{
+			"| cmd | app/cmd.main, app/cmd.run |",
+			"- `cmd` → `db` (3 calls)",
+		}},
+		{&GraphMLFormatter{}, []string{
+			`<data key="n_functions">app/cmd.main app/cmd.run</data>`,
+			`<data key="e_weight">3</data>`,
+		}},
+	} {
+		result, err := tt.f.Format(collapsed)
+		if err != nil {
+			t.Fatalf("%s: Format() error = %v", tt.f.Name(), err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(result), want) {
+				t.Errorf("%s: missing %s, got: %s", tt.f.Name(), want, result)
+			}
+		}
+	}
+}
+
 func TestMarkdownFormatter(t *testing.T) {
 	f := &MarkdownFormatter{}
 
@@ -127,6 +288,87 @@ func TestMarkdownFormatter(t *testing.T) {
 	}
 }
 
+func TestFormatters_GroupedChains(t *testing.T) {
+	resp := CallersResponse{
+		Query:  QueryInfo{Command: "callers", Target: "load"},
+		Target: TargetInfo{Symbol: "load"},
+		Groups: GroupByDepth([]Result{
+			{Symbol: "run", File: "/src/run.go", Line: 8, Depth: 1, Chain: []string{"run", "load"}},
+			{Symbol: "main", File: "/src/main.go", Line: 3, Depth: 2, Chain: []string{"main", "run", "load"}},
+		}),
+	}
+
+	dot, err := (&DotFormatter{}).Format(resp)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if got := strings.Count(string(dot), `"run" -> "load"`); got != 1 {
+		t.Errorf("run -> load drawn %d times, want once: %s", got, dot)
+	}
+	if !strings.Contains(string(dot), `"main" -> "run"`) {
+		t.Errorf("Missing chain edge, got: %s", dot)
+	}
+
+	md, err := (&MarkdownFormatter{}).Format(resp)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	for _, want := range []string{"## Depth 1", "## Depth 2", "| main | /src/main.go | 3 |"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("Missing %q, got: %s", want, md)
+		}
+	}
+}
+
+func TestDotFormatter_Paths(t *testing.T) {
+	resp := PathResponse{
+		Paths: []CallPath{
+			{Length: 2, Symbols: []string{"main", "admin", "query"}, Hops: []PathHop{{From: "main", To: "admin"}, {From: "admin", To: "query"}}},
+			{Length: 2, Symbols: []string{"main", "serve", "query"}, Hops: []PathHop{{From: "main", To: "serve"}, {From: "serve", To: "query"}}},
+		},
+	}
+	result, err := (&DotFormatter{}).Format(resp)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	output := string(result)
+	for _, want := range []string{
+		`"main" [style=filled, fillcolor=lightgrey];`,
+		`"query" [style=filled, fillcolor=lightgrey];`,
+		`"main" -> "admin" [color=red, penwidth=2];`,
+		`"main" -> "serve";`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Missing %s, got: %s", want, output)
+		}
+	}
+}
+
+func TestMarkdownFormatter_Tree(t *testing.T) {
+	f := &MarkdownFormatter{}
+
+	tree := TreeResponse{
+		Query: TreeQuery{Command: "tree", Root: "main"},
+		Nodes: map[string]TreeNode{
+			"example.com/cmd.main": {ID: "example.com/cmd.main", Name: "cmd.main", File: "/src/cmd/main.go", Line: 3},
+			"example.com/a.New":    {ID: "example.com/a.New", Name: "a.New", File: "/src/a/new.go", Line: 7},
+		},
+		Edges: []TreeEdge{{From: "example.com/cmd.main", To: "example.com/a.New", File: "/src/cmd/main.go", Line: 4}},
+	}
+	result, err := f.Format(tree)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	output := string(result)
+	for _, want := range []string{"| a.New | new.go | 7 |", "- `cmd.main` → `a.New` (main.go:4)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Missing %q, got: %s", want, output)
+		}
+	}
+}
+
 func TestRegistry(t *testing.T) {
 	r := NewRegistry()
 
@@ -151,3 +393,39 @@ func TestRegistry(t *testing.T) {
 		t.Error("Expected error for unknown formatter")
 	}
 }
+
+func TestFormatters_MultiTarget(t *testing.T) {
+	resp := MultiCallersResponse{
+		Query: QueryInfo{Command: "callers", Target: "Server.*"},
+		Targets: []CallersResponse{
+			{
+				Target:  TargetInfo{Symbol: "Server.Start"},
+				Results: []Result{{Symbol: "main", File: "/src/main.go", Line: 3}},
+			},
+			{
+				Target:  TargetInfo{Symbol: "Server.Stop"},
+				Results: []Result{{Symbol: "shutdown", File: "/src/main.go", Line: 9}},
+			},
+		},
+	}
+
+	dot, err := (&DotFormatter{}).Format(resp)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	for _, want := range []string{`"main" -> "Server.Start"`, `"shutdown" -> "Server.Stop"`} {
+		if !strings.Contains(string(dot), want) {
+			t.Errorf("Missing %s, got: %s", want, dot)
+		}
+	}
+
+	md, err := (&MarkdownFormatter{}).Format(resp)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	for _, want := range []string{"## Server.Start", "## Server.Stop", "| shutdown | /src/main.go | 9 |"} {
+		if !strings.Contains(string(md), want) {
+			t.Errorf("Missing %q, got: %s", want, md)
+		}
+	}
+}
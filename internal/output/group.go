@@ -0,0 +1,22 @@
+package output
+
+import "slices"
+
+// GroupByDepth groups results by depth, shallowest first, keeping their
+// order within each group.
+func GroupByDepth(results []Result) []ResultGroup {
+	var groups []ResultGroup
+	index := make(map[int]int)
+	for _, r := range results {
+		i, ok := index[r.Depth]
+		if !ok {
+			i = len(groups)
+			index[r.Depth] = i
+			groups = append(groups, ResultGroup{Depth: r.Depth})
+		}
+		groups[i].Results = append(groups[i].Results, r)
+		groups[i].Count++
+	}
+	slices.SortStableFunc(groups, func(a, b ResultGroup) int { return a.Depth - b.Depth })
+	return groups
+}
@@ -0,0 +1,36 @@
+package output
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGroupByDepth(t *testing.T) {
+	results := []Result{
+		{Symbol: "b", Depth: 2},
+		{Symbol: "a", Depth: 1},
+		{Symbol: "c", Depth: 2},
+		{Symbol: "d", Depth: 1},
+	}
+
+	groups := GroupByDepth(results)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	for i, want := range []struct {
+		depth   int
+		symbols []string
+	}{
+		{1, []string{"a", "d"}},
+		{2, []string{"b", "c"}},
+	} {
+		g := groups[i]
+		var symbols []string
+		for _, r := range g.Results {
+			symbols = append(symbols, r.Symbol)
+		}
+		if g.Depth != want.depth || g.Count != len(want.symbols) || !slices.Equal(symbols, want.symbols) {
+			t.Errorf("group %d = depth %d, count %d, %v; want depth %d, %v", i, g.Depth, g.Count, symbols, want.depth, want.symbols)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package output
+
+import "slices"
+
+// Add folds the summary of one target into s.
+func (s *MultiSummary) Add(sum Summary) {
+	s.Targets++
+	s.Count += sum.Count
+	s.InTests += sum.InTests
+	s.Truncated = s.Truncated || sum.Truncated
+	if s.StopReason == "" {
+		s.StopReason = sum.StopReason
+	}
+	for _, p := range sum.Packages {
+		if !slices.Contains(s.Packages, p) {
+			s.Packages = append(s.Packages, p)
+		}
+	}
+}
+
+// Add folds the impact summary of one target into s.
+func (s *MultiImpactSummary) Add(sum ImpactSummary) {
+	s.Targets++
+	s.TotalLocations += sum.TotalLocations
+	s.Callers += sum.Callers
+	s.References += sum.References
+	s.Implementations += sum.Implementations
+	s.DependentPackages += sum.DependentPackages
+	s.InTests += sum.InTests
+	s.Truncated = s.Truncated || sum.Truncated
+	if s.StopReason == "" {
+		s.StopReason = sum.StopReason
+	}
+}
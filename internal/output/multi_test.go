@@ -0,0 +1,34 @@
+package output
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestMultiSummary_Add(t *testing.T) {
+	var s MultiSummary
+	s.Add(Summary{Count: 2, Packages: []string{"a.go", "b.go"}, InTests: 1})
+	s.Add(Summary{Count: 3, Packages: []string{"b.go", "c.go"}, Truncated: true, StopReason: "max_nodes"})
+
+	if s.Targets != 2 || s.Count != 5 || s.InTests != 1 {
+		t.Errorf("targets, count, in tests = %d, %d, %d, want 2, 5, 1", s.Targets, s.Count, s.InTests)
+	}
+	if !s.Truncated || s.StopReason != "max_nodes" {
+		t.Errorf("truncated, stop reason = %v, %q, want true, max_nodes", s.Truncated, s.StopReason)
+	}
+	if !slices.Equal(s.Packages, []string{"a.go", "b.go", "c.go"}) {
+		t.Errorf("packages = %v, want each file once", s.Packages)
+	}
+}
+
+func TestMultiImpactSummary_Add(t *testing.T) {
+	var s MultiImpactSummary
+	s.Add(ImpactSummary{TotalLocations: 3, Callers: 1, References: 2})
+	s.Add(ImpactSummary{TotalLocations: 4, References: 1, Implementations: 3, InTests: 2, Truncated: true, StopReason: "max_edges"})
+
+	want := ImpactSummary{TotalLocations: 7, Callers: 1, References: 3, Implementations: 3, InTests: 2, Truncated: true, StopReason: "max_edges"}
+	if s.Targets != 2 || !reflect.DeepEqual(s.ImpactSummary, want) {
+		t.Errorf("got %d targets, %+v, want 2, %+v", s.Targets, s.ImpactSummary, want)
+	}
+}
@@ -6,10 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 )
 
 // SnippetExtractor extracts code snippets from source files.
+// It is safe for concurrent use.
 type SnippetExtractor struct {
+	mu    sync.RWMutex
 	cache map[string][]string // file path -> lines
 }
 
@@ -101,7 +104,10 @@ func (e *SnippetExtractor) ExtractCallExpr(filePath string, line, startCol, endC
 // getLines returns the lines of a file, using cache.
 func (e *SnippetExtractor) getLines(filePath string) ([]string, error) {
 	// Check cache
-	if lines, ok := e.cache[filePath]; ok {
+	e.mu.RLock()
+	lines, ok := e.cache[filePath]
+	e.mu.RUnlock()
+	if ok {
 		return lines, nil
 	}
 
@@ -112,7 +118,6 @@ func (e *SnippetExtractor) getLines(filePath string) ([]string, error) {
 	}
 	defer file.Close()
 
-	var lines []string
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		lines = append(lines, scanner.Text())
@@ -122,13 +127,17 @@ func (e *SnippetExtractor) getLines(filePath string) ([]string, error) {
 	}
 
 	// Cache the result
+	e.mu.Lock()
 	e.cache[filePath] = lines
+	e.mu.Unlock()
 
 	return lines, nil
 }
 
 // ClearCache clears the file cache.
 func (e *SnippetExtractor) ClearCache() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.cache = make(map[string][]string)
 }
 
@@ -1,8 +1,10 @@
 package output
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 )
 
@@ -217,3 +219,28 @@ func TestSnippetExtractor_Cache(t *testing.T) {
 		t.Errorf("after cache clear: got %q, want %q", got, "modified")
 	}
 }
+
+func TestSnippetExtractor_Concurrent(t *testing.T) {
+	tmpDir := t.TempDir()
+	var files []string
+	for i := range 4 {
+		f := filepath.Join(tmpDir, fmt.Sprintf("f%d.txt", i))
+		if err := os.WriteFile(f, []byte("a\nb\nc\n"), 0644); err != nil {
+			t.Fatalf("write test file: %v", err)
+		}
+		files = append(files, f)
+	}
+
+	extractor := NewSnippetExtractor()
+	var wg sync.WaitGroup
+	for i := range 32 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := extractor.Extract(files[i%len(files)], 2, 1); err != nil {
+				t.Errorf("extract: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,6 +1,8 @@
 // Package output provides types and utilities for Wildcat's JSON output.
 package output
 
+import "encoding/json"
+
 // QueryInfo describes the query that was executed.
 type QueryInfo struct {
 	Command  string `json:"command"`
@@ -29,6 +31,27 @@ type Result struct {
 	CallExpr string   `json:"call_expr,omitempty"`
 	Args     []string `json:"args,omitempty"`
 	InTest   bool     `json:"in_test"`
+	Dynamic  bool     `json:"dynamic,omitempty"` // Reached through interface dispatch
+
+	// Set on transitive traversal results: distance from the target and
+	// the symbols connecting them, in call order
+	Depth int      `json:"depth,omitempty"`
+	Chain []string `json:"chain,omitempty"`
+
+	// Set on traversal results whose own calls were not explored
+	Truncated bool   `json:"truncated,omitempty"` // A traversal budget ran out first
+	Error     string `json:"error,omitempty"`     // Fetching them failed
+}
+
+// Meta describes how the language server session behind a response was obtained.
+type Meta struct {
+	IndexMS  int64  `json:"index_ms"`            // Time the server spent indexing before answering
+	ReadyVia string `json:"ready_via,omitempty"` // How readiness was detected
+	Retries  int    `json:"retries"`             // Requests retried after transient server errors
+
+	Unsupported []string `json:"unsupported,omitempty"` // Expected capabilities the server did not advertise
+	Fallbacks   []string `json:"fallbacks,omitempty"`   // Substitute strategies used for missing capabilities
+	Emulated    bool     `json:"emulated,omitempty"`    // Call hierarchy was derived from references, not the server
 }
 
 // Summary provides aggregate information about the results.
@@ -37,22 +60,55 @@ type Summary struct {
 	Packages  []string `json:"packages,omitempty"`
 	InTests   int      `json:"in_tests"`
 	Truncated bool     `json:"truncated"`
+
+	StopReason string `json:"stop_reason,omitempty"` // Traversal budget that ran out
 }
 
-// CallersResponse is the output for the callers command.
+// CallersResponse is the output for the callers command. With --group-by depth
+// the results are reported in Groups and Results is empty.
 type CallersResponse struct {
-	Query   QueryInfo  `json:"query"`
-	Target  TargetInfo `json:"target"`
-	Results []Result   `json:"results"`
-	Summary Summary    `json:"summary"`
+	Query   QueryInfo     `json:"query"`
+	Target  TargetInfo    `json:"target"`
+	Results []Result      `json:"results"`
+	Groups  []ResultGroup `json:"groups,omitempty"`
+	Summary Summary       `json:"summary"`
+	Meta    *Meta         `json:"meta,omitempty"`
 }
 
-// CalleesResponse is the output for the callees command.
+// CalleesResponse is the output for the callees command. With --group-by depth
+// the results are reported in Groups and Results is empty.
 type CalleesResponse struct {
-	Query   QueryInfo  `json:"query"`
-	Target  TargetInfo `json:"target"`
-	Results []Result   `json:"results"`
-	Summary Summary    `json:"summary"`
+	Query   QueryInfo     `json:"query"`
+	Target  TargetInfo    `json:"target"`
+	Results []Result      `json:"results"`
+	Groups  []ResultGroup `json:"groups,omitempty"`
+	Summary Summary       `json:"summary"`
+	Meta    *Meta         `json:"meta,omitempty"`
+}
+
+// MultiSummary combines the summaries of the targets of a pattern query.
+// Packages lists each file once.
+type MultiSummary struct {
+	Targets int `json:"targets"`
+	Summary
+	SearchCapped bool     `json:"search_capped,omitempty"` // The server's symbol search hit its limit, so targets may be missing
+	Errors       []string `json:"errors,omitempty"`        // Targets that could not be queried
+}
+
+// MultiCallersResponse is the output for the callers command when a
+// pattern query matches several symbols: one section per target.
+type MultiCallersResponse struct {
+	Query   QueryInfo         `json:"query"`
+	Targets []CallersResponse `json:"targets"`
+	Summary MultiSummary      `json:"summary"`
+	Meta    *Meta             `json:"meta,omitempty"`
+}
+
+// ResultGroup holds the results found at one traversal depth.
+type ResultGroup struct {
+	Depth   int      `json:"depth"`
+	Count   int      `json:"count"`
+	Results []Result `json:"results"`
 }
 
 // RefsResponse is the output for the refs command.
@@ -61,37 +117,66 @@ type RefsResponse struct {
 	Target  TargetInfo `json:"target"`
 	Results []Result   `json:"results"`
 	Summary Summary    `json:"summary"`
+	Meta    *Meta      `json:"meta,omitempty"`
+}
+
+// MultiRefsResponse is the output for the refs command when a pattern
+// query matches several symbols: one section per target.
+type MultiRefsResponse struct {
+	Query   QueryInfo      `json:"query"`
+	Targets []RefsResponse `json:"targets"`
+	Summary MultiSummary   `json:"summary"`
+	Meta    *Meta          `json:"meta,omitempty"`
 }
 
 // TreeNode represents a node in the call tree.
 type TreeNode struct {
+	ID        string   `json:"id"`   // Stable identity: package, receiver and name
+	Name      string   `json:"name"` // Shortest unambiguous display name
+	Package   string   `json:"package,omitempty"`
 	File      string   `json:"file"`
 	Line      int      `json:"line"`
+	Depth     int      `json:"depth"` // Calls from the root: negative for callers, positive for callees
 	Signature string   `json:"signature,omitempty"`
 	Calls     []string `json:"calls,omitempty"`
 	CalledBy  []string `json:"called_by,omitempty"`
+
+	Truncated bool   `json:"truncated,omitempty"` // Not fully explored: a traversal budget ran out
+	Error     string `json:"error,omitempty"`     // Exploring it failed
 }
 
-// TreeEdge represents an edge in the call tree.
+// TreeEdge represents an edge in the call tree. From and To are node IDs.
 type TreeEdge struct {
-	From string `json:"from"`
-	To   string `json:"to"`
-	File string `json:"file"`
-	Line int    `json:"line"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Dynamic bool   `json:"dynamic,omitempty"` // Call through an interface method
+
+	BackEdge bool `json:"back_edge,omitempty"` // Call back to a node on the path from the root
+}
+
+// Cycle is a set of functions that can reach each other through calls:
+// a strongly connected component of the explored call graph.
+type Cycle struct {
+	Symbols []string   `json:"symbols"` // Node IDs, sorted
+	Calls   []TreeEdge `json:"calls"`   // Call sites between them
 }
 
 // TreeSummary provides aggregate information about the tree.
 type TreeSummary struct {
-	NodeCount       int  `json:"node_count"`
-	EdgeCount       int  `json:"edge_count"`
-	MaxDepthReached int  `json:"max_depth_reached"`
-	Truncated       bool `json:"truncated"`
+	NodeCount       int    `json:"node_count"`
+	EdgeCount       int    `json:"edge_count"`
+	MaxDepthReached int    `json:"max_depth_reached"`
+	Truncated       bool   `json:"truncated"`
+	StopReason      string `json:"stop_reason,omitempty"` // Traversal budget that ran out
 }
 
 // TreeQuery describes the tree query parameters.
 type TreeQuery struct {
 	Command   string `json:"command"`
 	Root      string `json:"root"`
+	RootID    string `json:"root_id,omitempty"`
 	Depth     int    `json:"depth"`
 	Direction string `json:"direction"`
 }
@@ -101,7 +186,183 @@ type TreeResponse struct {
 	Query   TreeQuery           `json:"query"`
 	Nodes   map[string]TreeNode `json:"nodes"`
 	Edges   []TreeEdge          `json:"edges"`
+	Cycles  []Cycle             `json:"cycles,omitempty"`
 	Summary TreeSummary         `json:"summary"`
+	Meta    *Meta               `json:"meta,omitempty"`
+}
+
+// CyclesSummary provides aggregate information about a cycle search.
+type CyclesSummary struct {
+	Count      int    `json:"count"`
+	NodeCount  int    `json:"node_count"` // Nodes explored
+	Truncated  bool   `json:"truncated"`
+	StopReason string `json:"stop_reason,omitempty"` // Traversal budget that ran out
+}
+
+// CyclesResponse is the output for the cycles command.
+type CyclesResponse struct {
+	Query   TreeQuery     `json:"query"`
+	Cycles  []Cycle       `json:"cycles"`
+	Summary CyclesSummary `json:"summary"`
+	Meta    *Meta         `json:"meta,omitempty"`
+}
+
+// PathHop is one call along a path. File and Line locate the call site
+// in the caller.
+type PathHop struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	File string `json:"file,omitempty"`
+	Line int    `json:"line,omitempty"`
+}
+
+// CallPath is a chain of calls from one symbol to another.
+type CallPath struct {
+	Length  int       `json:"length"`  // Number of calls
+	Symbols []string  `json:"symbols"` // Symbols passed through, in call order
+	Hops    []PathHop `json:"hops"`
+}
+
+// PathSummary provides aggregate information about a path search.
+type PathSummary struct {
+	Count         int      `json:"count"`
+	Shortest      int      `json:"shortest,omitempty"`
+	NodesExplored int      `json:"nodes_explored"`
+	Truncated     bool     `json:"truncated"`
+	StopReason    string   `json:"stop_reason,omitempty"` // Traversal budget that ran out
+	Errors        []string `json:"errors,omitempty"`      // Symbols whose calls could not be fetched
+}
+
+// PathResponse is the output for the path command.
+type PathResponse struct {
+	Query   QueryInfo   `json:"query"`
+	From    TargetInfo  `json:"from"`
+	To      TargetInfo  `json:"to"`
+	Paths   []CallPath  `json:"paths"`
+	Summary PathSummary `json:"summary"`
+	Meta    *Meta       `json:"meta,omitempty"`
+}
+
+// GraphNode is a function or method in the module call graph.
+type GraphNode struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Kind    string `json:"kind"`    // "function" or "method"
+	Package string `json:"package"` // Package path, or directory relative to the workspace
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+
+	// Classification
+	Test     bool `json:"test"`     // Declared in a test file
+	Stdlib   bool `json:"stdlib"`   // Declared in the standard library
+	External bool `json:"external"` // Declared outside the workspace, but not in the standard library
+
+	Truncated bool   `json:"truncated,omitempty"` // Its calls were not fetched: a traversal budget ran out
+	Error     string `json:"error,omitempty"`     // Fetching its calls failed
+}
+
+// GraphSummary provides aggregate information about the module call graph.
+type GraphSummary struct {
+	Files      int      `json:"files"`     // Source files scanned
+	Functions  int      `json:"functions"` // Functions and methods declared in them
+	NodeCount  int      `json:"node_count"`
+	EdgeCount  int      `json:"edge_count"`
+	Truncated  bool     `json:"truncated"`
+	StopReason string   `json:"stop_reason,omitempty"` // Traversal budget that ran out
+	Errors     []string `json:"errors,omitempty"`      // Files and symbols that could not be read
+}
+
+// GraphResponse is the output for the graph command. With --csv the nodes
+// and edges are written to the files listed in Files instead.
+type GraphResponse struct {
+	Query   QueryInfo            `json:"query"`
+	Nodes   map[string]GraphNode `json:"nodes,omitempty"`
+	Edges   []TreeEdge           `json:"edges,omitempty"`
+	Files   []string             `json:"files,omitempty"`
+	Summary GraphSummary         `json:"summary"`
+	Meta    *Meta                `json:"meta,omitempty"`
+}
+
+// UnusedSymbol is a function or method that no entrypoint reaches.
+// File, Line and LineEnd span its declaration.
+type UnusedSymbol struct {
+	Symbol  string `json:"symbol"` // Node ID
+	Name    string `json:"name"`
+	Kind    string `json:"kind"` // "function" or "method"
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	LineEnd int    `json:"line_end"`
+	InTest  bool   `json:"in_test"`
+	Reason  string `json:"reason"` // no_references, self_only, test_only or unreachable
+
+	// Where it is referenced from: function IDs, or file:line outside any function
+	ReferencedFrom []string `json:"referenced_from,omitempty"`
+}
+
+// UnusedSummary provides aggregate information about an unused search.
+type UnusedSummary struct {
+	Count       int            `json:"count"`
+	ByReason    map[string]int `json:"by_reason,omitempty"`
+	Functions   int            `json:"functions"`   // Functions and methods declared
+	Entrypoints int            `json:"entrypoints"` // Of those, taken as entrypoints
+	Reachable   int            `json:"reachable"`
+	Library     bool           `json:"library,omitempty"` // No main function: exported API taken as entrypoints
+	Truncated   bool           `json:"truncated"`
+	StopReason  string         `json:"stop_reason,omitempty"` // Traversal budget that ran out
+	Errors      []string       `json:"errors,omitempty"`      // Files and symbols that could not be read
+}
+
+// UnusedResponse is the output for the unused command.
+type UnusedResponse struct {
+	Query   QueryInfo      `json:"query"`
+	Results []UnusedSymbol `json:"results"`
+	Summary UnusedSummary  `json:"summary"`
+	Meta    *Meta          `json:"meta,omitempty"`
+}
+
+// Ways to collapse a tree or graph.
+const (
+	CollapsePackage = "package"
+	CollapseFile    = "file"
+)
+
+// GroupNode is a package or file standing in for the functions it
+// contains in a collapsed tree or graph.
+type GroupNode struct {
+	ID        string   `json:"id"`                  // Package path or file
+	Name      string   `json:"name"`                // Short display name
+	Functions []string `json:"functions"`           // IDs of the functions it contains, sorted
+	Truncated bool     `json:"truncated,omitempty"` // Some of them were not fully explored
+	Depth     int      `json:"depth,omitempty"`     // Depth of its member nearest the root, in trees
+}
+
+// GroupEdge aggregates the calls from the functions of one group to
+// those of another, or of the same group.
+type GroupEdge struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Weight int    `json:"weight"` // Number of calls
+}
+
+// CollapsedTreeResponse is the output for the tree command with --collapse.
+// Query.RootID names the group containing the root.
+type CollapsedTreeResponse struct {
+	Query    TreeQuery            `json:"query"`
+	Collapse string               `json:"collapse"`
+	Nodes    map[string]GroupNode `json:"nodes"`
+	Edges    []GroupEdge          `json:"edges"`
+	Summary  TreeSummary          `json:"summary"`
+	Meta     *Meta                `json:"meta,omitempty"`
+}
+
+// CollapsedGraphResponse is the output for the graph command with --collapse.
+type CollapsedGraphResponse struct {
+	Query    QueryInfo            `json:"query"`
+	Collapse string               `json:"collapse"`
+	Nodes    map[string]GroupNode `json:"nodes"`
+	Edges    []GroupEdge          `json:"edges"`
+	Summary  GraphSummary         `json:"summary"`
+	Meta     *Meta                `json:"meta,omitempty"`
 }
 
 // ImpactCategory represents a category of impact.
@@ -110,6 +371,10 @@ type ImpactCategory struct {
 	File   string `json:"file"`
 	Line   int    `json:"line"`
 	Reason string `json:"reason,omitempty"`
+
+	// Set on callers whose own callers were not explored
+	Truncated bool   `json:"truncated,omitempty"` // A traversal budget ran out first
+	Error     string `json:"error,omitempty"`     // Fetching them failed
 }
 
 // ImpactDependent represents a dependent package.
@@ -135,6 +400,10 @@ type ImpactSummary struct {
 	Implementations   int `json:"implementations"`
 	DependentPackages int `json:"dependent_packages"`
 	InTests           int `json:"in_tests"`
+
+	Truncated  bool     `json:"truncated"`             // Callers are incomplete
+	StopReason string   `json:"stop_reason,omitempty"` // Traversal budget that ran out
+	Errors     []string `json:"errors,omitempty"`      // Parts of the analysis that failed
 }
 
 // ImpactResponse is the output for the impact command.
@@ -143,6 +412,24 @@ type ImpactResponse struct {
 	Target  TargetInfo    `json:"target"`
 	Impact  Impact        `json:"impact"`
 	Summary ImpactSummary `json:"summary"`
+	Meta    *Meta         `json:"meta,omitempty"`
+}
+
+// MultiImpactSummary combines the impact summaries of the targets of a
+// pattern query.
+type MultiImpactSummary struct {
+	Targets int `json:"targets"`
+	ImpactSummary
+	SearchCapped bool `json:"search_capped,omitempty"` // The server's symbol search hit its limit, so targets may be missing
+}
+
+// MultiImpactResponse is the output for the impact command when a pattern
+// query matches several symbols: one section per target.
+type MultiImpactResponse struct {
+	Query   QueryInfo          `json:"query"`
+	Targets []ImpactResponse   `json:"targets"`
+	Summary MultiImpactSummary `json:"summary"`
+	Meta    *Meta              `json:"meta,omitempty"`
 }
 
 // ImplementsResponse is the output for the implements command.
@@ -151,6 +438,7 @@ type ImplementsResponse struct {
 	Interface       TargetInfo `json:"interface"`
 	Implementations []Result   `json:"implementations"`
 	Summary         Summary    `json:"summary"`
+	Meta            *Meta      `json:"meta,omitempty"`
 }
 
 // SatisfiesResponse is the output for the satisfies command.
@@ -159,6 +447,7 @@ type SatisfiesResponse struct {
 	Type       TargetInfo        `json:"type"`
 	Interfaces []InterfaceResult `json:"interfaces"`
 	Summary    Summary           `json:"summary"`
+	Meta       *Meta             `json:"meta,omitempty"`
 }
 
 // InterfaceResult represents an interface that a type satisfies.
@@ -185,6 +474,33 @@ type DepResult struct {
 	ImportLine int    `json:"import_line"`
 }
 
+// DaemonStatusResponse is the output for the daemon status and stop commands.
+type DaemonStatusResponse struct {
+	Running            bool   `json:"running"`
+	PID                int    `json:"pid,omitempty"`
+	WorkDir            string `json:"work_dir"`
+	Language           string `json:"language"`
+	Server             string `json:"server,omitempty"`
+	Socket             string `json:"socket"`
+	Ready              bool   `json:"ready"`
+	IndexMS            int64  `json:"index_ms,omitempty"`
+	StartedAt          string `json:"started_at,omitempty"`
+	UptimeSeconds      int    `json:"uptime_seconds,omitempty"`
+	IdleSeconds        int    `json:"idle_seconds,omitempty"`
+	IdleTimeoutSeconds int    `json:"idle_timeout_seconds,omitempty"`
+	Connections        int    `json:"connections"`
+	Requests           int64  `json:"requests"`
+}
+
+// BatchResponse is one line of the batch command's output: the response
+// to one request, or the error it failed with.
+type BatchResponse struct {
+	ID       any             `json:"id"` // Echoed from the request, else its line number
+	Command  string          `json:"command,omitempty"`
+	Response json.RawMessage `json:"response,omitempty"`
+	Error    *ErrorDetail    `json:"error,omitempty"`
+}
+
 // ErrorResponse is the output when an error occurs.
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
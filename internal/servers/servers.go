@@ -1,8 +1,11 @@
 package servers
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/jasonmoo/wildcat/internal/lsp"
@@ -16,72 +19,96 @@ type ServerSpec struct {
 	Args         []string       // startup arguments
 	Extensions   []string       // file extensions (without dot)
 	InitOptions  map[string]any // LSP initializationOptions
-	Capabilities []string       // required LSP capabilities
+	Capabilities []string       // LSP methods the server is expected to support
+	RootMarkers  []string       // files marking the workspace root (e.g., "go.mod")
 }
 
 // registry holds all known language server configurations.
 var registry = []ServerSpec{
 	{
-		Language:   "go",
-		Name:       "gopls",
-		Command:    "gopls",
-		Args:       []string{"serve"},
-		Extensions: []string{"go"},
+		Language:    "go",
+		Name:        "gopls",
+		Command:     "gopls",
+		Args:        []string{"serve"},
+		Extensions:  []string{"go"},
+		RootMarkers: []string{"go.work", "go.mod"},
 		Capabilities: []string{
+			"workspace/symbol",
+			"textDocument/documentSymbol",
 			"textDocument/references",
 			"textDocument/definition",
+			"textDocument/implementation",
 			"callHierarchy/incomingCalls",
 			"callHierarchy/outgoingCalls",
+			"typeHierarchy/supertypes",
+			"typeHierarchy/subtypes",
 		},
 	},
 	{
-		Language:   "python",
-		Name:       "pyright",
-		Command:    "pyright-langserver",
-		Args:       []string{"--stdio"},
-		Extensions: []string{"py", "pyi"},
+		Language:    "python",
+		Name:        "pyright",
+		Command:     "pyright-langserver",
+		Args:        []string{"--stdio"},
+		Extensions:  []string{"py", "pyi"},
+		RootMarkers: []string{"pyproject.toml", "setup.py", "setup.cfg"},
 		Capabilities: []string{
+			"workspace/symbol",
+			"textDocument/documentSymbol",
 			"textDocument/references",
 			"textDocument/definition",
 		},
 	},
 	{
-		Language:   "typescript",
-		Name:       "typescript-language-server",
-		Command:    "typescript-language-server",
-		Args:       []string{"--stdio"},
-		Extensions: []string{"ts", "tsx", "js", "jsx"},
+		Language:    "typescript",
+		Name:        "typescript-language-server",
+		Command:     "typescript-language-server",
+		Args:        []string{"--stdio"},
+		Extensions:  []string{"ts", "tsx", "js", "jsx"},
+		RootMarkers: []string{"tsconfig.json", "package.json"},
 		Capabilities: []string{
+			"workspace/symbol",
+			"textDocument/documentSymbol",
 			"textDocument/references",
 			"textDocument/definition",
+			"textDocument/implementation",
 			"callHierarchy/incomingCalls",
 			"callHierarchy/outgoingCalls",
 		},
 	},
 	{
-		Language:   "rust",
-		Name:       "rust-analyzer",
-		Command:    "rust-analyzer",
-		Args:       []string{},
-		Extensions: []string{"rs"},
+		Language:    "rust",
+		Name:        "rust-analyzer",
+		Command:     "rust-analyzer",
+		Args:        []string{},
+		Extensions:  []string{"rs"},
+		RootMarkers: []string{"Cargo.toml"},
 		Capabilities: []string{
+			"workspace/symbol",
+			"textDocument/documentSymbol",
 			"textDocument/references",
 			"textDocument/definition",
+			"textDocument/implementation",
 			"callHierarchy/incomingCalls",
 			"callHierarchy/outgoingCalls",
 		},
 	},
 	{
-		Language:   "c",
-		Name:       "clangd",
-		Command:    "clangd",
-		Args:       []string{},
-		Extensions: []string{"c", "h", "cpp", "hpp", "cc", "cxx"},
+		Language:    "c",
+		Name:        "clangd",
+		Command:     "clangd",
+		Args:        []string{},
+		Extensions:  []string{"c", "h", "cpp", "hpp", "cc", "cxx"},
+		RootMarkers: []string{"compile_commands.json", "CMakeLists.txt"},
 		Capabilities: []string{
+			"workspace/symbol",
+			"textDocument/documentSymbol",
 			"textDocument/references",
 			"textDocument/definition",
+			"textDocument/implementation",
 			"callHierarchy/incomingCalls",
 			"callHierarchy/outgoingCalls",
+			"typeHierarchy/supertypes",
+			"typeHierarchy/subtypes",
 		},
 	},
 }
@@ -92,6 +119,42 @@ func (s *ServerSpec) Available() bool {
 	return err == nil
 }
 
+// Missing returns the capabilities the spec expects that the server did
+// not advertise during initialize.
+func (s *ServerSpec) Missing(caps lsp.ServerCapabilities) []string {
+	var missing []string
+	for _, method := range s.Capabilities {
+		if !caps.Supports(method) {
+			missing = append(missing, method)
+		}
+	}
+	return missing
+}
+
+// HasCapability reports whether the spec expects the server to support method.
+func (s *ServerSpec) HasCapability(method string) bool {
+	return slices.Contains(s.Capabilities, method)
+}
+
+// Root returns the workspace root for dir: the nearest directory at or
+// above it holding one of the spec's root markers, else the nearest
+// holding .git, else dir itself.
+func (s *ServerSpec) Root(dir string) string {
+	for _, markers := range [][]string{s.RootMarkers, {".git"}} {
+		for d := dir; ; d = filepath.Dir(d) {
+			for _, m := range markers {
+				if _, err := os.Stat(filepath.Join(d, m)); err == nil {
+					return d
+				}
+			}
+			if filepath.Dir(d) == d {
+				break
+			}
+		}
+	}
+	return dir
+}
+
 // ToConfig converts a ServerSpec to an LSP ServerConfig.
 func (s *ServerSpec) ToConfig(workDir string) lsp.ServerConfig {
 	return lsp.ServerConfig{
@@ -137,6 +200,18 @@ func List() []ServerSpec {
 	return result
 }
 
+// Supporting describes the registered servers expected to support
+// method, as "name (language)".
+func Supporting(method string) []string {
+	var result []string
+	for i := range registry {
+		if registry[i].HasCapability(method) {
+			result = append(result, fmt.Sprintf("%s (%s)", registry[i].Name, registry[i].Language))
+		}
+	}
+	return result
+}
+
 // Available returns all servers that are currently available in PATH.
 func Available() []ServerSpec {
 	var result []ServerSpec
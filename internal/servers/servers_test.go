@@ -1,7 +1,12 @@
 package servers
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
 )
 
 func TestGet(t *testing.T) {
@@ -119,6 +124,33 @@ func TestServerSpec_ToConfig(t *testing.T) {
 	}
 }
 
+func TestServerSpec_Root(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{".git", "mod/internal/pkg", "plain/sub"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "mod/go.mod"), []byte("module m\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec, _ := Get("go")
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: "mod/internal/pkg", want: "mod"},
+		{dir: "mod", want: "mod"},
+		{dir: "plain/sub", want: "."},
+	}
+	for _, tt := range tests {
+		if got := spec.Root(filepath.Join(root, tt.dir)); got != filepath.Join(root, tt.want) {
+			t.Errorf("Root(%s) = %q, want %q", tt.dir, got, filepath.Join(root, tt.want))
+		}
+	}
+}
+
 func TestServerSpec_Available(t *testing.T) {
 	spec, _ := Get("go")
 	// gopls should be available in development environment
@@ -126,3 +158,37 @@ func TestServerSpec_Available(t *testing.T) {
 		t.Skip("gopls not in PATH")
 	}
 }
+
+func TestServerSpec_Missing(t *testing.T) {
+	spec, _ := Get("go")
+
+	full := lsp.ServerCapabilities{
+		WorkspaceSymbolProvider: true,
+		DocumentSymbolProvider:  true,
+		ReferencesProvider:      true,
+		DefinitionProvider:      true,
+		ImplementationProvider:  true,
+		CallHierarchyProvider:   map[string]any{},
+		TypeHierarchyProvider:   true,
+	}
+	if missing := spec.Missing(full); len(missing) != 0 {
+		t.Errorf("Missing(full) = %v, want none", missing)
+	}
+
+	partial := full
+	partial.TypeHierarchyProvider = false
+	want := []string{"typeHierarchy/supertypes", "typeHierarchy/subtypes"}
+	if missing := spec.Missing(partial); !slices.Equal(missing, want) {
+		t.Errorf("Missing(partial) = %v, want %v", missing, want)
+	}
+}
+
+func TestSupporting(t *testing.T) {
+	got := Supporting("callHierarchy/incomingCalls")
+	if !slices.Contains(got, "gopls (go)") {
+		t.Errorf("Supporting(incomingCalls) = %v, want gopls", got)
+	}
+	if slices.Contains(got, "pyright (python)") {
+		t.Errorf("Supporting(incomingCalls) = %v, pyright has no call hierarchy", got)
+	}
+}
@@ -0,0 +1,223 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jasonmoo/wildcat/internal/servers"
+)
+
+// A PackageFinder lists the source files of the package or module pkg,
+// as a query names it, in the workspace at root. It returns no files
+// when it cannot find the package.
+type PackageFinder func(ctx context.Context, root, pkg string) ([]string, error)
+
+// moduleIndex names the file that stands for its directory as a module,
+// for languages whose modules are files.
+var moduleIndex = map[string]string{
+	"python":     "__init__",
+	"typescript": "index",
+	"rust":       "mod",
+}
+
+// FinderFor returns the package finder for the language spec serves, or
+// nil when its packages cannot be located from their names:
+//   - Go           go list, by import path, path suffix or package name
+//   - Python       pkg/module.py or pkg/module/__init__.py
+//   - TypeScript   src/server.ts or src/server/index.ts
+//   - Rust         net.rs or net/mod.rs
+//
+// The Go finder lists a workspace's packages once and reuses the list, so
+// share one finder across the queries of a session.
+func FinderFor(spec *servers.ServerSpec) PackageFinder {
+	if spec == nil {
+		return nil
+	}
+	if spec.Language == "go" {
+		return (&goPackages{}).files
+	}
+	index, ok := moduleIndex[spec.Language]
+	if !ok {
+		return nil
+	}
+	return func(ctx context.Context, root, pkg string) ([]string, error) {
+		return moduleFiles(root, pkg, spec.Extensions, index)
+	}
+}
+
+// goPackage is the part of `go list -json` output that locates a package.
+type goPackage struct {
+	ImportPath  string
+	Name        string
+	Dir         string
+	GoFiles     []string
+	CgoFiles    []string
+	TestGoFiles []string
+	Error       *struct{ Err string }
+}
+
+// goPackages finds Go packages, caching each workspace's package list.
+type goPackages struct {
+	mu    sync.Mutex
+	index map[string][]goPackage // Packages under each root
+}
+
+// goPatterns are the names go list expands to many packages.
+var goPatterns = []string{"all", "std", "cmd", "tool", "work"}
+
+// files finds the package whose import path is pkg, such as a standard
+// library package or a full workspace path. Failing that, it finds the
+// workspace packages whose import path ends with pkg or whose name is pkg.
+func (g *goPackages) files(ctx context.Context, root, pkg string) ([]string, error) {
+	var files []string
+	if !slices.Contains(goPatterns, pkg) && !strings.Contains(pkg, "...") {
+		pkgs, err := goList(ctx, root, pkg)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range pkgs {
+			if p.Error == nil {
+				files = append(files, p.files()...)
+			}
+		}
+		if len(files) > 0 {
+			return files, nil
+		}
+	}
+
+	pkgs, err := g.workspace(ctx, root)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pkgs {
+		if strings.HasSuffix(p.ImportPath, "/"+pkg) || p.Name == pkg {
+			files = append(files, p.files()...)
+		}
+	}
+	return files, nil
+}
+
+// workspace lists the packages under root, once per root.
+func (g *goPackages) workspace(ctx context.Context, root string) ([]goPackage, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if pkgs, ok := g.index[root]; ok {
+		return pkgs, nil
+	}
+	pkgs, err := goList(ctx, root, "./...")
+	if err != nil {
+		return nil, err
+	}
+	if g.index == nil {
+		g.index = make(map[string][]goPackage)
+	}
+	g.index[root] = pkgs
+	return pkgs, nil
+}
+
+// files returns the absolute paths of the package's files, tests included.
+func (p *goPackage) files() []string {
+	var files []string
+	for _, list := range [][]string{p.GoFiles, p.CgoFiles, p.TestGoFiles} {
+		for _, f := range list {
+			files = append(files, filepath.Join(p.Dir, f))
+		}
+	}
+	return files
+}
+
+// goList runs go list on pattern in dir, without resolving imports.
+// Packages that fail to load are still listed with whatever files were
+// found, and their error.
+func goList(ctx context.Context, dir, pattern string) ([]goPackage, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-find",
+		"-json=ImportPath,Name,Dir,GoFiles,CgoFiles,TestGoFiles,Error", pattern)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []goPackage
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for {
+		var p goPackage
+		if err := dec.Decode(&p); err == io.EOF {
+			return pkgs, nil
+		} else if err != nil {
+			return nil, err
+		}
+		pkgs = append(pkgs, p)
+	}
+}
+
+// moduleFiles finds the files under root declaring the module pkg, a
+// slash-separated module path. A module is a file named for its last
+// segment, or the index file of a directory named for it. Leading
+// directories, such as src, need not be given.
+func moduleFiles(root, pkg string, extensions []string, index string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != root && skipDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		ext := filepath.Ext(path)
+		if !slices.Contains(extensions, strings.TrimPrefix(ext, ".")) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, strings.TrimSuffix(path, ext))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if filepath.Base(rel) == index {
+			rel = filepath.ToSlash(filepath.Dir(rel))
+		}
+		if rel == pkg || strings.HasSuffix(rel, "/"+pkg) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// siblingFiles returns the other files in file's directory with its extension.
+func siblingFiles(file string) []string {
+	dir := filepath.Dir(file)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if !e.IsDir() && path != file && filepath.Ext(path) == filepath.Ext(file) {
+			files = append(files, path)
+		}
+	}
+	return files
+}
+
+// skipDir returns true for directories that hold no workspace sources.
+func skipDir(name string) bool {
+	switch name {
+	case "vendor", "node_modules", "testdata", "target", "__pycache__":
+		return true
+	}
+	return strings.HasPrefix(name, ".")
+}
@@ -0,0 +1,139 @@
+package symbols
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/servers"
+)
+
+// writeTree creates each file under root with content.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFinderFor_Modules(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"src/pkg/module.py":           "",
+		"src/pkg/__init__.py":         "",
+		"src/pkg/sub/__init__.py":     "",
+		"src/other/module.py":         "",
+		".venv/pkg/module.py":         "",
+		"web/src/server.ts":           "",
+		"web/src/client/index.ts":     "",
+		"web/node_modules/x/index.ts": "",
+		"src/net/mod.rs":              "",
+		"src/net/tcp.rs":              "",
+	})
+
+	tests := []struct {
+		lang string
+		pkg  string
+		want []string
+	}{
+		{lang: "python", pkg: "pkg/module", want: []string{"src/pkg/module.py"}},
+		{lang: "python", pkg: "pkg", want: []string{"src/pkg/__init__.py"}},
+		{lang: "python", pkg: "pkg/sub", want: []string{"src/pkg/sub/__init__.py"}},
+		{lang: "python", pkg: "module", want: []string{"src/other/module.py", "src/pkg/module.py"}},
+		{lang: "typescript", pkg: "src/server", want: []string{"web/src/server.ts"}},
+		{lang: "typescript", pkg: "client", want: []string{"web/src/client/index.ts"}},
+		{lang: "typescript", pkg: "x", want: nil},
+		{lang: "rust", pkg: "net", want: []string{"src/net/mod.rs"}},
+		{lang: "rust", pkg: "net/tcp", want: []string{"src/net/tcp.rs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.lang+"/"+tt.pkg, func(t *testing.T) {
+			spec, _ := servers.Get(tt.lang)
+			files, err := FinderFor(spec)(t.Context(), root, tt.pkg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, f := range files {
+				rel, _ := filepath.Rel(root, f)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Namespaces say nothing about where C++ code lives
+	spec, _ := servers.Get("c")
+	if FinderFor(spec) != nil {
+		t.Error("FinderFor(c) != nil")
+	}
+}
+
+func TestFinderFor_Go(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not installed")
+	}
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"go.mod":                         "module example.com/app\n\ngo 1.21\n",
+		"internal/config/config.go":      "package config\n",
+		"internal/config/config_test.go": "package config\n",
+		"server/server.go":               "package server\n",
+	})
+
+	spec, _ := servers.Get("go")
+	find := FinderFor(spec)
+
+	tests := []struct {
+		pkg  string
+		want []string
+	}{
+		{pkg: "config", want: []string{"internal/config/config.go", "internal/config/config_test.go"}},
+		{pkg: "internal/config", want: []string{"internal/config/config.go", "internal/config/config_test.go"}},
+		{pkg: "example.com/app/server", want: []string{"server/server.go"}},
+		{pkg: "missing", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pkg, func(t *testing.T) {
+			files, err := find(t.Context(), root, tt.pkg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, f := range files {
+				rel, _ := filepath.Rel(root, f)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The workspace is listed once; import paths are still looked up
+	writeTree(t, root, map[string]string{"worker/worker.go": "package worker\n"})
+	if files, err := find(t.Context(), root, "worker"); err != nil || files != nil {
+		t.Errorf("find(worker) = %v, %v, want the cached listing", files, err)
+	}
+	if files, err := find(t.Context(), root, "example.com/app/worker"); err != nil || len(files) != 1 {
+		t.Errorf("find(example.com/app/worker) = %v, %v, want worker.go", files, err)
+	}
+
+	// Packages outside the workspace are found by import path
+	files, err := find(t.Context(), root, "errors")
+	if err != nil || len(files) == 0 {
+		t.Errorf("find(errors) = %v, %v, want the standard library package", files, err)
+	}
+}
@@ -2,7 +2,14 @@
 package symbols
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+
+	"github.com/jasonmoo/wildcat/internal/servers"
 )
 
 // Query represents a parsed symbol query.
@@ -12,21 +19,120 @@ type Query struct {
 	Pointer  bool   // Whether receiver is pointer (*Type)
 	Name     string // Function or method name (e.g., "Load", "Start")
 	Raw      string // Original input string
+
+	// The full path a query spells out, for languages that nest deeper
+	// than package and type. Type is the innermost container.
+	Module     []string // Module path segments (e.g., ["crate", "net"])
+	Containers []string // Enclosing types, outermost first (e.g., ["Outer", "Inner"])
+	Qualifier  string   // Whole scope, for servers that name containers by it (e.g., C++ "ns::widget")
+
+	// Pattern queries select every matching symbol. Package, Type and Name
+	// may also be globs (e.g., "Server.*", "*Handler").
+	Regexp *regexp.Regexp // From "re:", matched against plain and qualified names
+
+	// Position queries name a source file instead of a package
+	File   string // File path, absolute or relative to the workspace root
+	Line   int    // 1-based line, 0 to select by Name instead
+	Column int    // 1-based byte column, 0 for the symbol enclosing Line
 }
 
-// Parse parses a symbol string into a Query.
+// Parse parses a symbol string in Go syntax into a Query.
 // Supported formats:
 //   - Function           -> name only, resolve in context
 //   - pkg.Function       -> package.name
 //   - Type.Method        -> type.method
 //   - (*Type).Method     -> pointer receiver method
 //   - path/to/pkg.Func   -> full path
+//   - file.go:42         -> symbol enclosing line 42
+//   - file.go:42:7       -> symbol under the cursor at line 42, column 7
+//   - file.go#Name       -> symbol declared in file.go
+//   - Server.*           -> glob: every method on Server
+//   - re:^Load.*$        -> regular expression over symbol names
 func Parse(input string) (*Query, error) {
+	return parse(input, parseName)
+}
+
+// A Parser parses a symbol string into a Query.
+type Parser func(input string) (*Query, error)
+
+// ParserFor returns the parser for the symbol syntax of the language spec
+// serves. Every syntax accepts the position and pattern formats; names
+// follow the language:
+//   - Python       pkg.module.Class.method
+//   - TypeScript   src/server.Server#start (instance), Server.create (static)
+//   - Rust         crate::net::Server::start
+//   - C/C++        ns::Widget::draw
+//
+// Other languages use Go syntax.
+func ParserFor(spec *servers.ServerSpec) Parser {
+	var name func(string) (*Query, error)
+	switch {
+	case spec == nil:
+		return Parse
+	case spec.Language == "python":
+		name = parsePythonName
+	case spec.Language == "typescript":
+		name = parseTypeScriptName
+	case spec.Language == "rust":
+		name = parseRustName
+	case spec.Language == "c":
+		name = parseCppName
+	default:
+		return Parse
+	}
+	return func(input string) (*Query, error) {
+		return parse(input, name)
+	}
+}
+
+// parse parses input, using name for the formats that name a symbol.
+func parse(input string, name func(string) (*Query, error)) (*Query, error) {
 	input = strings.TrimSpace(input)
 	if input == "" {
 		return nil, &ParseError{Input: input, Message: "empty symbol"}
 	}
 
+	if q, ok, err := parseLocation(input, name); ok || err != nil {
+		return q, err
+	}
+
+	if expr, ok := strings.CutPrefix(input, "re:"); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil || expr == "" {
+			return nil, &ParseError{Input: input, Message: "invalid regular expression"}
+		}
+		return &Query{Regexp: re, Raw: input}, nil
+	}
+
+	q, err := name(input)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range append([]string{q.Package, q.Type, q.Name}, q.Module...) {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, &ParseError{Input: input, Message: "invalid pattern"}
+		}
+	}
+	return q, nil
+}
+
+// parseName parses the Go name formats: functions and methods, qualified
+// or not.
+func parseName(input string) (*Query, error) {
+	q, err := parseGoName(input)
+	if err != nil {
+		return nil, err
+	}
+	if q.Package != "" {
+		q.Module = strings.Split(q.Package, "/")
+	}
+	if q.Type != "" {
+		q.Containers = []string{q.Type}
+	}
+	return q, nil
+}
+
+func parseGoName(input string) (*Query, error) {
 	q := &Query{Raw: input}
 
 	// Check for pointer receiver: (*Type).Method
@@ -95,8 +201,183 @@ func Parse(input string) (*Query, error) {
 	return q, nil
 }
 
+// parseLocation parses the position formats, which start with a source
+// file. ok is false when input is not one of them.
+func parseLocation(input string, name func(string) (*Query, error)) (*Query, bool, error) {
+	if i := strings.Index(input, "#"); i > 0 && isSourceFile(input[:i]) {
+		q, err := parse(input[i+1:], name)
+		if err != nil {
+			return nil, true, &ParseError{Input: input, Message: "invalid symbol after '#'"}
+		}
+		q.Package = ""
+		q.Module = nil
+		q.File = input[:i]
+		q.Raw = input
+		return q, true, nil
+	}
+
+	// Take up to two numbers off the end: file:line or file:line:column
+	file := input
+	var nums []int
+	for len(nums) < 2 {
+		i := strings.LastIndex(file, ":")
+		if i < 0 {
+			break
+		}
+		n, err := strconv.Atoi(file[i+1:])
+		if err != nil || n < 1 {
+			break
+		}
+		nums = append([]int{n}, nums...)
+		file = file[:i]
+	}
+	if len(nums) == 0 || !isSourceFile(file) {
+		if i := strings.Index(input, ":"); i > 0 && isSourceFile(input[:i]) {
+			return nil, true, &ParseError{Input: input, Message: "expected line number after ':'"}
+		}
+		return nil, false, nil
+	}
+
+	q := &Query{File: file, Line: nums[0], Raw: input}
+	if len(nums) == 2 {
+		q.Column = nums[1]
+	}
+	return q, true, nil
+}
+
+// parsePythonName parses a dotted Python path: pkg.module.Class.method.
+func parsePythonName(input string) (*Query, error) {
+	q := &Query{Raw: input}
+	if err := splitPath(q, strings.Split(input, ".")); err != nil {
+		return nil, err
+	}
+	q.Package = strings.Join(q.Module, "/")
+	return q, nil
+}
+
+// parseTypeScriptName parses a TypeScript path. Class#method names an
+// instance member and Class.method a static one. A module path may lead
+// with directories: src/server.Server#start.
+func parseTypeScriptName(input string) (*Query, error) {
+	q := &Query{Raw: input}
+
+	var dirs []string
+	rest := input
+	if i := strings.LastIndex(rest, "/"); i >= 0 {
+		for _, d := range strings.Split(rest[:i], "/") {
+			if d != "." {
+				dirs = append(dirs, d)
+			}
+		}
+		rest = rest[i+1:]
+	}
+
+	instance := false
+	var segs []string
+	if left, name, ok := strings.Cut(rest, "#"); ok {
+		// Whatever precedes '#' is the class, whatever its case
+		instance = true
+		segs = append(strings.Split(left, "."), name)
+	} else {
+		segs = strings.Split(rest, ".")
+		// Class.prototype.method is the instance method too
+		if i := slices.Index(segs, "prototype"); i > 0 && i == len(segs)-2 {
+			instance = true
+			segs = slices.Delete(segs, i, i+1)
+		}
+	}
+	if err := splitPath(q, segs); err != nil {
+		return nil, err
+	}
+	if instance && len(q.Containers) == 0 && len(q.Module) > 0 {
+		q.Containers = q.Module[len(q.Module)-1:]
+		q.Module = q.Module[:len(q.Module)-1]
+		q.Type = q.Containers[0]
+	}
+	for _, d := range dirs {
+		if d == "" {
+			return nil, &ParseError{Input: input, Message: "empty path segment"}
+		}
+	}
+	q.Module = append(dirs, q.Module...)
+	q.Package = strings.Join(q.Module, "/")
+	return q, nil
+}
+
+// parseRustName parses a Rust path: crate::net::Server::start. The crate,
+// self and super keywords stay in Module but not in Package.
+func parseRustName(input string) (*Query, error) {
+	q := &Query{Raw: input}
+	if err := splitPath(q, strings.Split(strings.TrimPrefix(input, "::"), "::")); err != nil {
+		return nil, err
+	}
+	var pkg []string
+	for _, m := range q.Module {
+		if m != "crate" && m != "self" && m != "super" {
+			pkg = append(pkg, m)
+		}
+	}
+	q.Package = strings.Join(pkg, "/")
+	return q, nil
+}
+
+// parseCppName parses a C++ qualified name: ns::Widget::draw. A leading
+// "::" names the global namespace. Namespaces and classes are spelled
+// alike (std::vector::push_back), so the whole scope is kept as the
+// qualifier, matched against the container the server reports.
+func parseCppName(input string) (*Query, error) {
+	q := &Query{Raw: input}
+	segs := strings.Split(strings.TrimPrefix(input, "::"), "::")
+	if err := splitPath(q, segs); err != nil {
+		return nil, err
+	}
+	q.Package = strings.Join(q.Module, "::")
+	q.Qualifier = strings.Join(segs[:len(segs)-1], "::")
+	return q, nil
+}
+
+// splitPath fills q from the segments of a qualified name. The last
+// segment is the name; before it, lowercase segments are the module path
+// and segments from the first capitalized one on are containers.
+func splitPath(q *Query, segs []string) error {
+	for _, s := range segs {
+		if s == "" {
+			return &ParseError{Input: q.Raw, Message: "empty path segment"}
+		}
+	}
+	last := len(segs) - 1
+	q.Name = segs[last]
+
+	i := 0
+	for i < last && !isCapitalized(segs[i]) {
+		i++
+	}
+	if i > 0 {
+		q.Module = segs[:i]
+	}
+	if i < last {
+		q.Containers = segs[i:last]
+		q.Type = segs[last-1]
+	}
+	return nil
+}
+
 // String returns a string representation of the query.
 func (q *Query) String() string {
+	if q.Regexp != nil && q.File == "" {
+		return "re:" + q.Regexp.String()
+	}
+	if q.File != "" {
+		switch {
+		case q.Column > 0:
+			return fmt.Sprintf("%s:%d:%d", q.File, q.Line, q.Column)
+		case q.Line > 0:
+			return fmt.Sprintf("%s:%d", q.File, q.Line)
+		}
+		name := *q
+		name.File = ""
+		return q.File + "#" + name.String()
+	}
 	if q.Pointer {
 		return "(*" + q.Type + ")." + q.Name
 	}
@@ -114,6 +395,16 @@ func (q *Query) IsMethod() bool {
 	return q.Type != ""
 }
 
+// IsPattern returns true if the query may select more than one symbol.
+func (q *Query) IsPattern() bool {
+	return q.Regexp != nil || isGlob(q.Package) || isGlob(q.Type) || isGlob(q.Name)
+}
+
+// IsLocation returns true if the query names a source file.
+func (q *Query) IsLocation() bool {
+	return q.File != ""
+}
+
 // ParseError represents a symbol parsing error.
 type ParseError struct {
 	Input   string
@@ -124,6 +415,24 @@ func (e *ParseError) Error() string {
 	return "invalid symbol '" + e.Input + "': " + e.Message
 }
 
+// isGlob returns true if s contains glob metacharacters.
+func isGlob(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// isSourceFile returns true if path has the extension of a supported language.
+func isSourceFile(path string) bool {
+	_, ok := servers.Detect(path)
+	return ok
+}
+
+// isCapitalized returns true if s starts with an uppercase letter after
+// any underscores, as in Python's _Private.
+func isCapitalized(s string) bool {
+	s = strings.TrimLeft(s, "_")
+	return s != "" && isUppercase(s[0])
+}
+
 // isUppercase returns true if the byte is an uppercase ASCII letter.
 func isUppercase(b byte) bool {
 	return b >= 'A' && b <= 'Z'
@@ -1,7 +1,10 @@
 package symbols
 
 import (
+	"slices"
 	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/servers"
 )
 
 func TestParse(t *testing.T) {
@@ -107,6 +110,316 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParse_Location(t *testing.T) {
+	tests := []struct {
+		input      string
+		wantFile   string
+		wantLine   int
+		wantColumn int
+		wantType   string
+		wantName   string
+		wantErr    bool
+	}{
+		{input: "main.go:42", wantFile: "main.go", wantLine: 42},
+		{input: "internal/lsp/client.go:42:7", wantFile: "internal/lsp/client.go", wantLine: 42, wantColumn: 7},
+		{input: "/abs/path/app.py:3", wantFile: "/abs/path/app.py", wantLine: 3},
+		{input: "server.go#Start", wantFile: "server.go", wantName: "Start"},
+		{input: "server.go#Server.Start", wantFile: "server.go", wantType: "Server", wantName: "Start"},
+		{input: "server.go#(*Server).Start", wantFile: "server.go", wantType: "Server", wantName: "Start"},
+		{input: "main.go:0", wantErr: true},
+		{input: "main.go:abc", wantErr: true},
+		{input: "server.go#(*Server.Start", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !got.IsLocation() {
+				t.Error("IsLocation() = false, want true")
+			}
+			if got.File != tt.wantFile {
+				t.Errorf("File = %q, want %q", got.File, tt.wantFile)
+			}
+			if got.Line != tt.wantLine || got.Column != tt.wantColumn {
+				t.Errorf("Line:Column = %d:%d, want %d:%d", got.Line, got.Column, tt.wantLine, tt.wantColumn)
+			}
+			if got.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", got.Type, tt.wantType)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", got.Name, tt.wantName)
+			}
+		})
+	}
+
+	// Names that only look like files are still symbols
+	for _, input := range []string{"config.Load", "os.Exit", "main.go"} {
+		if q, err := Parse(input); err != nil || q.IsLocation() {
+			t.Errorf("Parse(%q) = %+v, %v, want a symbol query", input, q, err)
+		}
+	}
+}
+
+func TestParse_Pattern(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantPkg  string
+		wantType string
+		wantName string
+		wantRe   string
+		wantErr  bool
+	}{
+		{input: "Server.*", wantType: "Server", wantName: "*"},
+		{input: "*Handler", wantName: "*Handler"},
+		{input: "config.Load*", wantPkg: "config", wantName: "Load*"},
+		{input: "(*Server).Get?", wantType: "Server", wantName: "Get?"},
+		{input: "re:^Load.*$", wantRe: "^Load.*$"},
+		{input: "re:", wantErr: true},
+		{input: "re:(", wantErr: true},
+		{input: "Server.[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !got.IsPattern() {
+				t.Error("IsPattern() = false, want true")
+			}
+			if got.Package != tt.wantPkg || got.Type != tt.wantType || got.Name != tt.wantName {
+				t.Errorf("Package, Type, Name = %q, %q, %q, want %q, %q, %q",
+					got.Package, got.Type, got.Name, tt.wantPkg, tt.wantType, tt.wantName)
+			}
+			if tt.wantRe != "" && (got.Regexp == nil || got.Regexp.String() != tt.wantRe) {
+				t.Errorf("Regexp = %v, want %q", got.Regexp, tt.wantRe)
+			}
+			if tt.wantRe != "" && got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+
+	// A pointer receiver is not a glob
+	if q, _ := Parse("(*Server).Start"); q.IsPattern() {
+		t.Error("(*Server).Start parsed as a pattern")
+	}
+}
+
+// syntaxTest is a case for a language's symbol syntax.
+type syntaxTest struct {
+	input          string
+	wantModule     []string
+	wantContainers []string
+	wantPkg        string
+	wantType       string
+	wantName       string
+	wantQualifier  string
+	wantErr        bool
+}
+
+// testSyntax runs tests against the parser for lang.
+func testSyntax(t *testing.T, lang string, tests []syntaxTest) {
+	t.Helper()
+	spec, ok := servers.Get(lang)
+	if !ok {
+		t.Fatalf("no server for %q", lang)
+	}
+	parse := ParserFor(spec)
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := parse(tt.input)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(got.Module, tt.wantModule) {
+				t.Errorf("Module = %q, want %q", got.Module, tt.wantModule)
+			}
+			if !slices.Equal(got.Containers, tt.wantContainers) {
+				t.Errorf("Containers = %q, want %q", got.Containers, tt.wantContainers)
+			}
+			if got.Package != tt.wantPkg || got.Type != tt.wantType || got.Name != tt.wantName {
+				t.Errorf("Package, Type, Name = %q, %q, %q, want %q, %q, %q",
+					got.Package, got.Type, got.Name, tt.wantPkg, tt.wantType, tt.wantName)
+			}
+			if got.Qualifier != tt.wantQualifier {
+				t.Errorf("Qualifier = %q, want %q", got.Qualifier, tt.wantQualifier)
+			}
+		})
+	}
+}
+
+func TestParserFor_Go(t *testing.T) {
+	testSyntax(t, "go", []syntaxTest{
+		{input: "Load", wantName: "Load"},
+		{input: "config.Load", wantModule: []string{"config"}, wantPkg: "config", wantName: "Load"},
+		{input: "internal/config.Load", wantModule: []string{"internal", "config"}, wantPkg: "internal/config", wantName: "Load"},
+		{input: "(*Server).Start", wantContainers: []string{"Server"}, wantType: "Server", wantName: "Start"},
+	})
+
+	if p := ParserFor(nil); p == nil {
+		t.Error("ParserFor(nil) = nil, want Parse")
+	}
+}
+
+func TestParserFor_Python(t *testing.T) {
+	testSyntax(t, "python", []syntaxTest{
+		{input: "main", wantName: "main"},
+		{input: "utils.load", wantModule: []string{"utils"}, wantPkg: "utils", wantName: "load"},
+		{
+			input:          "pkg.module.Class.method",
+			wantModule:     []string{"pkg", "module"},
+			wantContainers: []string{"Class"},
+			wantPkg:        "pkg/module",
+			wantType:       "Class",
+			wantName:       "method",
+		},
+		{
+			input:          "app.Outer.Inner.run",
+			wantModule:     []string{"app"},
+			wantContainers: []string{"Outer", "Inner"},
+			wantPkg:        "app",
+			wantType:       "Inner",
+			wantName:       "run",
+		},
+		{
+			input:          "models._Base.save",
+			wantModule:     []string{"models"},
+			wantContainers: []string{"_Base"},
+			wantPkg:        "models",
+			wantType:       "_Base",
+			wantName:       "save",
+		},
+		{input: "Server.*", wantContainers: []string{"Server"}, wantType: "Server", wantName: "*"},
+		{input: "pkg..load", wantErr: true},
+	})
+}
+
+func TestParserFor_TypeScript(t *testing.T) {
+	testSyntax(t, "typescript", []syntaxTest{
+		{input: "main", wantName: "main"},
+		{input: "Server#start", wantContainers: []string{"Server"}, wantType: "Server", wantName: "start"},
+		{input: "Server.create", wantContainers: []string{"Server"}, wantType: "Server", wantName: "create"},
+		{input: "Server.prototype.start", wantContainers: []string{"Server"}, wantType: "Server", wantName: "start"},
+		{input: "server#start", wantContainers: []string{"server"}, wantType: "server", wantName: "start"},
+		{
+			input:          "src/server.Server#start",
+			wantModule:     []string{"src", "server"},
+			wantContainers: []string{"Server"},
+			wantPkg:        "src/server",
+			wantType:       "Server",
+			wantName:       "start",
+		},
+		{
+			input:      "./lib/util.parse",
+			wantModule: []string{"lib", "util"},
+			wantPkg:    "lib/util",
+			wantName:   "parse",
+		},
+		{input: "Server#", wantErr: true},
+	})
+}
+
+func TestParserFor_Rust(t *testing.T) {
+	testSyntax(t, "rust", []syntaxTest{
+		{input: "main", wantName: "main"},
+		{
+			input:          "crate::net::Server::start",
+			wantModule:     []string{"crate", "net"},
+			wantContainers: []string{"Server"},
+			wantPkg:        "net",
+			wantType:       "Server",
+			wantName:       "start",
+		},
+		{input: "Server::new", wantContainers: []string{"Server"}, wantType: "Server", wantName: "new"},
+		{
+			input:      "self::util::parse",
+			wantModule: []string{"self", "util"},
+			wantPkg:    "util",
+			wantName:   "parse",
+		},
+		{input: "::std::mem::swap", wantModule: []string{"std", "mem"}, wantPkg: "std/mem", wantName: "swap"},
+		{input: "net::::start", wantErr: true},
+	})
+}
+
+func TestParserFor_Cpp(t *testing.T) {
+	testSyntax(t, "c", []syntaxTest{
+		{input: "main", wantName: "main"},
+		{
+			input:          "ns::Widget::draw",
+			wantModule:     []string{"ns"},
+			wantContainers: []string{"Widget"},
+			wantPkg:        "ns",
+			wantType:       "Widget",
+			wantName:       "draw",
+			wantQualifier:  "ns::Widget",
+		},
+		{
+			input:          "gfx::detail::Widget::Impl::~Impl",
+			wantModule:     []string{"gfx", "detail"},
+			wantContainers: []string{"Widget", "Impl"},
+			wantPkg:        "gfx::detail",
+			wantType:       "Impl",
+			wantName:       "~Impl",
+			wantQualifier:  "gfx::detail::Widget::Impl",
+		},
+		{
+			input:         "std::vector::push_back",
+			wantModule:    []string{"std", "vector"},
+			wantPkg:       "std::vector",
+			wantName:      "push_back",
+			wantQualifier: "std::vector",
+		},
+		{input: "::helper", wantName: "helper"},
+		{input: "Widget::", wantErr: true},
+	})
+}
+
+func TestParserFor_Location(t *testing.T) {
+	spec, _ := servers.Get("typescript")
+	got, err := ParserFor(spec)("src/server.ts#Server#start")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.File != "src/server.ts" || got.Type != "Server" || got.Name != "start" || got.Package != "" {
+		t.Errorf("File, Package, Type, Name = %q, %q, %q, %q, want %q, \"\", %q, %q",
+			got.File, got.Package, got.Type, got.Name, "src/server.ts", "Server", "start")
+	}
+}
+
 func TestQuery_String(t *testing.T) {
 	tests := []struct {
 		query *Query
@@ -128,6 +441,18 @@ func TestQuery_String(t *testing.T) {
 			query: &Query{Type: "Server", Pointer: true, Name: "Start"},
 			want:  "(*Server).Start",
 		},
+		{
+			query: &Query{File: "main.go", Line: 42},
+			want:  "main.go:42",
+		},
+		{
+			query: &Query{File: "main.go", Line: 42, Column: 7},
+			want:  "main.go:42:7",
+		},
+		{
+			query: &Query{File: "server.go", Type: "Server", Name: "Start"},
+			want:  "server.go#Server.Start",
+		},
 	}
 
 	for _, tt := range tests {
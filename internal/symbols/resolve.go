@@ -2,6 +2,11 @@ package symbols
 
 import (
 	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp/syntax"
+	"slices"
 	"strings"
 
 	"github.com/jasonmoo/wildcat/internal/errors"
@@ -19,19 +24,47 @@ type ResolvedSymbol struct {
 
 // Resolver resolves symbol queries using an LSP client.
 type Resolver struct {
-	client *lsp.Client
+	client      *lsp.Client
+	packages    PackageFinder
+	symbolLimit int
 }
 
+// symbolLimit is the most workspace/symbol results gopls returns; other
+// servers cap their results similarly.
+const symbolLimit = 100
+
 // NewResolver creates a new symbol resolver.
 func NewResolver(client *lsp.Client) *Resolver {
-	return &Resolver{client: client}
+	return &Resolver{client: client, symbolLimit: symbolLimit}
+}
+
+// SetPackageFinder sets how the resolver finds the files of a query's
+// package. Without one, only type qualifiers are located directly.
+func (r *Resolver) SetPackageFinder(f PackageFinder) {
+	r.packages = f
 }
 
 // Resolve finds a symbol matching the query.
 // Returns an error with suggestions if not found or ambiguous.
 func (r *Resolver) Resolve(ctx context.Context, query *Query) (*ResolvedSymbol, error) {
+	if query.IsLocation() {
+		return r.resolveLocation(ctx, query)
+	}
+
+	declared := r.findDeclared(ctx, query)
+	if len(declared) == 1 {
+		return &declared[0], nil
+	}
+	if len(declared) > 1 {
+		candidates := make([]string, len(declared))
+		for i, m := range declared {
+			candidates[i] = m.Name
+		}
+		return nil, errors.NewAmbiguousSymbol(query.Raw, candidates)
+	}
+
 	// Search for the symbol using workspace/symbol
-	symbols, err := r.client.WorkspaceSymbol(ctx, query.Name)
+	symbols, err := r.client.WorkspaceSymbol(ctx, searchTerm(query))
 	if err != nil {
 		return nil, errors.NewLSPError("workspace/symbol", err)
 	}
@@ -74,11 +107,22 @@ func (r *Resolver) Resolve(ctx context.Context, query *Query) (*ResolvedSymbol,
 	}, nil
 }
 
-// FindAll finds all symbols matching the query.
-func (r *Resolver) FindAll(ctx context.Context, query *Query) ([]ResolvedSymbol, error) {
-	symbols, err := r.client.WorkspaceSymbol(ctx, query.Name)
+// FindAll finds all symbols matching the query. It also reports whether
+// the server's search results reached its limit, in which case some
+// matches may be missing.
+func (r *Resolver) FindAll(ctx context.Context, query *Query) ([]ResolvedSymbol, bool, error) {
+	if query.IsLocation() {
+		matches, _, err := r.locate(ctx, query)
+		return matches, false, err
+	}
+
+	if declared := r.findDeclared(ctx, query); len(declared) > 0 {
+		return declared, false, nil
+	}
+
+	symbols, err := r.client.WorkspaceSymbol(ctx, searchTerm(query))
 	if err != nil {
-		return nil, errors.NewLSPError("workspace/symbol", err)
+		return nil, false, errors.NewLSPError("workspace/symbol", err)
 	}
 
 	var results []ResolvedSymbol
@@ -94,18 +138,36 @@ func (r *Resolver) FindAll(ctx context.Context, query *Query) ([]ResolvedSymbol,
 		}
 	}
 
-	return results, nil
+	return results, len(symbols) >= r.symbolLimit, nil
 }
 
 // matchesQuery checks if a symbol matches the query.
 func (r *Resolver) matchesQuery(sym lsp.SymbolInformation, query *Query) bool {
+	if query.Regexp != nil {
+		return query.Regexp.MatchString(sym.Name) || query.Regexp.MatchString(r.formatSymbol(sym))
+	}
+
 	// Name must match
-	if sym.Name != query.Name {
+	if !matchName(query.Name, sym.Name) {
+		return false
+	}
+
+	// Glob qualifiers match the container, or the type name within it
+	container := sym.ContainerName
+	if isGlob(query.Package) && !matchName(query.Package, container) {
+		return false
+	}
+	if isGlob(query.Type) && !matchName(query.Type, container) &&
+		!matchName(query.Type, container[strings.LastIndex(container, ".")+1:]) {
+		return false
+	}
+
+	if query.Qualifier != "" && !matchScope(query.Qualifier, container) {
 		return false
 	}
 
 	// If query specifies a package, container should contain it
-	if query.Package != "" {
+	if query.Package != "" && !isGlob(query.Package) {
 		if !strings.Contains(strings.ToLower(sym.ContainerName), strings.ToLower(query.Package)) {
 			// Also check URI for package path
 			if !strings.Contains(strings.ToLower(sym.Location.URI), strings.ToLower(query.Package)) {
@@ -115,7 +177,7 @@ func (r *Resolver) matchesQuery(sym lsp.SymbolInformation, query *Query) bool {
 	}
 
 	// If query specifies a type, container should match
-	if query.Type != "" {
+	if query.Type != "" && !isGlob(query.Type) {
 		if !strings.Contains(sym.ContainerName, query.Type) {
 			return false
 		}
@@ -145,3 +207,473 @@ func (r *Resolver) suggestFromSymbols(symbols []lsp.SymbolInformation, query *Qu
 	}
 	return errors.SuggestSimilar(query.Raw, candidates, limit)
 }
+
+// searchTerm returns the text to search workspace/symbol for: the query's
+// name, or the longest literal text a pattern must contain. Servers match
+// it loosely, so results are still checked with matchesQuery.
+func searchTerm(query *Query) string {
+	if query.Regexp != nil {
+		re, err := syntax.Parse(query.Regexp.String(), syntax.Perl)
+		if err != nil {
+			return ""
+		}
+		return regexpLiteral(re)
+	}
+	if !isGlob(query.Name) {
+		return query.Name
+	}
+	if term := globLiteral(query.Name); term != "" {
+		return term
+	}
+	// Servers match qualified names, so "Server.*" finds Server's methods
+	return globLiteral(query.Type)
+}
+
+// regexpLiteral returns the longest literal text every match of re
+// contains, or "" if there is none.
+func regexpLiteral(re *syntax.Regexp) string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return string(re.Rune)
+	case syntax.OpCapture, syntax.OpPlus:
+		return regexpLiteral(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return regexpLiteral(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		var longest string
+		for _, sub := range re.Sub {
+			if lit := regexpLiteral(sub); len(lit) > len(longest) {
+				longest = lit
+			}
+		}
+		return longest
+	}
+	return ""
+}
+
+// matchType returns true if typ, a dotted chain of enclosing types, is
+// the query's innermost container or its whole container chain, or ends
+// with the query's qualifier if it has one.
+func matchType(query *Query, typ string) bool {
+	if query.Qualifier != "" {
+		return matchScope(query.Qualifier, strings.ReplaceAll(typ, ".", "::"))
+	}
+	if query.Type == "" {
+		return true
+	}
+	return matchName(query.Type, typ) ||
+		matchName(query.Type, typ[strings.LastIndex(typ, ".")+1:]) ||
+		matchName(strings.Join(query.Containers, "."), typ)
+}
+
+// matchScope reports whether qualifier, a "::"-joined scope, names scope
+// or its innermost part: "widget" and "ns::widget" both name ns::widget.
+// Template arguments in scope are ignored.
+func matchScope(qualifier, scope string) bool {
+	var b strings.Builder
+	depth := 0
+	for _, c := range scope {
+		switch {
+		case c == '<':
+			depth++
+		case c == '>' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(c)
+		}
+	}
+	scope = b.String()
+
+	for {
+		if matchName(qualifier, scope) {
+			return true
+		}
+		_, rest, ok := strings.Cut(scope, "::")
+		if !ok {
+			return false
+		}
+		scope = rest
+	}
+}
+
+// globLiteral returns the longest run of literal text in a glob.
+func globLiteral(glob string) string {
+	var longest, run []byte
+	for i := 0; i < len(glob); i++ {
+		switch glob[i] {
+		case '*', '?':
+			longest, run = longer(longest, run), nil
+		case '[':
+			longest, run = longer(longest, run), nil
+			for i++; i < len(glob) && glob[i] != ']'; i++ {
+				if glob[i] == '\\' {
+					i++
+				}
+			}
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				run = append(run, glob[i])
+			}
+		default:
+			run = append(run, glob[i])
+		}
+	}
+	return string(longer(longest, run))
+}
+
+func longer(a, b []byte) []byte {
+	if len(b) > len(a) {
+		return b
+	}
+	return a
+}
+
+// matchName reports whether name matches pattern, a glob or a plain name.
+func matchName(pattern, name string) bool {
+	if !isGlob(pattern) {
+		return pattern == name
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// fileSymbol is a symbol from a document symbol listing.
+type fileSymbol struct {
+	ResolvedSymbol
+	Type      string    // Enclosing type or receiver, if any
+	Base      string    // Name without the type
+	Selection lsp.Range // Range of the symbol's name
+}
+
+// findDeclared finds the symbols matching a qualified query where they
+// are declared: in its package's files, or its type's. Servers cap and
+// rank workspace/symbol results, so a common name may never be listed
+// there. It returns nothing when the query is unqualified or its
+// declaration is not found, leaving the query to workspace/symbol.
+func (r *Resolver) findDeclared(ctx context.Context, query *Query) []ResolvedSymbol {
+	if query.Regexp != nil {
+		return nil
+	}
+
+	if r.packages != nil && query.Package != "" && !isGlob(query.Package) {
+		files, err := r.packages(ctx, r.client.Root(), query.Package)
+		if err == nil && len(files) > 0 {
+			pkg := query.Package[strings.LastIndexAny(query.Package, "/:")+1:]
+			matches := r.declaredIn(ctx, files, query)
+			for i := range matches {
+				matches[i].Name = pkg + "." + matches[i].Name
+			}
+			return matches
+		}
+	}
+
+	if query.Type == "" || isGlob(query.Type) {
+		return nil
+	}
+	var matches []ResolvedSymbol
+	for _, file := range r.typeFiles(ctx, query) {
+		found := r.declaredIn(ctx, []string{file}, query)
+		if len(found) == 0 {
+			// Go declares methods anywhere in the type's package
+			found = r.declaredIn(ctx, siblingFiles(file), query)
+		}
+		for _, m := range found {
+			if !slices.ContainsFunc(matches, func(s ResolvedSymbol) bool {
+				return s.URI == m.URI && s.Position == m.Position
+			}) {
+				matches = append(matches, m)
+			}
+		}
+	}
+	return matches
+}
+
+// declaredIn returns the symbols in files matching query's type and name.
+// Without a type or qualifier, only top-level symbols match.
+func (r *Resolver) declaredIn(ctx context.Context, files []string, query *Query) []ResolvedSymbol {
+	var matches []ResolvedSymbol
+	for _, file := range files {
+		syms, err := r.fileSymbols(ctx, lsp.FileURI(file))
+		if err != nil {
+			continue
+		}
+		for _, s := range syms {
+			if query.Type == "" && query.Qualifier == "" && s.Type != "" {
+				continue
+			}
+			if matchName(query.Name, s.Base) && matchType(query, s.Type) {
+				matches = append(matches, s.ResolvedSymbol)
+			}
+		}
+	}
+	return matches
+}
+
+// typeFiles returns the files declaring a type named query.Type, in the
+// query's package if it has one.
+func (r *Resolver) typeFiles(ctx context.Context, query *Query) []string {
+	syms, err := r.client.WorkspaceSymbol(ctx, query.Type)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, sym := range syms {
+		if sym.Name != query.Type && !strings.HasSuffix(sym.Name, "."+query.Type) {
+			continue
+		}
+		switch sym.Kind {
+		case lsp.SymbolKindClass, lsp.SymbolKindStruct, lsp.SymbolKindInterface, lsp.SymbolKindEnum:
+		default:
+			continue
+		}
+		if query.Package != "" && !isGlob(query.Package) &&
+			!strings.Contains(strings.ToLower(sym.ContainerName), strings.ToLower(query.Package)) &&
+			!strings.Contains(strings.ToLower(sym.Location.URI), strings.ToLower(query.Package)) {
+			continue
+		}
+		if file := lsp.URIToPath(sym.Location.URI); !slices.Contains(files, file) {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// resolveLocation resolves a position query against its file's symbols.
+func (r *Resolver) resolveLocation(ctx context.Context, query *Query) (*ResolvedSymbol, error) {
+	matches, names, err := r.locate(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		var suggestions []string
+		if query.Line == 0 {
+			name := *query
+			name.File = ""
+			suggestions = errors.SuggestSimilar(name.String(), names, 5)
+		}
+		return nil, errors.NewSymbolNotFound(query.Raw, suggestions)
+	}
+
+	if len(matches) > 1 {
+		candidates := make([]string, len(matches))
+		for i, m := range matches {
+			candidates[i] = m.Name
+		}
+		return nil, errors.NewAmbiguousSymbol(query.Raw, candidates)
+	}
+
+	return &matches[0], nil
+}
+
+// locate finds the symbols a position query selects in its file: the
+// symbol under the cursor when a column is given, else the innermost one
+// enclosing the line, else those named by the query. It also returns the
+// names of all the file's symbols, for suggestions.
+func (r *Resolver) locate(ctx context.Context, query *Query) ([]ResolvedSymbol, []string, error) {
+	path := query.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(r.client.Root(), path)
+	}
+	uri := lsp.FileURI(path)
+
+	syms, err := r.fileSymbols(ctx, uri)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := make([]string, len(syms))
+	for i, s := range syms {
+		names[i] = s.Name
+	}
+
+	if query.Line == 0 {
+		var matches []ResolvedSymbol
+		for _, s := range syms {
+			match := matchName(query.Name, s.Base) && matchType(query, s.Type)
+			if query.Regexp != nil {
+				match = query.Regexp.MatchString(s.Base) || query.Regexp.MatchString(s.Name)
+			}
+			if match {
+				matches = append(matches, s.ResolvedSymbol)
+			}
+		}
+		return matches, names, nil
+	}
+
+	pos := lsp.Position{Line: query.Line - 1}
+	if query.Column > 0 {
+		pos.Character = utf16Column(path, query.Line, query.Column)
+		sym, err := r.definitionAt(ctx, uri, pos, syms)
+		if err != nil {
+			return nil, nil, err
+		}
+		if sym != nil {
+			return []ResolvedSymbol{*sym}, names, nil
+		}
+	}
+
+	// Lines only: an indented declaration still encloses its first line
+	var best *fileSymbol
+	for i, s := range syms {
+		if s.Range.Start.Line <= pos.Line && pos.Line <= s.Range.End.Line &&
+			(best == nil || !before(s.Range.Start, best.Range.Start)) {
+			best = &syms[i]
+		}
+	}
+	if best == nil {
+		return nil, names, nil
+	}
+	return []ResolvedSymbol{best.ResolvedSymbol}, names, nil
+}
+
+// definitionAt returns the symbol declared where the identifier at pos is
+// defined, or nil if it is not a symbol of its file, such as a local
+// variable. syms are the symbols of uri.
+func (r *Resolver) definitionAt(ctx context.Context, uri string, pos lsp.Position, syms []fileSymbol) (*ResolvedSymbol, error) {
+	locs, err := r.client.Definition(ctx, uri, pos)
+	if err != nil {
+		return nil, errors.NewLSPError("textDocument/definition", err)
+	}
+	if len(locs) == 0 {
+		return nil, nil
+	}
+
+	loc := locs[0]
+	if loc.URI != uri {
+		if syms, err = r.fileSymbols(ctx, loc.URI); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range syms {
+		if s.Selection.Contains(loc.Range.Start) {
+			return &s.ResolvedSymbol, nil
+		}
+	}
+	return nil, nil
+}
+
+// fileSymbols lists the symbols of a file, flattening any hierarchy.
+func (r *Resolver) fileSymbols(ctx context.Context, uri string) ([]fileSymbol, error) {
+	syms, err := r.client.DocumentSymbol(ctx, uri)
+	if err != nil {
+		return nil, errors.NewLSPError("textDocument/documentSymbol", err)
+	}
+
+	var result []fileSymbol
+	var collect func(syms []lsp.DocumentSymbol, parent string)
+	collect = func(syms []lsp.DocumentSymbol, parent string) {
+		for _, s := range syms {
+			typ, base := splitReceiver(s.Name)
+			if typ == "" {
+				typ = parent
+			}
+			name := base
+			if typ != "" {
+				name = typ + "." + base
+			}
+			result = append(result, fileSymbol{
+				ResolvedSymbol: ResolvedSymbol{
+					Name:     name,
+					Kind:     s.Kind,
+					URI:      uri,
+					Position: s.SelectionRange.Start,
+					Range:    s.Range,
+				},
+				Type:      typ,
+				Base:      base,
+				Selection: s.SelectionRange,
+			})
+			if impl, ok := implType(s.Name); ok {
+				// rust-analyzer nests methods under their impl block
+				name = impl
+				if parent != "" {
+					name = parent + "." + impl
+				}
+			}
+			collect(s.Children, name)
+		}
+	}
+	collect(syms, "")
+	return result, nil
+}
+
+// splitReceiver splits a method name as gopls lists it, "(*Type).Method",
+// into its receiver type and name. Other names have no type.
+func splitReceiver(name string) (typ, base string) {
+	if !strings.HasPrefix(name, "(") {
+		return "", name
+	}
+	recv, base, ok := strings.Cut(name[1:], ").")
+	if !ok {
+		return "", name
+	}
+	recv = strings.TrimPrefix(recv, "*")
+	if i := strings.Index(recv, "["); i >= 0 {
+		recv = recv[:i]
+	}
+	return recv, base
+}
+
+// implType returns the type a Rust impl block, as rust-analyzer names it
+// ("impl Server", "impl<T> Handler for Server<T>"), implements methods
+// for. Other names are not impl blocks.
+func implType(name string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, "impl")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '<') {
+		return "", false
+	}
+	if i := strings.LastIndex(rest, " for "); i >= 0 {
+		rest = rest[i+len(" for "):]
+	} else if strings.HasPrefix(rest, "<") {
+		// Skip the impl's generic parameters
+		depth := 0
+		for i, c := range rest {
+			if c == '<' {
+				depth++
+			} else if c == '>' {
+				if depth--; depth == 0 {
+					rest = rest[i+1:]
+					break
+				}
+			}
+		}
+	}
+	typ := strings.TrimLeft(strings.TrimSpace(rest), "&")
+	for _, prefix := range []string{"mut ", "dyn "} {
+		typ = strings.TrimPrefix(typ, prefix)
+	}
+	if i := strings.IndexAny(typ, "< "); i >= 0 {
+		typ = typ[:i]
+	}
+	if i := strings.LastIndex(typ, "::"); i >= 0 {
+		typ = typ[i+2:]
+	}
+	if typ == "" {
+		return "", false
+	}
+	return typ, true
+}
+
+// utf16Column converts a 1-based byte column on a 1-based line of file,
+// as compilers and stack traces report it, to the 0-based UTF-16 offset
+// LSP positions count in. It assumes ASCII if the file cannot be read.
+func utf16Column(file string, line, column int) int {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return column - 1
+	}
+	lines := strings.SplitN(string(data), "\n", line+1)
+	if line > len(lines) {
+		return column - 1
+	}
+	return lsp.UTF16Offset(lines[line-1], column-1)
+}
+
+// before reports whether a comes before b.
+func before(a, b lsp.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
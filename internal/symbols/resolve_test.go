@@ -0,0 +1,495 @@
+package symbols
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/errors"
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+	"github.com/jasonmoo/wildcat/internal/servers"
+)
+
+func newTestResolver(t *testing.T) (*Resolver, *lsptest.Server) {
+	t.Helper()
+	srv := lsptest.NewServer("/ws")
+	srv.Func("config", "Load", "config/config.go", 10)
+	srv.Func("config", "LoadFile", "config/config.go", 20)
+	srv.Method("Server", "Start", "server/server.go", 30)
+	srv.Method("Client", "Start", "client/client.go", 40)
+	srv.Func("server", "Start", "server/start.go", 5)
+	return NewResolver(srv.Client(t)), srv
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	r, srv := newTestResolver(t)
+
+	tests := []struct {
+		query    string
+		wantName string
+		wantURI  string
+		wantCode errors.Code
+	}{
+		{query: "config.Load", wantName: "config.Load", wantURI: srv.URI("config/config.go")},
+		{query: "Load", wantName: "config.Load", wantURI: srv.URI("config/config.go")},
+		{query: "Server.Start", wantName: "Server.Start", wantURI: srv.URI("server/server.go")},
+		{query: "(*Client).Start", wantName: "Client.Start", wantURI: srv.URI("client/client.go")},
+		{query: "Start", wantCode: errors.CodeAmbiguousSymbol},
+		{query: "Missing", wantCode: errors.CodeSymbolNotFound},
+		{query: "other.Load", wantCode: errors.CodeSymbolNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			sym, err := r.Resolve(t.Context(), q)
+			if tt.wantCode != "" {
+				we, ok := errors.FromError(err)
+				if !ok || we.Code != tt.wantCode {
+					t.Fatalf("err = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if sym.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", sym.Name, tt.wantName)
+			}
+			if sym.URI != tt.wantURI {
+				t.Errorf("URI = %q, want %q", sym.URI, tt.wantURI)
+			}
+		})
+	}
+}
+
+func TestResolver_Suggestions(t *testing.T) {
+	r, _ := newTestResolver(t)
+
+	q, _ := Parse("other.Load")
+	_, err := r.Resolve(t.Context(), q)
+	we, ok := errors.FromError(err)
+	if !ok {
+		t.Fatalf("err = %v, want WildcatError", err)
+	}
+	if len(we.Suggestions) == 0 {
+		t.Error("expected suggestions for near miss")
+	}
+}
+
+func TestResolver_FindAll(t *testing.T) {
+	r, _ := newTestResolver(t)
+
+	q, _ := Parse("Start")
+	syms, _, err := r.FindAll(t.Context(), q)
+	if err != nil {
+		t.Fatalf("FindAll: %v", err)
+	}
+	if len(syms) != 3 {
+		t.Errorf("FindAll(Start) = %d symbols, want 3", len(syms))
+	}
+}
+
+func TestResolver_ResolveLocation(t *testing.T) {
+	srv := lsptest.NewServer("/ws")
+	run := srv.Add(lsptest.Symbol{Name: "run", Container: "main", Kind: lsp.SymbolKindFunction, File: "main.go", Line: 5, EndLine: 12})
+	load := srv.Func("config", "Load", "config/config.go", 10)
+	srv.Call(run, load, 8)
+	srv.Method("Server", "Start", "server.go", 3)
+	srv.Method("Client", "Start", "server.go", 8)
+	// gopls names methods after their receiver
+	srv.Add(lsptest.Symbol{Name: "(*Server).Stop", Kind: lsp.SymbolKindMethod, File: "server.go", Line: 12})
+	r := NewResolver(srv.Client(t))
+
+	tests := []struct {
+		query    string
+		wantName string
+		wantURI  string
+		wantCode errors.Code
+	}{
+		{query: "main.go:7", wantName: "run", wantURI: srv.URI("main.go")},
+		{query: "main.go:8:3", wantName: "Load", wantURI: srv.URI("config/config.go")},
+		{query: "main.go:6:3", wantName: "run", wantURI: srv.URI("main.go")},
+		{query: "/ws/config/config.go:10", wantName: "Load", wantURI: srv.URI("config/config.go")},
+		{query: "server.go#Stop", wantName: "Server.Stop", wantURI: srv.URI("server.go")},
+		{query: "server.go#(*Server).Stop", wantName: "Server.Stop", wantURI: srv.URI("server.go")},
+		{query: "server.go#Start", wantCode: errors.CodeAmbiguousSymbol},
+		{query: "server.go#Client.Stop", wantCode: errors.CodeSymbolNotFound},
+		{query: "main.go:99", wantCode: errors.CodeSymbolNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			sym, err := r.Resolve(t.Context(), q)
+			if tt.wantCode != "" {
+				we, ok := errors.FromError(err)
+				if !ok || we.Code != tt.wantCode {
+					t.Fatalf("err = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if sym.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", sym.Name, tt.wantName)
+			}
+			if sym.URI != tt.wantURI {
+				t.Errorf("URI = %q, want %q", sym.URI, tt.wantURI)
+			}
+		})
+	}
+
+	// The position is the symbol's name, ready for call hierarchy requests
+	q, _ := Parse("main.go:7")
+	sym, err := r.Resolve(t.Context(), q)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if sym.Position != (lsp.Position{Line: 4}) {
+		t.Errorf("Position = %+v, want line 4", sym.Position)
+	}
+}
+
+func TestResolver_FindAllPattern(t *testing.T) {
+	r, _ := newTestResolver(t)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "Server.*", want: []string{"Server.Start"}},
+		{query: "*Start", want: []string{"Server.Start", "Client.Start", "server.Start"}},
+		{query: "config.Load*", want: []string{"config.Load", "config.LoadFile"}},
+		{query: "re:^Load", want: []string{"config.Load", "config.LoadFile"}},
+		{query: `re:^config\.Load$`, want: []string{"config.Load"}},
+		{query: "server/server.go#S*", want: []string{"Start"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			syms, _, err := r.FindAll(t.Context(), q)
+			if err != nil {
+				t.Fatalf("FindAll: %v", err)
+			}
+			var got []string
+			for _, s := range syms {
+				got = append(got, s.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FindAll(%s) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_FindAllCapped(t *testing.T) {
+	srv := lsptest.NewServer("/ws")
+	for _, name := range []string{"Open", "Opener", "OpenFile", "OpenDir"} {
+		srv.Func("fs", name, "fs/fs.go", 1)
+	}
+	srv.Func("web", "NewHandler", "web/web.go", 1)
+	srv.Struct("web", "FileHandler", "web/file.go", 5)
+	srv.SymbolLimit = 3
+
+	r := NewResolver(srv.Client(t))
+	r.symbolLimit = srv.SymbolLimit
+
+	tests := []struct {
+		query      string
+		want       []string
+		wantCapped bool
+	}{
+		// Searching for "" would return only the first few symbols
+		{query: "*Handler", want: []string{"web.NewHandler", "web.FileHandler"}},
+		{query: "re:.*Handler$", want: []string{"web.NewHandler", "web.FileHandler"}},
+		{query: "Open*", want: []string{"fs.Open", "fs.Opener", "fs.OpenFile"}, wantCapped: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			syms, capped, err := r.FindAll(t.Context(), q)
+			if err != nil {
+				t.Fatalf("FindAll: %v", err)
+			}
+			var got []string
+			for _, s := range syms {
+				got = append(got, s.Name)
+			}
+			if !slices.Equal(got, tt.want) || capped != tt.wantCapped {
+				t.Errorf("FindAll(%s) = %v, %v, want %v, %v", tt.query, got, capped, tt.want, tt.wantCapped)
+			}
+		})
+	}
+}
+
+func TestSearchTerm(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "Load", want: "Load"},
+		{query: "Load*", want: "Load"},
+		{query: "*Handler", want: "Handler"},
+		{query: "Get*By?d", want: "Get"},
+		{query: "[Ss]erve*Handler", want: "Handler"},
+		{query: `Load\*`, want: "Load*"},
+		{query: "Server.*", want: "Server"},
+		{query: "re:^Load.*$", want: "Load"},
+		{query: "re:.*Handler", want: "Handler"},
+		{query: "re:(Get|Set)Value+", want: "Valu"},
+		{query: "re:^(?:New)?Server", want: "Server"},
+		{query: "re:a|b", want: ""},
+	}
+
+	for _, tt := range tests {
+		q, err := Parse(tt.query)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", tt.query, err)
+		}
+		if got := searchTerm(q); got != tt.want {
+			t.Errorf("searchTerm(%s) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestResolver_ResolveDeclared(t *testing.T) {
+	root := t.TempDir()
+	for _, f := range []string{"config/config.go", "server/server.go", "server/run.go"} {
+		path := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := lsptest.NewServer(root)
+	// Common names crowd the declaration out of capped results
+	for _, pkg := range []string{"a", "b", "c", "d"} {
+		srv.Func(pkg, "New", pkg+"/"+pkg+".go", 1)
+		srv.Method("Worker", "Run", pkg+"/"+pkg+".go", 5)
+	}
+	srv.Func("config", "New", "config/config.go", 10)
+	srv.Struct("server", "Server", "server/server.go", 3)
+	// gopls names methods after their receiver
+	srv.Add(lsptest.Symbol{Name: "(*Server).Run", Kind: lsp.SymbolKindMethod, File: "server/run.go", Line: 7})
+	srv.SymbolLimit = 3
+
+	r := NewResolver(srv.Client(t))
+	r.SetPackageFinder(func(ctx context.Context, root, pkg string) ([]string, error) {
+		if pkg == "config" {
+			return []string{filepath.Join(root, "config/config.go")}, nil
+		}
+		return nil, nil
+	})
+
+	tests := []struct {
+		query    string
+		wantName string
+		wantURI  string
+		wantCode errors.Code
+	}{
+		{query: "config.New", wantName: "config.New", wantURI: srv.URI("config/config.go")},
+		{query: "Server.Run", wantName: "Server.Run", wantURI: srv.URI("server/run.go")},
+		{query: "config.Missing", wantCode: errors.CodeSymbolNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			sym, err := r.Resolve(t.Context(), q)
+			if tt.wantCode != "" {
+				we, ok := errors.FromError(err)
+				if !ok || we.Code != tt.wantCode {
+					t.Fatalf("err = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if sym.Name != tt.wantName {
+				t.Errorf("Name = %q, want %q", sym.Name, tt.wantName)
+			}
+			if sym.URI != tt.wantURI {
+				t.Errorf("URI = %q, want %q", sym.URI, tt.wantURI)
+			}
+		})
+	}
+
+	// Without a qualifier the capped search is all there is
+	q, _ := Parse("New")
+	if _, err := r.Resolve(t.Context(), q); err == nil {
+		t.Error("Resolve(New) succeeded, want an error from the capped search")
+	}
+}
+
+func TestResolver_ResolveDeclaredRust(t *testing.T) {
+	srv := lsptest.NewServer(t.TempDir())
+	for _, file := range []string{"src/a.rs", "src/b.rs", "src/c.rs"} {
+		srv.Method("Worker", "run", file, 5)
+	}
+	srv.Struct("server", "Server", "src/server.rs", 3)
+	// rust-analyzer nests methods under their impl blocks
+	impl := srv.Add(lsptest.Symbol{Name: "impl Server", Kind: lsp.SymbolKindObject, File: "src/server.rs", Line: 7, EndLine: 12})
+	srv.Add(lsptest.Symbol{Name: "run", Container: "impl Server", Kind: lsp.SymbolKindMethod, File: "src/server.rs", Line: 8, Parent: impl})
+	trait := srv.Add(lsptest.Symbol{Name: "impl<T> Handler<T> for Server", Kind: lsp.SymbolKindObject, File: "src/server.rs", Line: 14, EndLine: 18})
+	srv.Add(lsptest.Symbol{Name: "handle", Container: "impl<T> Handler<T> for Server", Kind: lsp.SymbolKindMethod, File: "src/server.rs", Line: 15, Parent: trait})
+	srv.SymbolLimit = 3
+
+	r := NewResolver(srv.Client(t))
+	for _, tt := range []struct {
+		query    string
+		wantName string
+		wantLine int
+	}{
+		{query: "Server.run", wantName: "Server.run", wantLine: 7},
+		{query: "Server.handle", wantName: "Server.handle", wantLine: 14},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			sym, err := r.Resolve(t.Context(), q)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if sym.Name != tt.wantName || sym.Position.Line != tt.wantLine {
+				t.Errorf("got %s at line %d, want %s at line %d", sym.Name, sym.Position.Line, tt.wantName, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestResolver_ResolveCpp(t *testing.T) {
+	srv := lsptest.NewServer(t.TempDir())
+	// clangd names a member's whole scope as its container
+	srv.Method("std::vector", "push_back", "include/vector", 10)
+	srv.Method("std::deque", "push_back", "include/deque", 10)
+	srv.Method("ns::widget", "draw", "src/widget.cc", 5)
+	srv.Method("ns::detail::widget", "draw", "src/detail.cc", 5)
+	srv.Method("ns::gadget", "draw", "src/gadget.cc", 5)
+	srv.Method("ns::widget_view", "draw", "src/view.cc", 5)
+	// and nests declarations under their namespace and class
+	ns := srv.Add(lsptest.Symbol{Name: "ns", Kind: lsp.SymbolKindNamespace, File: "src/shapes.h", Line: 1, EndLine: 20})
+	widget := srv.Add(lsptest.Symbol{Name: "widget", Kind: lsp.SymbolKindClass, File: "src/shapes.h", Line: 2, EndLine: 5, Parent: ns})
+	srv.Add(lsptest.Symbol{Name: "draw", Kind: lsp.SymbolKindMethod, File: "src/shapes.h", Line: 3, Parent: widget})
+	gadget := srv.Add(lsptest.Symbol{Name: "gadget", Kind: lsp.SymbolKindClass, File: "src/shapes.h", Line: 7, EndLine: 10, Parent: ns})
+	srv.Add(lsptest.Symbol{Name: "draw", Kind: lsp.SymbolKindMethod, File: "src/shapes.h", Line: 8, Parent: gadget})
+
+	spec, _ := servers.Get("c")
+	parse := ParserFor(spec)
+	r := NewResolver(srv.Client(t))
+	tests := []struct {
+		query    string
+		wantURI  string
+		wantLine int
+		wantCode errors.Code
+	}{
+		{query: "std::vector::push_back", wantURI: srv.URI("include/vector"), wantLine: 9},
+		{query: "ns::widget::draw", wantURI: srv.URI("src/widget.cc"), wantLine: 4},
+		{query: "detail::widget::draw", wantURI: srv.URI("src/detail.cc"), wantLine: 4},
+		{query: "widget::draw", wantCode: errors.CodeAmbiguousSymbol},
+		{query: "std::list::push_back", wantCode: errors.CodeSymbolNotFound},
+		{query: "src/shapes.h#ns::gadget::draw", wantURI: srv.URI("src/shapes.h"), wantLine: 7},
+		{query: "src/shapes.h#widget::draw", wantURI: srv.URI("src/shapes.h"), wantLine: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			q, err := parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			sym, err := r.Resolve(t.Context(), q)
+			if tt.wantCode != "" {
+				we, ok := errors.FromError(err)
+				if !ok || we.Code != tt.wantCode {
+					t.Fatalf("err = %v, want code %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if sym.URI != tt.wantURI || sym.Position.Line != tt.wantLine {
+				t.Errorf("got %s:%d, want %s:%d", sym.URI, sym.Position.Line, tt.wantURI, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestImplType(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"impl Server", "Server"},
+		{"impl<T> Server<T>", "Server"},
+		{"impl Handler for Server", "Server"},
+		{"impl<T: Clone> Handler<T> for crate::net::Server<T>", "Server"},
+		{"impl fmt::Display for &mut Server", "Server"},
+		{"implement", ""},
+		{"Server", ""},
+	}
+	for _, tt := range tests {
+		got, ok := implType(tt.name)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("implType(%q) = %q, %v, want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestUTF16Column(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	src := "package main\n\ta := \"é😀\"; Load()\n"
+	if err := os.WriteFile(file, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line, column int
+		want         int
+	}{
+		{line: 1, column: 1, want: 0},
+		{line: 2, column: 2, want: 1},   // after the tab
+		{line: 2, column: 17, want: 13}, // Load: é is 2 bytes and 1 unit, 😀 is 4 bytes and 2 units
+		{line: 2, column: 99, want: 19}, // past the end of the line
+		{line: 9, column: 5, want: 4},   // past the end of the file
+	}
+	for _, tt := range tests {
+		if got := utf16Column(file, tt.line, tt.column); got != tt.want {
+			t.Errorf("utf16Column(%d, %d) = %d, want %d", tt.line, tt.column, got, tt.want)
+		}
+	}
+	if got := utf16Column("/missing.go", 1, 7); got != 6 {
+		t.Errorf("unreadable file: got %d, want 6", got)
+	}
+}
@@ -0,0 +1,94 @@
+package traverse
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// findCycles returns the strongly connected components of the graph formed
+// by edges that contain a cycle: two or more nodes, or one that calls
+// itself. Cycles are ordered by their first symbol and each lists the call
+// sites between its members in edge order.
+func findCycles(edges []output.TreeEdge) []output.Cycle {
+	succ := make(map[string][]string)
+	seen := make(map[[2]string]bool)
+	var ids []string
+	addNode := func(id string) {
+		if _, ok := succ[id]; !ok {
+			succ[id] = nil
+			ids = append(ids, id)
+		}
+	}
+	for _, e := range edges {
+		addNode(e.From)
+		addNode(e.To)
+		if !seen[[2]string{e.From, e.To}] {
+			seen[[2]string{e.From, e.To}] = true
+			succ[e.From] = append(succ[e.From], e.To)
+		}
+	}
+	sort.Strings(ids)
+
+	// Tarjan's algorithm
+	index := make(map[string]int)
+	low := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var connect func(v string)
+	connect = func(v string) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range succ[v] {
+			if _, visited := index[w]; !visited {
+				connect(w)
+				low[v] = min(low[v], low[w])
+			} else if onStack[w] {
+				low[v] = min(low[v], index[w])
+			}
+		}
+
+		if low[v] == index[v] {
+			var comp []string
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				comp = append(comp, w)
+				if w == v {
+					break
+				}
+			}
+			components = append(components, comp)
+		}
+	}
+	for _, id := range ids {
+		if _, visited := index[id]; !visited {
+			connect(id)
+		}
+	}
+
+	var cycles []output.Cycle
+	for _, comp := range components {
+		if len(comp) == 1 && !seen[[2]string{comp[0], comp[0]}] {
+			continue
+		}
+		sort.Strings(comp)
+
+		c := output.Cycle{Symbols: comp}
+		for _, e := range edges {
+			if slices.Contains(comp, e.From) && slices.Contains(comp, e.To) {
+				c.Calls = append(c.Calls, e)
+			}
+		}
+		cycles = append(cycles, c)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Symbols[0] < cycles[j].Symbols[0] })
+	return cycles
+}
@@ -0,0 +1,83 @@
+package traverse
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+func TestFindCycles(t *testing.T) {
+	edges := []output.TreeEdge{
+		{From: "main", To: "parse", Line: 1},
+		{From: "parse", To: "eval", Line: 2},
+		{From: "eval", To: "parse", Line: 3},
+		{From: "eval", To: "print", Line: 4},
+		{From: "print", To: "print", Line: 5},
+		{From: "main", To: "exit", Line: 6},
+	}
+
+	cycles := findCycles(edges)
+	if len(cycles) != 2 {
+		t.Fatalf("got %d cycles, want 2: %+v", len(cycles), cycles)
+	}
+	if got := cycles[0].Symbols; !slices.Equal(got, []string{"eval", "parse"}) {
+		t.Errorf("cycle 0 = %v, want [eval parse]", got)
+	}
+	var lines []int
+	for _, c := range cycles[0].Calls {
+		lines = append(lines, c.Line)
+	}
+	if !slices.Equal(lines, []int{2, 3}) {
+		t.Errorf("cycle 0 call lines = %v, want [2 3]", lines)
+	}
+	if got := cycles[1].Symbols; !slices.Equal(got, []string{"print"}) {
+		t.Errorf("cycle 1 = %v, want [print]", got)
+	}
+}
+
+func TestTraverser_BuildTreeCycles(t *testing.T) {
+	// parse <-> eval recurse into each other, and eval calls itself
+	srv := lsptest.NewServer("/ws")
+	main := srv.Func("main", "main", "main.go", 1)
+	parse := srv.Func("main", "parse", "parse.go", 1)
+	eval := srv.Func("main", "eval", "eval.go", 1)
+	srv.Call(main, parse, 1)
+	srv.Call(parse, eval, 2)
+	srv.Call(eval, parse, 3)
+	srv.Call(eval, eval, 4)
+
+	client := srv.Client(t)
+	item := prepare(t, client, srv, main)
+	tree, err := NewTraverser(client).BuildTree(t.Context(), item, Options{Direction: Down, FindCycles: true})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+
+	var back []string
+	for _, e := range tree.Edges {
+		if e.BackEdge {
+			back = append(back, e.From+"->"+e.To)
+		}
+	}
+	if !slices.Equal(back, []string{"ws.eval->ws.parse", "ws.eval->ws.eval"}) {
+		t.Errorf("back edges = %v, want eval->parse and eval->eval", back)
+	}
+
+	if len(tree.Cycles) != 1 || !slices.Equal(tree.Cycles[0].Symbols, []string{"ws.eval", "ws.parse"}) {
+		t.Fatalf("cycles = %+v, want one of eval and parse", tree.Cycles)
+	}
+	if n := len(tree.Cycles[0].Calls); n != 3 {
+		t.Errorf("cycle has %d calls, want 3", n)
+	}
+
+	// Without the option cycles are not computed, but back edges still are
+	tree, err = NewTraverser(client).BuildTree(t.Context(), item, Options{Direction: Down})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	if tree.Cycles != nil {
+		t.Errorf("cycles = %+v, want none unless requested", tree.Cycles)
+	}
+}
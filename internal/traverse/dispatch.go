@@ -0,0 +1,180 @@
+package traverse
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+)
+
+// Interface dispatch. The call hierarchy only reports static calls, so a
+// call through an interface method ends at the abstract method and a
+// concrete method called only through its interface appears to have no
+// callers. With Options.ExpandInterfaces the traversal follows
+// textDocument/implementation across that gap, and the calls it adds are
+// marked dynamic.
+
+// dispatchError reports interface dispatch that could not be followed
+// from an item. The static calls it was to be added to are still good.
+type dispatchError struct {
+	err error
+}
+
+func (e *dispatchError) Error() string {
+	return "expanding interfaces: " + e.err.Error()
+}
+
+func (e *dispatchError) Unwrap() error {
+	return e.err
+}
+
+// partial reports whether err left the calls fetched with it usable,
+// because only interface dispatch failed.
+func partial(err error) bool {
+	var derr *dispatchError
+	return errors.As(err, &derr)
+}
+
+// withDispatch adds the dynamic calls of item to its static calls.
+// Walking down, each call to an interface method is followed by calls to
+// its concrete implementations from the same call sites. Walking up, the
+// callers of the interface methods item implements are added. Lookups
+// that fail only lose their own dynamic calls; they are reported together
+// as a *dispatchError alongside the calls found.
+func (t *Traverser) withDispatch(ctx context.Context, item lsp.CallHierarchyItem, calls []call, dir Direction) ([]call, error) {
+	var errs []error
+	if dir == Up {
+		if item.Kind != lsp.SymbolKindMethod {
+			return calls, nil
+		}
+		abstract, err := t.implementations(ctx, item, true)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, m := range abstract {
+			incoming, err := t.client.IncomingCalls(ctx, m)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, c := range incoming {
+				calls = append(calls, call{item: c.From, ranges: c.FromRanges, dynamic: true})
+			}
+		}
+		return calls, dispatchErr(errs)
+	}
+
+	var result []call
+	for _, c := range calls {
+		result = append(result, c)
+		if c.item.Kind != lsp.SymbolKindMethod {
+			continue
+		}
+		ok, err := t.isInterfaceMethod(ctx, c.item)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		concrete, err := t.implementations(ctx, c.item, false)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		for _, impl := range concrete {
+			result = append(result, call{item: impl, ranges: c.ranges, dynamic: true})
+		}
+	}
+	return result, dispatchErr(errs)
+}
+
+// dispatchErr combines the failed lookups of withDispatch, if any.
+func dispatchErr(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &dispatchError{err: errors.Join(errs...)}
+}
+
+// implementations returns the call hierarchy items of the methods related
+// to method by textDocument/implementation: the interface methods it
+// implements when abstract is true, otherwise the concrete methods that
+// implement it. Locations that cannot be resolved are skipped, and their
+// errors returned with the items that could be.
+func (t *Traverser) implementations(ctx context.Context, method lsp.CallHierarchyItem, abstract bool) ([]lsp.CallHierarchyItem, error) {
+	locs, err := t.client.Implementation(ctx, method.URI, method.SelectionRange.Start)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []lsp.CallHierarchyItem
+	var errs []error
+	for _, loc := range locs {
+		items, err := t.client.PrepareCallHierarchy(ctx, loc.URI, loc.Range.Start)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, item := range items {
+			if itemKey(item) == itemKey(method) {
+				continue
+			}
+			isAbstract, err := t.isInterfaceMethod(ctx, item)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if isAbstract == abstract {
+				result = append(result, item)
+			}
+		}
+	}
+	return result, errors.Join(errs...)
+}
+
+// isInterfaceMethod reports whether item is declared inside an interface.
+func (t *Traverser) isInterfaceMethod(ctx context.Context, item lsp.CallHierarchyItem) (bool, error) {
+	if item.Kind != lsp.SymbolKindMethod {
+		return false, nil
+	}
+	syms, err := t.documentSymbols(ctx, item.URI)
+	if err != nil {
+		return false, err
+	}
+
+	// Servers may nest methods under their interface or list them flat
+	var inInterface func([]lsp.DocumentSymbol) bool
+	inInterface = func(syms []lsp.DocumentSymbol) bool {
+		for _, s := range syms {
+			if !s.Range.Contains(item.SelectionRange.Start) {
+				continue
+			}
+			if s.Kind == lsp.SymbolKindInterface || inInterface(s.Children) {
+				return true
+			}
+		}
+		return false
+	}
+	return inInterface(syms), nil
+}
+
+// documentSymbols returns the cached symbols of a document.
+func (t *Traverser) documentSymbols(ctx context.Context, uri string) ([]lsp.DocumentSymbol, error) {
+	t.mu.Lock()
+	syms, ok := t.symbols[uri]
+	t.mu.Unlock()
+	if ok {
+		return syms, nil
+	}
+
+	syms, err := t.client.DocumentSymbol(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	t.symbols[uri] = syms
+	t.mu.Unlock()
+	return syms, nil
+}
@@ -0,0 +1,124 @@
+package traverse
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+)
+
+// dispatchGraph builds a call through an interface:
+//
+//	main -> Formatter.Format  (interface method)
+//	JSON.Format, YAML.Format implement it
+//	JSON.Format -> marshal
+func dispatchGraph(t *testing.T) (*lsptest.Server, map[string]*lsptest.Symbol) {
+	t.Helper()
+	srv := lsptest.NewServer("/ws")
+	syms := map[string]*lsptest.Symbol{
+		"main":      srv.Func("main", "main", "main.go", 1),
+		"Formatter": srv.Add(lsptest.Symbol{Name: "Formatter", Container: "output", Kind: lsp.SymbolKindInterface, File: "format.go", Line: 3, EndLine: 5}),
+		"Format":    srv.Method("Formatter", "Format", "format.go", 4),
+		"JSON":      srv.Method("JSON", "Format", "json.go", 3),
+		"YAML":      srv.Method("YAML", "Format", "yaml.go", 3),
+		"marshal":   srv.Func("output", "marshal", "json.go", 10),
+	}
+	srv.Implements(syms["JSON"], syms["Format"])
+	srv.Implements(syms["YAML"], syms["Format"])
+	srv.Call(syms["main"], syms["Format"], 1)
+	srv.Call(syms["JSON"], syms["marshal"], 4)
+	return srv, syms
+}
+
+func TestTraverser_ExpandInterfacesDown(t *testing.T) {
+	srv, syms := dispatchGraph(t)
+	client := srv.Client(t)
+	item := prepare(t, client, srv, syms["main"])
+
+	static, err := NewTraverser(client).GetCallees(t.Context(), item, Options{})
+	if err != nil {
+		t.Fatalf("GetCallees: %v", err)
+	}
+	if got := symbolNames(static.Calls); !slices.Equal(got, []string{"Format"}) {
+		t.Errorf("static callees = %v, want [Format]", got)
+	}
+
+	tree, err := NewTraverser(client).BuildTree(t.Context(), item, Options{Direction: Down, ExpandInterfaces: true})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+
+	// The static edge to the interface method stays; each implementation
+	// is reached dynamically from the same call site and explored further
+	var dynamic []string
+	var reachedMarshal bool
+	for _, e := range tree.Edges {
+		if e.Dynamic {
+			dynamic = append(dynamic, tree.Nodes[e.To].File)
+			if e.From != "ws.main" || e.Line != 1 {
+				t.Errorf("dynamic edge %+v, want from ws.main at line 1", e)
+			}
+		}
+		if e.To == "ws.marshal" {
+			reachedMarshal = true
+		}
+	}
+	if !slices.Equal(dynamic, []string{"/ws/json.go", "/ws/yaml.go"}) {
+		t.Errorf("dynamic edges to %v, want json.go and yaml.go", dynamic)
+	}
+	if !reachedMarshal {
+		t.Error("traversal did not continue into JSON.Format")
+	}
+}
+
+func TestTraverser_ExpandInterfacesUp(t *testing.T) {
+	srv, syms := dispatchGraph(t)
+	client := srv.Client(t)
+	item := prepare(t, client, srv, syms["JSON"])
+
+	static, err := NewTraverser(client).GetCallers(t.Context(), item, Options{Direction: Up})
+	if err != nil {
+		t.Fatalf("GetCallers: %v", err)
+	}
+	if len(static.Calls) != 0 {
+		t.Errorf("static callers = %v, want none", symbolNames(static.Calls))
+	}
+
+	got, err := NewTraverser(client).GetCallers(t.Context(), item, Options{Direction: Up, ExpandInterfaces: true})
+	if err != nil {
+		t.Fatalf("GetCallers: %v", err)
+	}
+	if len(got.Calls) != 1 || got.Calls[0].Symbol != "main" || !got.Calls[0].Dynamic {
+		t.Errorf("callers = %+v, want main through dispatch", got.Calls)
+	}
+}
+
+func TestTraverser_ExpandInterfacesFailure(t *testing.T) {
+	srv, syms := dispatchGraph(t)
+	srv.Fail("textDocument/implementation", lsp.CodeInternalError)
+	client := srv.Client(t)
+	item := prepare(t, client, srv, syms["main"])
+
+	// The failed lookup loses only the dynamic calls: the static edge to
+	// the interface method stays and the error is kept on main
+	tree, err := NewTraverser(client).BuildTree(t.Context(), item, Options{Direction: Down, ExpandInterfaces: true})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+	var static, dynamic int
+	for _, e := range tree.Edges {
+		if e.Dynamic {
+			dynamic++
+		} else if e.From == "ws.main" {
+			static++
+		}
+	}
+	if static != 1 || dynamic != 0 {
+		t.Errorf("%d static and %d dynamic edges from main, want 1 and 0", static, dynamic)
+	}
+	if main := tree.Nodes["ws.main"]; !strings.Contains(main.Error, "expanding interfaces") {
+		t.Errorf("main error = %q, want the failed dispatch", main.Error)
+	}
+}
@@ -0,0 +1,272 @@
+package traverse
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// BuildGraph builds the call graph of the functions and methods declared
+// in files, given as document URIs. Every declared function is a node
+// whether or not anything calls it; functions declared elsewhere, such as
+// in the standard library, become nodes when something in files calls
+// them. Edges run from caller to callee, one per call site, and refer to
+// nodes by the IDs described in identify.
+//
+// MaxDepth is ignored. MaxNodes bounds the functions whose calls are
+// fetched and MaxEdges the calls reported; functions left unexpanded when
+// a budget runs out are marked Truncated. A file or function whose symbols
+// or calls cannot be fetched is listed in the summary and the rest of the
+// graph is still built.
+func (t *Traverser) BuildGraph(ctx context.Context, files []string, opts Options) (*output.GraphResponse, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	g := t.buildGraph(ctx, files, opts)
+
+	ids := t.assignIDs(g.items)
+	byID := make(map[string]output.GraphNode, len(g.nodes))
+	for key, n := range g.nodes {
+		n.ID, n.Name = ids[key].ID, ids[key].Name
+		byID[n.ID] = n
+	}
+	edges := g.edges
+	for i := range edges {
+		edges[i].From = ids[edges[i].From].ID
+		edges[i].To = ids[edges[i].To].ID
+	}
+
+	return &output.GraphResponse{
+		Nodes: byID,
+		Edges: edges,
+		Summary: output.GraphSummary{
+			Files:      g.files,
+			Functions:  len(g.declared),
+			NodeCount:  len(byID),
+			EdgeCount:  len(edges),
+			Truncated:  g.stopped != "",
+			StopReason: g.stopped,
+			Errors:     g.failed,
+		},
+	}, nil
+}
+
+// moduleGraph is a call graph built by buildGraph, keyed by item key.
+type moduleGraph struct {
+	items    map[string]lsp.CallHierarchyItem
+	nodes    map[string]output.GraphNode // without ID and Name
+	declared []string                    // keys of the functions declared in the files, in order
+	edges    []output.TreeEdge           // From and To are item keys
+	files    int                         // files scanned
+	stopped  string
+	failed   []string
+}
+
+// buildGraph does the work of BuildGraph, leaving opts.Timeout to the caller.
+func (t *Traverser) buildGraph(ctx context.Context, files []string, opts Options) *moduleGraph {
+	opts.Direction = Down
+
+	if opts.ExcludeTests {
+		var kept []string
+		for _, uri := range files {
+			if !output.IsTestFile(lsp.URIToPath(uri)) {
+				kept = append(kept, uri)
+			}
+		}
+		files = kept
+	}
+
+	g := &moduleGraph{
+		items: make(map[string]lsp.CallHierarchyItem),
+		nodes: make(map[string]output.GraphNode),
+		files: len(files),
+	}
+	add := func(item lsp.CallHierarchyItem) (string, bool) {
+		key := itemKey(item)
+		if _, ok := g.items[key]; ok {
+			return key, false
+		}
+		g.items[key] = item
+		g.nodes[key] = t.graphNode(item)
+		return key, true
+	}
+
+	// Declared functions, in file and declaration order
+	decls, errs := t.declarations(ctx, files, opts)
+	var declared []lsp.CallHierarchyItem
+	for i, fileItems := range decls {
+		if err := errs[i]; err != nil {
+			if ctx.Err() != nil {
+				g.stopped = StopTimeout
+			} else {
+				g.failed = append(g.failed, fmt.Sprintf("%s: %v", lsp.URIToPath(files[i]), err))
+			}
+			continue
+		}
+		for _, item := range fileItems {
+			if key, isNew := add(item); isNew {
+				declared = append(declared, item)
+				g.declared = append(g.declared, key)
+			}
+		}
+	}
+
+	truncate := func(items []lsp.CallHierarchyItem, reason string) {
+		g.stopped = reason
+		for _, item := range items {
+			key := itemKey(item)
+			n := g.nodes[key]
+			n.Truncated = true
+			g.nodes[key] = n
+		}
+	}
+
+	expand := declared
+	switch {
+	case g.stopped != "":
+		truncate(expand, g.stopped)
+		expand = nil
+	case opts.MaxNodes > 0 && len(expand) > opts.MaxNodes:
+		truncate(expand[opts.MaxNodes:], StopMaxNodes)
+		expand = expand[:opts.MaxNodes]
+	}
+
+	level, errs := t.fetchLevel(ctx, expand, opts)
+
+	reported := 0
+	for i, calls := range level {
+		item := expand[i]
+		key := itemKey(item)
+		if err := errs[i]; err != nil {
+			if ctx.Err() != nil && !partial(err) {
+				truncate([]lsp.CallHierarchyItem{item}, StopTimeout)
+				continue
+			}
+			n := g.nodes[key]
+			n.Error = err.Error()
+			g.nodes[key] = n
+			g.failed = append(g.failed, fmt.Sprintf("%s: %v", item.Name, err))
+			if !partial(err) {
+				continue
+			}
+		}
+
+		for _, c := range calls {
+			if opts.ExcludeTests && output.IsTestFile(lsp.URIToPath(c.item.URI)) {
+				continue
+			}
+			if opts.ExcludeStdlib && t.isStdlib(c.item.URI) {
+				continue
+			}
+
+			if opts.MaxEdges > 0 && reported >= opts.MaxEdges {
+				truncate([]lsp.CallHierarchyItem{item}, StopMaxEdges)
+				break
+			}
+			reported++
+
+			callee, _ := add(c.item)
+			for _, r := range c.ranges {
+				g.edges = append(g.edges, output.TreeEdge{
+					From:    key,
+					To:      callee,
+					File:    lsp.URIToPath(item.URI),
+					Line:    r.Start.Line + 1,
+					Dynamic: c.dynamic,
+				})
+			}
+		}
+	}
+	return g
+}
+
+// declarations returns the call hierarchy items of the functions and
+// methods declared in each file, in declaration order. Results and errors
+// are indexed like files.
+func (t *Traverser) declarations(ctx context.Context, files []string, opts Options) ([][]lsp.CallHierarchyItem, []error) {
+	results := make([][]lsp.CallHierarchyItem, len(files))
+	errs := make([]error, len(files))
+	sem := make(chan struct{}, opts.concurrency())
+
+	var wg sync.WaitGroup
+	for i, uri := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = t.declared(ctx, uri)
+		}()
+	}
+	wg.Wait()
+
+	return results, errs
+}
+
+// declared returns the call hierarchy items of the functions and methods
+// declared in one file.
+func (t *Traverser) declared(ctx context.Context, uri string) ([]lsp.CallHierarchyItem, error) {
+	syms, err := t.documentSymbols(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+
+	// Servers may nest methods under their type or list them flat
+	var funcs []lsp.DocumentSymbol
+	var collect func([]lsp.DocumentSymbol)
+	collect = func(syms []lsp.DocumentSymbol) {
+		for _, s := range syms {
+			switch s.Kind {
+			case lsp.SymbolKindFunction, lsp.SymbolKindMethod, lsp.SymbolKindConstructor:
+				funcs = append(funcs, s)
+			}
+			collect(s.Children)
+		}
+	}
+	collect(syms)
+
+	var result []lsp.CallHierarchyItem
+	for _, s := range funcs {
+		items, err := t.client.PrepareCallHierarchy(ctx, uri, s.SelectionRange.Start)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, items...)
+	}
+	return result, nil
+}
+
+// graphNode describes and classifies item. ID and Name are left to the caller.
+func (t *Traverser) graphNode(item lsp.CallHierarchyItem) output.GraphNode {
+	file := lsp.URIToPath(item.URI)
+	kind := "function"
+	if item.Kind == lsp.SymbolKindMethod {
+		kind = "method"
+	}
+	stdlib := t.isStdlib(item.URI)
+	rel, err := filepath.Rel(t.client.Root(), file)
+	inWorkspace := err == nil && !strings.HasPrefix(rel, "..")
+
+	return output.GraphNode{
+		Kind:     kind,
+		Package:  t.qualifier(item),
+		File:     file,
+		Line:     item.Range.Start.Line + 1,
+		Test:     output.IsTestFile(file),
+		Stdlib:   stdlib,
+		External: !stdlib && !inWorkspace,
+	}
+}
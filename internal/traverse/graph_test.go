@@ -0,0 +1,122 @@
+package traverse
+
+import (
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+func TestTraverser_BuildGraph(t *testing.T) {
+	srv, syms := callGraph(t)
+	printFn := srv.Func("fmt", "Println", "../usr/local/go/src/fmt/print.go", 10)
+	do := srv.Func("lib", "Do", "../deps/lib/lib.go", 3)
+	srv.Call(syms["parse"], printFn, 8)
+	srv.Call(syms["serve"], do, 4)
+
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	var files []string
+	for _, f := range []string{"main.go", "load.go", "serve.go", "main_test.go"} {
+		files = append(files, srv.URI(f))
+	}
+
+	graph, err := tr.BuildGraph(t.Context(), files, Options{})
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+
+	if graph.Summary.Functions != 6 || graph.Summary.NodeCount != 8 || graph.Summary.EdgeCount != 8 {
+		t.Errorf("functions, nodes, edges = %d, %d, %d, want 6, 8, 8",
+			graph.Summary.Functions, graph.Summary.NodeCount, graph.Summary.EdgeCount)
+	}
+
+	byName := make(map[string]output.GraphNode)
+	for id, n := range graph.Nodes {
+		if n.ID != id {
+			t.Errorf("node %s keyed as %s", n.ID, id)
+		}
+		byName[n.Name] = n
+	}
+	for name, want := range map[string][3]bool{ // test, stdlib, external
+		"ws.run":      {false, false, false},
+		"ws.TestRun":  {true, false, false},
+		"fmt.Println": {false, true, false},
+		"lib.Do":      {false, false, true},
+	} {
+		n, ok := byName[name]
+		if !ok {
+			t.Errorf("missing node %s", name)
+			continue
+		}
+		if got := [3]bool{n.Test, n.Stdlib, n.External}; got != want {
+			t.Errorf("%s test, stdlib, external = %v, want %v", name, got, want)
+		}
+	}
+	if n := byName["ws.run"]; n.Package != "ws" || n.Kind != "function" {
+		t.Errorf("run package, kind = %q, %q, want ws, function", n.Package, n.Kind)
+	}
+
+	found := false
+	for _, e := range graph.Edges {
+		if e.From == "ws.serve" && e.To == byName["lib.Do"].ID {
+			found = e.File == "/ws/serve.go" && e.Line == 4
+		}
+	}
+	if !found {
+		t.Errorf("missing serve -> lib.Do call at serve.go:4 in %v", graph.Edges)
+	}
+}
+
+func TestTraverser_BuildGraphFilters(t *testing.T) {
+	srv, syms := callGraph(t)
+	printFn := srv.Func("fmt", "Println", "../usr/local/go/src/fmt/print.go", 10)
+	srv.Call(syms["parse"], printFn, 8)
+
+	client := srv.Client(t)
+	client.SetRetryPolicy(lsp.RetryPolicy{})
+	tr := NewTraverser(client)
+	var files []string
+	for _, f := range []string{"main.go", "load.go", "serve.go", "main_test.go"} {
+		files = append(files, srv.URI(f))
+	}
+
+	graph, err := tr.BuildGraph(t.Context(), files, Options{ExcludeTests: true, ExcludeStdlib: true})
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	if graph.Summary.Files != 3 || graph.Summary.NodeCount != 5 {
+		t.Errorf("files, nodes = %d, %d, want 3, 5", graph.Summary.Files, graph.Summary.NodeCount)
+	}
+
+	// A budget keeps every declared function but expands only some
+	graph, err = tr.BuildGraph(t.Context(), files, Options{MaxNodes: 2})
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	if graph.Summary.StopReason != StopMaxNodes || graph.Summary.Functions != 6 {
+		t.Errorf("stop reason, functions = %q, %d, want %q, 6", graph.Summary.StopReason, graph.Summary.Functions, StopMaxNodes)
+	}
+	truncated := 0
+	for _, n := range graph.Nodes {
+		if n.Truncated {
+			truncated++
+		}
+	}
+	if truncated != 4 {
+		t.Errorf("%d nodes truncated, want 4", truncated)
+	}
+
+	// A failing function is marked and the rest of the graph built
+	srv.FailCalls(syms["load"], lsp.CodeInternalError)
+	graph, err = tr.BuildGraph(t.Context(), files, Options{})
+	if err != nil {
+		t.Fatalf("BuildGraph: %v", err)
+	}
+	if graph.Nodes["ws.load"].Error == "" || len(graph.Summary.Errors) != 1 {
+		t.Errorf("load error = %q, summary errors = %v", graph.Nodes["ws.load"].Error, graph.Summary.Errors)
+	}
+	if graph.Summary.EdgeCount != 6 {
+		t.Errorf("EdgeCount = %d, want 6", graph.Summary.EdgeCount)
+	}
+}
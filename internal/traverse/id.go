@@ -0,0 +1,113 @@
+package traverse
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+)
+
+// identity names a call hierarchy item independently of where a traversal
+// found it.
+type identity struct {
+	ID   string // Qualifier, receiver and name, e.g. "github.com/x/server.Server.Start"
+	Name string // The same with the qualifier shortened to its last element
+}
+
+// identify derives the identity of item. The qualifier is the package path
+// when the server reports one in the item's detail (gopls uses
+// "pkg/path • file.go"), otherwise the item's directory relative to the
+// workspace root. Methods declared with a Go receiver include its type.
+func (t *Traverser) identify(item lsp.CallHierarchyItem) identity {
+	qual := t.qualifier(item)
+	name := item.Name
+	if item.Kind == lsp.SymbolKindMethod {
+		file := lsp.URIToPath(item.URI)
+		if line, err := t.extractor.ExtractLine(file, item.SelectionRange.Start.Line+1); err == nil {
+			if recv := goReceiver(line); recv != "" {
+				name = recv + "." + name
+			}
+		}
+	}
+	return identity{
+		ID:   qual + "." + name,
+		Name: path.Base(qual) + "." + name,
+	}
+}
+
+// qualifier returns the package path or directory that scopes item.
+func (t *Traverser) qualifier(item lsp.CallHierarchyItem) string {
+	if pkg, _, ok := strings.Cut(item.Detail, " • "); ok && pkg != "" {
+		return pkg
+	}
+
+	root := t.client.Root()
+	dir := filepath.Dir(lsp.URIToPath(item.URI))
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(dir)
+	}
+	if rel == "." {
+		return filepath.Base(root)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// goReceiver returns the receiver type of a Go method declaration line,
+// without pointer or type parameters, or "" if line is not one.
+func goReceiver(line string) string {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), "func (")
+	if !ok {
+		return ""
+	}
+	recv, _, ok := strings.Cut(rest, ")")
+	if !ok {
+		return ""
+	}
+	recv, _, _ = strings.Cut(recv, "[")
+	fields := strings.Fields(recv)
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(fields[len(fields)-1], "*")
+}
+
+// assignIDs returns the identity of each item, keyed like items. Items that
+// share an ID, such as same-named functions under different build tags,
+// are told apart by the file and line of their declaration.
+func (t *Traverser) assignIDs(items map[string]lsp.CallHierarchyItem) map[string]identity {
+	ids := make(map[string]identity, len(items))
+	byID := make(map[string][]string)
+	byName := make(map[string]int)
+	for key, item := range items {
+		id := t.identify(item)
+		ids[key] = id
+		byID[id.ID] = append(byID[id.ID], key)
+	}
+
+	for _, keys := range byID {
+		if len(keys) < 2 {
+			continue
+		}
+		for _, key := range keys {
+			item := items[key]
+			at := fmt.Sprintf("@%s:%d", filepath.Base(lsp.URIToPath(item.URI)), item.SelectionRange.Start.Line+1)
+			id := ids[key]
+			ids[key] = identity{ID: id.ID + at, Name: id.Name + at}
+		}
+	}
+
+	// Short names that still clash, e.g. two packages named config, fall
+	// back to the full ID
+	for _, id := range ids {
+		byName[id.Name]++
+	}
+	for key, id := range ids {
+		if byName[id.Name] > 1 {
+			ids[key] = identity{ID: id.ID, Name: id.ID}
+		}
+	}
+	return ids
+}
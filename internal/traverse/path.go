@@ -0,0 +1,265 @@
+package traverse
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// DefaultPathLength bounds the paths FindPaths looks for when
+// Options.MaxDepth is unset.
+const DefaultPathLength = 10
+
+// exploredGraph is the part of the call graph explored by a search. Edges run
+// from caller to callee whichever direction they were discovered in.
+type exploredGraph struct {
+	items map[string]lsp.CallHierarchyItem
+	out   map[string][]string // callee keys by caller key, in discovery order
+	in    map[string][]string // caller keys by callee key
+	sites map[[2]string][]lsp.Range
+}
+
+func newExploredGraph() *exploredGraph {
+	return &exploredGraph{
+		items: make(map[string]lsp.CallHierarchyItem),
+		out:   make(map[string][]string),
+		in:    make(map[string][]string),
+		sites: make(map[[2]string][]lsp.Range),
+	}
+}
+
+// add records an item and returns its key.
+func (g *exploredGraph) add(item lsp.CallHierarchyItem) string {
+	key := itemKey(item)
+	if _, ok := g.items[key]; !ok {
+		g.items[key] = item
+	}
+	return key
+}
+
+// edge records that caller calls callee at ranges. An edge found from
+// both ends is kept once.
+func (g *exploredGraph) edge(caller, callee lsp.CallHierarchyItem, ranges []lsp.Range) {
+	from, to := g.add(caller), g.add(callee)
+	e := [2]string{from, to}
+	if _, ok := g.sites[e]; ok {
+		return
+	}
+	g.sites[e] = ranges
+	g.out[from] = append(g.out[from], to)
+	g.in[to] = append(g.in[to], from)
+}
+
+// paths returns up to n simple paths from one key to another of at most
+// maxLen calls, shortest first. Each path lists the keys it passes through.
+func (g *exploredGraph) paths(from, to string, maxLen, n int) [][]string {
+	// Distance to the sink bounds how far a partial path can still go
+	dist := map[string]int{to: 0}
+	queue := []string{to}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for _, caller := range g.in[k] {
+			if _, ok := dist[caller]; !ok {
+				dist[caller] = dist[k] + 1
+				queue = append(queue, caller)
+			}
+		}
+	}
+
+	var found [][]string
+	path := []string{from}
+	onPath := map[string]bool{from: true}
+
+	var extend func(at string, remaining int)
+	extend = func(at string, remaining int) {
+		if len(found) >= n {
+			return
+		}
+		if remaining == 0 {
+			if at == to {
+				found = append(found, slices.Clone(path))
+			}
+			return
+		}
+		for _, next := range g.out[at] {
+			d, ok := dist[next]
+			if !ok || d > remaining-1 || (onPath[next] && next != to) || (next == to && remaining > 1) {
+				continue
+			}
+			path = append(path, next)
+			onPath[next] = true
+			extend(next, remaining-1)
+			onPath[next] = false
+			path = path[:len(path)-1]
+		}
+	}
+
+	// Iterative deepening yields paths in order of length
+	for length := 1; length <= maxLen && len(found) < n; length++ {
+		extend(from, length)
+	}
+	return found
+}
+
+// searchSide is one end of a bidirectional search.
+type searchSide struct {
+	dir      Direction
+	frontier []lsp.CallHierarchyItem
+	depth    int
+	visited  *visitedSet
+}
+
+// FindPaths returns up to n of the shortest call paths from one item to
+// another. It searches outward from from along outgoing calls and from to
+// along incoming calls, always growing the side with the smaller frontier,
+// until n paths are known or the two sides have covered opts.MaxDepth
+// calls between them.
+//
+// Every path no longer than the combined depth of the two sides is in the
+// explored graph, so paths are exact and shortest first unless a budget
+// runs out, in which case the paths found so far are returned along with
+// the reason. Items whose calls cannot be fetched are left out of the
+// search and listed in the summary.
+func (t *Traverser) FindPaths(ctx context.Context, from, to lsp.CallHierarchyItem, n int, opts Options) (*output.PathResponse, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	maxLen := opts.MaxDepth
+	if maxLen <= 0 {
+		maxLen = DefaultPathLength
+	}
+	n = max(n, 1)
+
+	g := newExploredGraph()
+	source, sink := g.add(from), g.add(to)
+	fwd := &searchSide{dir: Down, frontier: []lsp.CallHierarchyItem{from}, visited: newVisitedSet()}
+	bwd := &searchSide{dir: Up, frontier: []lsp.CallHierarchyItem{to}, visited: newVisitedSet()}
+	fwd.visited.add(source)
+	bwd.visited.add(sink)
+
+	var stopped string
+	var failed []string
+	expanded, edges := 0, 0
+	limited := false
+
+	for len(g.paths(source, sink, fwd.depth+bwd.depth, n)) < n {
+		if fwd.depth+bwd.depth >= maxLen {
+			limited = len(fwd.frontier) > 0 && len(bwd.frontier) > 0
+			break
+		}
+
+		s := fwd
+		if len(fwd.frontier) == 0 || (len(bwd.frontier) > 0 && len(bwd.frontier) < len(fwd.frontier)) {
+			s = bwd
+		}
+		if len(s.frontier) == 0 {
+			break // the graph between them is fully explored
+		}
+		if ctx.Err() != nil {
+			stopped = StopTimeout
+			break
+		}
+
+		frontier := s.frontier
+		if opts.MaxNodes > 0 && expanded+len(frontier) > opts.MaxNodes {
+			frontier = frontier[:opts.MaxNodes-expanded]
+			stopped = StopMaxNodes
+		}
+		expanded += len(frontier)
+
+		sideOpts := opts
+		sideOpts.Direction = s.dir
+		level, errs := t.fetchLevel(ctx, frontier, sideOpts)
+
+		var next []lsp.CallHierarchyItem
+		for i, calls := range level {
+			item := frontier[i]
+			if err := errs[i]; err != nil && !partial(err) {
+				switch {
+				case ctx.Err() != nil:
+					stopped = StopTimeout
+				case s.depth == 0:
+					return nil, err
+				default:
+					failed = append(failed, fmt.Sprintf("%s: %v", item.Name, err))
+				}
+				continue
+			} else if err != nil {
+				// Only interface dispatch failed; the static calls stand
+				failed = append(failed, fmt.Sprintf("%s: %v", item.Name, err))
+			}
+
+			for _, c := range calls {
+				key := itemKey(c.item)
+				endpoint := key == source || key == sink
+				if !endpoint && opts.ExcludeTests && output.IsTestFile(lsp.URIToPath(c.item.URI)) {
+					continue
+				}
+				if !endpoint && opts.ExcludeStdlib && t.isStdlib(c.item.URI) {
+					continue
+				}
+
+				if opts.MaxEdges > 0 && edges >= opts.MaxEdges {
+					stopped = StopMaxEdges
+					break
+				}
+				edges++
+
+				if s.dir == Down {
+					g.edge(item, c.item, c.ranges)
+				} else {
+					g.edge(c.item, item, c.ranges)
+				}
+				if s.visited.add(key) {
+					next = append(next, c.item)
+				}
+			}
+		}
+		s.frontier = next
+		s.depth++
+
+		if stopped != "" {
+			break
+		}
+	}
+
+	paths := g.paths(source, sink, maxLen, n)
+	ids := t.assignIDs(g.items)
+
+	resp := &output.PathResponse{
+		Paths: make([]output.CallPath, 0, len(paths)),
+		Summary: output.PathSummary{
+			NodesExplored: expanded,
+			Truncated:     stopped != "" || (limited && len(paths) < n),
+			StopReason:    stopped,
+			Errors:        failed,
+		},
+	}
+	for _, keys := range paths {
+		p := output.CallPath{Length: len(keys) - 1}
+		for i, k := range keys {
+			p.Symbols = append(p.Symbols, ids[k].Name)
+			if i == 0 {
+				continue
+			}
+			hop := output.PathHop{From: ids[keys[i-1]].Name, To: ids[k].Name}
+			if ranges := g.sites[[2]string{keys[i-1], k}]; len(ranges) > 0 {
+				hop.File = lsp.URIToPath(g.items[keys[i-1]].URI)
+				hop.Line = ranges[0].Start.Line + 1
+			}
+			p.Hops = append(p.Hops, hop)
+		}
+		resp.Paths = append(resp.Paths, p)
+	}
+	resp.Summary.Count = len(resp.Paths)
+	if len(resp.Paths) > 0 {
+		resp.Summary.Shortest = resp.Paths[0].Length
+	}
+	return resp, nil
+}
@@ -0,0 +1,137 @@
+package traverse
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+)
+
+// pathGraph builds two routes from main to query, one a call longer:
+//
+//	main -> serve -> handle -> query
+//	main -> serve -> handle -> cache -> query
+//	main -> admin -> query
+//	TestQuery -> query
+func pathGraph(t *testing.T) (*lsptest.Server, map[string]*lsptest.Symbol) {
+	t.Helper()
+	srv := lsptest.NewServer("/ws")
+	syms := map[string]*lsptest.Symbol{}
+	for i, name := range []string{"main", "serve", "handle", "cache", "admin", "query"} {
+		syms[name] = srv.Func("main", name, "main.go", 10*(i+1))
+	}
+	syms["TestQuery"] = srv.Func("main", "TestQuery", "main_test.go", 3)
+	srv.Call(syms["main"], syms["serve"], 11)
+	srv.Call(syms["main"], syms["admin"], 12)
+	srv.Call(syms["serve"], syms["handle"], 21)
+	srv.Call(syms["handle"], syms["query"], 31)
+	srv.Call(syms["handle"], syms["cache"], 32)
+	srv.Call(syms["cache"], syms["query"], 41)
+	srv.Call(syms["admin"], syms["query"], 51)
+	srv.Call(syms["TestQuery"], syms["query"], 4)
+	return srv, syms
+}
+
+func TestTraverser_FindPaths(t *testing.T) {
+	srv, syms := pathGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	from := prepare(t, client, srv, syms["main"])
+	to := prepare(t, client, srv, syms["query"])
+
+	tests := []struct {
+		name string
+		n    int
+		opts Options
+		want [][]string
+	}{
+		{name: "shortest", n: 1, want: [][]string{{"ws.main", "ws.admin", "ws.query"}}},
+		{name: "all", n: 5, want: [][]string{
+			{"ws.main", "ws.admin", "ws.query"},
+			{"ws.main", "ws.serve", "ws.handle", "ws.query"},
+			{"ws.main", "ws.serve", "ws.handle", "ws.cache", "ws.query"},
+		}},
+		{name: "max depth", n: 5, opts: Options{MaxDepth: 3}, want: [][]string{
+			{"ws.main", "ws.admin", "ws.query"},
+			{"ws.main", "ws.serve", "ws.handle", "ws.query"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tr.FindPaths(t.Context(), from, to, tt.n, tt.opts)
+			if err != nil {
+				t.Fatalf("FindPaths: %v", err)
+			}
+			var got [][]string
+			for _, p := range resp.Paths {
+				got = append(got, p.Symbols)
+			}
+			if !slices.EqualFunc(got, tt.want, slices.Equal) {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+			if resp.Summary.Count != len(tt.want) || resp.Summary.Shortest != 2 {
+				t.Errorf("summary = %+v", resp.Summary)
+			}
+		})
+	}
+}
+
+func TestTraverser_FindPathsHops(t *testing.T) {
+	srv, syms := pathGraph(t)
+	client := srv.Client(t)
+	from := prepare(t, client, srv, syms["serve"])
+	to := prepare(t, client, srv, syms["query"])
+
+	resp, err := NewTraverser(client).FindPaths(t.Context(), from, to, 1, Options{})
+	if err != nil {
+		t.Fatalf("FindPaths: %v", err)
+	}
+	if len(resp.Paths) != 1 {
+		t.Fatalf("got %d paths, want 1", len(resp.Paths))
+	}
+	hops := resp.Paths[0].Hops
+	if len(hops) != 2 {
+		t.Fatalf("hops = %+v, want 2", hops)
+	}
+	// Each hop is located at the call site in the caller
+	if h := hops[1]; h.From != "ws.handle" || h.To != "ws.query" || h.File != "/ws/main.go" || h.Line != 31 {
+		t.Errorf("hop = %+v, want ws.handle -> ws.query at /ws/main.go:31", h)
+	}
+}
+
+func TestTraverser_FindPathsNone(t *testing.T) {
+	srv, syms := pathGraph(t)
+	client := srv.Client(t)
+	client.SetRetryPolicy(lsp.RetryPolicy{})
+	from := prepare(t, client, srv, syms["query"])
+	to := prepare(t, client, srv, syms["main"])
+
+	// query calls nothing, so there is no path back to main
+	resp, err := NewTraverser(client).FindPaths(t.Context(), from, to, 3, Options{})
+	if err != nil {
+		t.Fatalf("FindPaths: %v", err)
+	}
+	if len(resp.Paths) != 0 || resp.Summary.Truncated {
+		t.Errorf("got %+v, want no paths and a complete search", resp)
+	}
+}
+
+func TestTraverser_FindPathsBudget(t *testing.T) {
+	srv, syms := pathGraph(t)
+	client := srv.Client(t)
+	from := prepare(t, client, srv, syms["main"])
+	to := prepare(t, client, srv, syms["query"])
+
+	resp, err := NewTraverser(client).FindPaths(t.Context(), from, to, 5, Options{MaxNodes: 2})
+	if err != nil {
+		t.Fatalf("FindPaths: %v", err)
+	}
+	if resp.Summary.StopReason != StopMaxNodes || !resp.Summary.Truncated {
+		t.Errorf("summary = %+v, want stopped by %s", resp.Summary, StopMaxNodes)
+	}
+	if resp.Summary.NodesExplored != 2 {
+		t.Errorf("NodesExplored = %d, want 2", resp.Summary.NodesExplored)
+	}
+}
@@ -3,7 +3,11 @@ package traverse
 
 import (
 	"context"
+	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/jasonmoo/wildcat/internal/lsp"
 	"github.com/jasonmoo/wildcat/internal/output"
@@ -15,6 +19,17 @@ type Direction int
 const (
 	Up   Direction = iota // Callers (incoming calls)
 	Down                  // Callees (outgoing calls)
+	Both                  // Callers and callees; BuildTree only
+)
+
+// DefaultConcurrency bounds in-flight LSP requests when Options.Concurrency is unset.
+const DefaultConcurrency = 8
+
+// Reasons a traversal stopped before exploring everything within MaxDepth.
+const (
+	StopMaxNodes = "max_nodes" // Options.MaxNodes items were expanded
+	StopMaxEdges = "max_edges" // Options.MaxEdges calls were reported
+	StopTimeout  = "timeout"   // Options.Timeout or the context expired
 )
 
 // Options configures traversal behavior.
@@ -23,6 +38,29 @@ type Options struct {
 	MaxDepth      int
 	ExcludeTests  bool
 	ExcludeStdlib bool
+	Concurrency   int // Maximum concurrent LSP requests (default DefaultConcurrency)
+
+	// ExpandInterfaces follows calls through interface methods to their
+	// implementations and back; see withDispatch.
+	ExpandInterfaces bool
+
+	// FindCycles makes BuildTree report the recursive cycles among the
+	// explored nodes; see findCycles.
+	FindCycles bool
+
+	// Budgets. When one runs out the traversal stops and returns what it
+	// has found, marking the items it did not expand. Zero means unlimited.
+	MaxNodes int           // Maximum items expanded
+	MaxEdges int           // Maximum calls reported
+	Timeout  time.Duration // Maximum wall-clock time
+}
+
+// concurrency returns the effective request limit.
+func (o Options) concurrency() int {
+	if o.Concurrency > 0 {
+		return o.Concurrency
+	}
+	return DefaultConcurrency
 }
 
 // CallInfo contains information about a call site.
@@ -33,12 +71,36 @@ type CallInfo struct {
 	LineEnd    int
 	CallRanges []lsp.Range // Where the calls happen
 	InTest     bool
+	Dynamic    bool // Reached through interface dispatch, not a static call
+
+	// How the symbol was reached: its distance from the target (1 = direct)
+	// and the symbols connecting them, in call order. For callers the chain
+	// runs from this symbol to the target, for callees from the target to
+	// this symbol. The chain is the first path found, which is a shortest one.
+	Depth int
+	Chain []string
+
+	// Why this symbol's own calls are missing. Both are empty when it was
+	// expanded or lay beyond MaxDepth.
+	Truncated bool   // Not expanded because a budget ran out
+	Error     string // Expanding it failed
+
+	key string
+}
+
+// Traversal is the result of GetCallers or GetCallees.
+type Traversal struct {
+	Calls   []CallInfo
+	Stopped string // Stop* reason if a budget ran out, else empty
 }
 
 // Traverser walks the call hierarchy.
 type Traverser struct {
 	client    *lsp.Client
 	extractor *output.SnippetExtractor
+
+	mu      sync.Mutex
+	symbols map[string][]lsp.DocumentSymbol // by URI, for ExpandInterfaces
 }
 
 // NewTraverser creates a new call hierarchy traverser.
@@ -46,123 +108,292 @@ func NewTraverser(client *lsp.Client) *Traverser {
 	return &Traverser{
 		client:    client,
 		extractor: output.NewSnippetExtractor(),
+		symbols:   make(map[string][]lsp.DocumentSymbol),
 	}
 }
 
 // GetCallers returns all callers of a call hierarchy item.
-func (t *Traverser) GetCallers(ctx context.Context, item lsp.CallHierarchyItem, opts Options) ([]CallInfo, error) {
-	return t.traverse(ctx, item, opts, 0, make(map[string]bool))
+func (t *Traverser) GetCallers(ctx context.Context, item lsp.CallHierarchyItem, opts Options) (*Traversal, error) {
+	return t.collect(ctx, item, opts)
 }
 
 // GetCallees returns all callees of a call hierarchy item.
-func (t *Traverser) GetCallees(ctx context.Context, item lsp.CallHierarchyItem, opts Options) ([]CallInfo, error) {
+func (t *Traverser) GetCallees(ctx context.Context, item lsp.CallHierarchyItem, opts Options) (*Traversal, error) {
 	opts.Direction = Down
-	return t.traverse(ctx, item, opts, 0, make(map[string]bool))
+	return t.collect(ctx, item, opts)
 }
 
-// traverse recursively walks the call hierarchy.
-func (t *Traverser) traverse(ctx context.Context, item lsp.CallHierarchyItem, opts Options, depth int, visited map[string]bool) ([]CallInfo, error) {
-	// Check depth limit
-	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
-		return nil, nil
+// collect flattens the calls found by walk into CallInfo, in walk order.
+func (t *Traverser) collect(ctx context.Context, item lsp.CallHierarchyItem, opts Options) (*Traversal, error) {
+	var calls []CallInfo
+	truncated := make(map[string]bool)
+	failed := make(map[string]string)
+
+	// paths holds the path from the target to each item reached so far
+	paths := map[string][]string{itemKey(item): {item.Name}}
+
+	stopped, err := t.walk(ctx, item, opts, visitor{
+		call: func(parent lsp.CallHierarchyItem, c call, depth int) {
+			path := append(slices.Clone(paths[itemKey(parent)]), c.item.Name)
+			key := itemKey(c.item)
+			if _, seen := paths[key]; !seen {
+				paths[key] = path
+			}
+
+			info := c.info()
+			info.key = key
+			info.Depth = depth
+			info.Chain = slices.Clone(path)
+			if opts.Direction == Up {
+				slices.Reverse(info.Chain)
+			}
+			calls = append(calls, info)
+		},
+		skip: func(item lsp.CallHierarchyItem, _ int, _ string) {
+			truncated[itemKey(item)] = true
+		},
+		fail: func(item lsp.CallHierarchyItem, _ int, err error) {
+			failed[itemKey(item)] = err.Error()
+		},
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Prevent cycles
-	key := item.URI + ":" + item.Name
-	if visited[key] {
-		return nil, nil
+	// An item may be reported by several calls before it is expanded
+	for i := range calls {
+		calls[i].Truncated = truncated[calls[i].key]
+		calls[i].Error = failed[calls[i].key]
 	}
-	visited[key] = true
+	return &Traversal{Calls: calls, Stopped: stopped}, nil
+}
 
-	var results []CallInfo
+// call is one edge of the call hierarchy seen from the item being expanded:
+// its caller when walking up, its callee when walking down.
+type call struct {
+	item    lsp.CallHierarchyItem
+	ranges  []lsp.Range // call sites, always in the caller's file
+	dynamic bool        // added by interface dispatch
+}
 
-	if opts.Direction == Up {
-		// Get incoming calls (callers)
-		calls, err := t.client.IncomingCalls(ctx, item)
-		if err != nil {
-			return nil, err
-		}
+// info describes the call's far end.
+func (c call) info() CallInfo {
+	file := lsp.URIToPath(c.item.URI)
+	return CallInfo{
+		Symbol:     c.item.Name,
+		File:       file,
+		Line:       c.item.Range.Start.Line + 1, // LSP is 0-indexed
+		LineEnd:    c.item.Range.End.Line + 1,
+		CallRanges: c.ranges,
+		InTest:     output.IsTestFile(file),
+		Dynamic:    c.dynamic,
+	}
+}
 
-		for _, call := range calls {
-			info := t.callInfoFromIncoming(call)
+// visitedSet records the items already expanded. It is safe for concurrent use.
+type visitedSet struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
 
-			// Apply filters
-			if opts.ExcludeTests && info.InTest {
-				continue
-			}
-			if opts.ExcludeStdlib && t.isStdlib(call.From.URI) {
-				continue
-			}
+func newVisitedSet() *visitedSet {
+	return &visitedSet{seen: make(map[string]bool)}
+}
 
-			results = append(results, info)
+// add marks key as visited and reports whether it was new.
+func (v *visitedSet) add(key string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.seen[key] {
+		return false
+	}
+	v.seen[key] = true
+	return true
+}
 
-			// Recurse
-			if opts.MaxDepth == 0 || depth+1 < opts.MaxDepth {
-				sub, err := t.traverse(ctx, call.From, opts, depth+1, visited)
-				if err != nil {
-					return nil, err
-				}
-				results = append(results, sub...)
+// itemKey identifies an item by its declaration, so same-named functions
+// in different files or packages stay distinct.
+func itemKey(item lsp.CallHierarchyItem) string {
+	p := item.SelectionRange.Start
+	return fmt.Sprintf("%s#%s@%d:%d", item.URI, item.Name, p.Line, p.Character)
+}
+
+// visitor receives the events of a walk. Any callback may be nil.
+type visitor struct {
+	// expand is called for each item whose calls are fetched, with its depth.
+	expand func(item lsp.CallHierarchyItem, depth int)
+	// call is called for each call that passes the filters, with the depth
+	// of its far end.
+	call func(parent lsp.CallHierarchyItem, c call, depth int)
+	// skip is called for each item left unexpanded, or only partly
+	// reported, because a budget ran out.
+	skip func(item lsp.CallHierarchyItem, depth int, reason string)
+	// fail is called for each item whose calls could not be fetched.
+	fail func(item lsp.CallHierarchyItem, depth int, err error)
+}
+
+// walk visits the call hierarchy breadth-first from root. Each level's
+// items are expanded concurrently, bounded by opts.Concurrency, but the
+// callbacks run on the calling goroutine in a fixed order: level by level,
+// items in the order they were discovered, calls in the order the server
+// returned them. Completion order never affects the output.
+//
+// An item is expanded at most once and only while its depth is below
+// opts.MaxDepth. When a budget runs out walk stops expanding, reports the
+// items it leaves behind to v.skip and returns the reason. A failure to
+// expand one item is reported to v.fail and the rest of the walk goes on;
+// only a failure on root itself is returned as an error.
+func (t *Traverser) walk(ctx context.Context, root lsp.CallHierarchyItem, opts Options, v visitor) (string, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	visited := newVisitedSet()
+	visited.add(itemKey(root))
+	frontier := []lsp.CallHierarchyItem{root}
+
+	var stopped string
+	skip := func(items []lsp.CallHierarchyItem, depth int, reason string) {
+		stopped = reason
+		if v.skip != nil {
+			for _, item := range items {
+				v.skip(item, depth, reason)
 			}
 		}
-	} else {
-		// Get outgoing calls (callees)
-		calls, err := t.client.OutgoingCalls(ctx, item)
-		if err != nil {
-			return nil, err
-		}
+	}
 
-		for _, call := range calls {
-			info := t.callInfoFromOutgoing(call)
+	expanded, edges := 0, 0
+	for depth := 0; len(frontier) > 0; depth++ {
+		if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
+			break
+		}
+		if stopped != "" {
+			skip(frontier, depth, stopped)
+			break
+		}
+		if ctx.Err() != nil {
+			skip(frontier, depth, StopTimeout)
+			break
+		}
+		if opts.MaxNodes > 0 && expanded+len(frontier) > opts.MaxNodes {
+			n := opts.MaxNodes - expanded
+			skip(frontier[n:], depth, StopMaxNodes)
+			frontier = frontier[:n]
+		}
+		expanded += len(frontier)
 
-			// Apply filters
-			if opts.ExcludeTests && info.InTest {
-				continue
+		if v.expand != nil {
+			for _, item := range frontier {
+				v.expand(item, depth)
 			}
-			if opts.ExcludeStdlib && t.isStdlib(call.To.URI) {
+		}
+
+		level, errs := t.fetchLevel(ctx, frontier, opts)
+
+		var next []lsp.CallHierarchyItem
+		for i, calls := range level {
+			item := frontier[i]
+			if err := errs[i]; err != nil && !partial(err) {
+				switch {
+				case ctx.Err() != nil:
+					skip([]lsp.CallHierarchyItem{item}, depth, StopTimeout)
+				case depth == 0:
+					return "", err
+				case v.fail != nil:
+					v.fail(item, depth, err)
+				}
 				continue
+			} else if err != nil && v.fail != nil {
+				// Only interface dispatch failed; the static calls stand
+				v.fail(item, depth, err)
 			}
 
-			results = append(results, info)
+			for _, c := range calls {
+				if opts.ExcludeTests && output.IsTestFile(lsp.URIToPath(c.item.URI)) {
+					continue
+				}
+				if opts.ExcludeStdlib && t.isStdlib(c.item.URI) {
+					continue
+				}
+
+				if opts.MaxEdges > 0 && edges >= opts.MaxEdges {
+					skip([]lsp.CallHierarchyItem{item}, depth, StopMaxEdges)
+					break
+				}
+				edges++
+
+				if v.call != nil {
+					v.call(item, c, depth+1)
+				}
 
-			// Recurse
-			if opts.MaxDepth == 0 || depth+1 < opts.MaxDepth {
-				sub, err := t.traverse(ctx, call.To, opts, depth+1, visited)
-				if err != nil {
-					return nil, err
+				if (opts.MaxDepth == 0 || depth+1 < opts.MaxDepth) && visited.add(itemKey(c.item)) {
+					next = append(next, c.item)
 				}
-				results = append(results, sub...)
 			}
 		}
+		frontier = next
 	}
 
-	return results, nil
+	return stopped, nil
 }
 
-// callInfoFromIncoming creates CallInfo from an incoming call.
-func (t *Traverser) callInfoFromIncoming(call lsp.CallHierarchyIncomingCall) CallInfo {
-	file := lsp.URIToPath(call.From.URI)
-	return CallInfo{
-		Symbol:     call.From.Name,
-		File:       file,
-		Line:       call.From.Range.Start.Line + 1, // LSP is 0-indexed
-		LineEnd:    call.From.Range.End.Line + 1,
-		CallRanges: call.FromRanges,
-		InTest:     output.IsTestFile(file),
+// fetchLevel fetches the calls of every item concurrently. Results and
+// errors are indexed like items; one item failing does not affect the rest.
+func (t *Traverser) fetchLevel(ctx context.Context, items []lsp.CallHierarchyItem, opts Options) ([][]call, []error) {
+	results := make([][]call, len(items))
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, opts.concurrency())
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			results[i], errs[i] = t.calls(ctx, item, opts)
+		}()
 	}
+	wg.Wait()
+
+	return results, errs
 }
 
-// callInfoFromOutgoing creates CallInfo from an outgoing call.
-func (t *Traverser) callInfoFromOutgoing(call lsp.CallHierarchyOutgoingCall) CallInfo {
-	file := lsp.URIToPath(call.To.URI)
-	return CallInfo{
-		Symbol:     call.To.Name,
-		File:       file,
-		Line:       call.To.Range.Start.Line + 1,
-		LineEnd:    call.To.Range.End.Line + 1,
-		CallRanges: call.FromRanges,
-		InTest:     output.IsTestFile(file),
+// calls fetches one item's callers or callees.
+func (t *Traverser) calls(ctx context.Context, item lsp.CallHierarchyItem, opts Options) ([]call, error) {
+	var result []call
+	if opts.Direction == Up {
+		incoming, err := t.client.IncomingCalls(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		result = make([]call, len(incoming))
+		for i, c := range incoming {
+			result[i] = call{item: c.From, ranges: c.FromRanges}
+		}
+	} else {
+		outgoing, err := t.client.OutgoingCalls(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		result = make([]call, len(outgoing))
+		for i, c := range outgoing {
+			result[i] = call{item: c.To, ranges: c.FromRanges}
+		}
+	}
+
+	if opts.ExpandInterfaces {
+		return t.withDispatch(ctx, item, result, opts.Direction)
 	}
+	return result, nil
 }
 
 // isStdlib checks if a URI is from the standard library.
@@ -174,135 +405,188 @@ func (t *Traverser) isStdlib(uri string) bool {
 		(!strings.Contains(path, "/") && !strings.HasPrefix(path, "."))
 }
 
-// BuildTree builds a call tree structure.
+// BuildTree builds a call tree structure. Nodes are keyed by the stable
+// IDs described in identify, and edges refer to nodes by ID. Each node
+// records its signed distance from the root: negative for callers,
+// positive for callees.
+//
+// With Direction Both the callers and the callees of the root are walked
+// one after the other into the same graph. MaxNodes and MaxEdges apply to
+// each walk, Timeout to the two together.
+//
+// Calls back to a node already on the path from the root are kept as
+// edges marked BackEdge; they close a cycle and are not explored again.
 func (t *Traverser) BuildTree(ctx context.Context, item lsp.CallHierarchyItem, opts Options) (*output.TreeResponse, error) {
+	dirs := []Direction{opts.Direction}
+	if opts.Direction == Both {
+		dirs = []Direction{Up, Down}
+		if opts.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+			defer cancel()
+		}
+	}
+
+	// Built by item key, then renamed to IDs once every item is known
+	items := make(map[string]lsp.CallHierarchyItem)
 	nodes := make(map[string]output.TreeNode)
+	parents := make(map[string]string) // the node each was discovered from
 	var edges []output.TreeEdge
-
-	visited := make(map[string]bool)
 	maxDepth := 0
 
-	err := t.buildTreeRecursive(ctx, item, opts, 0, visited, nodes, &edges, &maxDepth)
-	if err != nil {
-		return nil, err
+	// ancestor reports whether a is key or lies on the path to it from the root
+	ancestor := func(a, key string) bool {
+		for {
+			if key == a {
+				return true
+			}
+			p, ok := parents[key]
+			if !ok {
+				return false
+			}
+			key = p
+		}
+	}
+
+	// node returns the node for item, creating it on first sight
+	node := func(item lsp.CallHierarchyItem) (string, output.TreeNode) {
+		key := itemKey(item)
+		if n, exists := nodes[key]; exists {
+			return key, n
+		}
+		items[key] = item
+		return key, output.TreeNode{
+			Package: t.qualifier(item),
+			File:    lsp.URIToPath(item.URI),
+			Line:    item.Range.Start.Line + 1,
+		}
+	}
+
+	var stopped string
+	for _, dir := range dirs {
+		sign := 1
+		if dir == Up {
+			sign = -1
+		}
+		o := opts
+		o.Direction = dir
+		if len(dirs) > 1 {
+			o.Timeout = 0 // already applied to ctx
+		}
+
+		reason, err := t.walk(ctx, item, o, visitor{
+			expand: func(item lsp.CallHierarchyItem, _ int) {
+				key, n := node(item)
+				nodes[key] = n
+			},
+			call: func(parent lsp.CallHierarchyItem, c call, depth int) {
+				maxDepth = max(maxDepth, depth)
+
+				key, n := node(parent)
+				other, on := node(c.item)
+				if _, exists := nodes[other]; !exists {
+					on.Depth = sign * depth
+					nodes[other] = on
+					parents[other] = key
+				}
+				back := ancestor(other, key)
+
+				from, to, callerURI := other, key, c.item.URI
+				if dir == Up {
+					n.CalledBy = append(n.CalledBy, other)
+				} else {
+					from, to, callerURI = key, other, parent.URI
+					n.Calls = append(n.Calls, other)
+				}
+				nodes[key] = n
+
+				for _, r := range c.ranges {
+					edges = append(edges, output.TreeEdge{
+						From:     from,
+						To:       to,
+						File:     lsp.URIToPath(callerURI),
+						Line:     r.Start.Line + 1,
+						Dynamic:  c.dynamic,
+						BackEdge: back,
+					})
+				}
+			},
+			skip: func(item lsp.CallHierarchyItem, _ int, _ string) {
+				key, n := node(item)
+				n.Truncated = true
+				nodes[key] = n
+			},
+			fail: func(item lsp.CallHierarchyItem, _ int, err error) {
+				key, n := node(item)
+				n.Error = err.Error()
+				nodes[key] = n
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if stopped == "" {
+			stopped = reason
+		}
+	}
+
+	ids := t.assignIDs(items)
+	rename := func(keys []string) []string {
+		out := make([]string, len(keys))
+		for i, k := range keys {
+			out[i] = ids[k].ID
+		}
+		return out
+	}
+
+	byID := make(map[string]output.TreeNode, len(nodes))
+	for key, n := range nodes {
+		id := ids[key]
+		n.ID, n.Name = id.ID, id.Name
+		if n.Calls != nil {
+			n.Calls = rename(n.Calls)
+		}
+		if n.CalledBy != nil {
+			n.CalledBy = rename(n.CalledBy)
+		}
+		byID[id.ID] = n
+	}
+	for i := range edges {
+		edges[i].From = ids[edges[i].From].ID
+		edges[i].To = ids[edges[i].To].ID
 	}
 
 	direction := "down"
-	if opts.Direction == Up {
+	switch opts.Direction {
+	case Up:
 		direction = "up"
+	case Both:
+		direction = "both"
+	}
+
+	var cycles []output.Cycle
+	if opts.FindCycles {
+		cycles = findCycles(edges)
 	}
 
+	root := ids[itemKey(item)]
 	return &output.TreeResponse{
 		Query: output.TreeQuery{
 			Command:   "tree",
 			Root:      item.Name,
+			RootID:    root.ID,
 			Depth:     opts.MaxDepth,
 			Direction: direction,
 		},
-		Nodes: nodes,
-		Edges: edges,
+		Nodes:  byID,
+		Edges:  edges,
+		Cycles: cycles,
 		Summary: output.TreeSummary{
-			NodeCount:       len(nodes),
+			NodeCount:       len(byID),
 			EdgeCount:       len(edges),
 			MaxDepthReached: maxDepth,
-			Truncated:       opts.MaxDepth > 0 && maxDepth >= opts.MaxDepth,
+			Truncated:       stopped != "" || (opts.MaxDepth > 0 && maxDepth >= opts.MaxDepth),
+			StopReason:      stopped,
 		},
 	}, nil
 }
-
-// buildTreeRecursive builds the tree structure recursively.
-func (t *Traverser) buildTreeRecursive(ctx context.Context, item lsp.CallHierarchyItem, opts Options, depth int, visited map[string]bool, nodes map[string]output.TreeNode, edges *[]output.TreeEdge, maxDepth *int) error {
-	if depth > *maxDepth {
-		*maxDepth = depth
-	}
-
-	if opts.MaxDepth > 0 && depth >= opts.MaxDepth {
-		return nil
-	}
-
-	key := item.URI + ":" + item.Name
-	if visited[key] {
-		return nil
-	}
-	visited[key] = true
-
-	file := lsp.URIToPath(item.URI)
-
-	// Add node if not exists
-	if _, exists := nodes[item.Name]; !exists {
-		nodes[item.Name] = output.TreeNode{
-			File: file,
-			Line: item.Range.Start.Line + 1,
-		}
-	}
-
-	if opts.Direction == Up {
-		calls, err := t.client.IncomingCalls(ctx, item)
-		if err != nil {
-			return err
-		}
-
-		node := nodes[item.Name]
-		for _, call := range calls {
-			if opts.ExcludeTests && output.IsTestFile(lsp.URIToPath(call.From.URI)) {
-				continue
-			}
-			if opts.ExcludeStdlib && t.isStdlib(call.From.URI) {
-				continue
-			}
-
-			node.CalledBy = append(node.CalledBy, call.From.Name)
-
-			// Add edge
-			for _, r := range call.FromRanges {
-				*edges = append(*edges, output.TreeEdge{
-					From: call.From.Name,
-					To:   item.Name,
-					File: lsp.URIToPath(call.From.URI),
-					Line: r.Start.Line + 1,
-				})
-			}
-
-			// Recurse
-			if err := t.buildTreeRecursive(ctx, call.From, opts, depth+1, visited, nodes, edges, maxDepth); err != nil {
-				return err
-			}
-		}
-		nodes[item.Name] = node
-	} else {
-		calls, err := t.client.OutgoingCalls(ctx, item)
-		if err != nil {
-			return err
-		}
-
-		node := nodes[item.Name]
-		for _, call := range calls {
-			if opts.ExcludeTests && output.IsTestFile(lsp.URIToPath(call.To.URI)) {
-				continue
-			}
-			if opts.ExcludeStdlib && t.isStdlib(call.To.URI) {
-				continue
-			}
-
-			node.Calls = append(node.Calls, call.To.Name)
-
-			// Add edge
-			for _, r := range call.FromRanges {
-				*edges = append(*edges, output.TreeEdge{
-					From: item.Name,
-					To:   call.To.Name,
-					File: file,
-					Line: r.Start.Line + 1,
-				})
-			}
-
-			// Recurse
-			if err := t.buildTreeRecursive(ctx, call.To, opts, depth+1, visited, nodes, edges, maxDepth); err != nil {
-				return err
-			}
-		}
-		nodes[item.Name] = node
-	}
-
-	return nil
-}
@@ -0,0 +1,449 @@
+package traverse
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+)
+
+// callGraph builds:
+//
+//	main -> run -> load -> parse
+//	         run -> serve -> run (cycle)
+//	TestRun -> run
+func callGraph(t *testing.T) (*lsptest.Server, map[string]*lsptest.Symbol) {
+	t.Helper()
+	srv := lsptest.NewServer("/ws")
+	syms := map[string]*lsptest.Symbol{
+		"main":    srv.Add(lsptest.Symbol{Name: "main", Container: "main", Kind: lsp.SymbolKindFunction, File: "main.go", Line: 3, EndLine: 6}),
+		"run":     srv.Add(lsptest.Symbol{Name: "run", Container: "main", Kind: lsp.SymbolKindFunction, File: "main.go", Line: 8, EndLine: 12}),
+		"load":    srv.Add(lsptest.Symbol{Name: "load", Container: "main", Kind: lsp.SymbolKindFunction, File: "load.go", Line: 3, EndLine: 5}),
+		"parse":   srv.Add(lsptest.Symbol{Name: "parse", Container: "main", Kind: lsp.SymbolKindFunction, File: "load.go", Line: 7, EndLine: 9}),
+		"serve":   srv.Add(lsptest.Symbol{Name: "serve", Container: "main", Kind: lsp.SymbolKindFunction, File: "serve.go", Line: 3, EndLine: 6}),
+		"TestRun": srv.Add(lsptest.Symbol{Name: "TestRun", Container: "main", Kind: lsp.SymbolKindFunction, File: "main_test.go", Line: 5, EndLine: 7}),
+	}
+	srv.Call(syms["main"], syms["run"], 4)
+	srv.Call(syms["run"], syms["load"], 9)
+	srv.Call(syms["run"], syms["serve"], 10)
+	srv.Call(syms["load"], syms["parse"], 4)
+	srv.Call(syms["serve"], syms["run"], 5)
+	srv.Call(syms["TestRun"], syms["run"], 6)
+	return srv, syms
+}
+
+// prepare returns the call hierarchy item for sym.
+func prepare(t *testing.T, client *lsp.Client, srv *lsptest.Server, sym *lsptest.Symbol) lsp.CallHierarchyItem {
+	t.Helper()
+	items, err := client.PrepareCallHierarchy(t.Context(), srv.URI(sym.File), lsp.Position{Line: sym.Line - 1})
+	if err != nil || len(items) != 1 {
+		t.Fatalf("PrepareCallHierarchy(%s) = %v, %v", sym.Name, items, err)
+	}
+	return items[0]
+}
+
+func symbolNames(calls []CallInfo) []string {
+	names := make([]string, len(calls))
+	for i, c := range calls {
+		names[i] = c.Symbol
+	}
+	return names
+}
+
+func TestTraverser_GetCallers(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["run"])
+
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{name: "direct", opts: Options{Direction: Up, MaxDepth: 1}, want: []string{"main", "serve", "TestRun"}},
+		{name: "exclude tests", opts: Options{Direction: Up, MaxDepth: 1, ExcludeTests: true}, want: []string{"main", "serve"}},
+		// Breadth-first: run's own callers, then serve's caller (run again)
+		{name: "cycle", opts: Options{Direction: Up, MaxDepth: 5}, want: []string{"main", "serve", "TestRun", "run"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tr.GetCallers(t.Context(), item, tt.opts)
+			if err != nil {
+				t.Fatalf("GetCallers: %v", err)
+			}
+			if got := symbolNames(got.Calls); !slices.Equal(got, tt.want) {
+				t.Errorf("callers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraverser_GetCallees(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["main"])
+
+	got, err := tr.GetCallees(t.Context(), item, Options{MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("GetCallees: %v", err)
+	}
+	calls := got.Calls
+	// Level by level; serve calls back into run, which is reported but
+	// not expanded again
+	want := []string{"run", "load", "serve", "parse", "run"}
+	if got := symbolNames(calls); !slices.Equal(got, want) {
+		t.Errorf("callees = %v, want %v", got, want)
+	}
+	if calls[0].File != "/ws/main.go" || calls[0].Line != 8 {
+		t.Errorf("run at %s:%d, want /ws/main.go:8", calls[0].File, calls[0].Line)
+	}
+}
+
+func TestTraverser_Chains(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+
+	callers, err := tr.GetCallers(t.Context(), prepare(t, client, srv, syms["parse"]), Options{Direction: Up})
+	if err != nil {
+		t.Fatalf("GetCallers: %v", err)
+	}
+	callees, err := tr.GetCallees(t.Context(), prepare(t, client, srv, syms["main"]), Options{})
+	if err != nil {
+		t.Fatalf("GetCallees: %v", err)
+	}
+
+	find := func(calls []CallInfo, name string) CallInfo {
+		for _, c := range calls {
+			if c.Symbol == name {
+				return c
+			}
+		}
+		t.Fatalf("%s not found in %v", name, symbolNames(calls))
+		return CallInfo{}
+	}
+
+	tests := []struct {
+		name  string
+		call  CallInfo
+		depth int
+		chain []string
+	}{
+		{name: "direct caller", call: find(callers.Calls, "load"), depth: 1, chain: []string{"load", "parse"}},
+		{name: "transitive caller", call: find(callers.Calls, "main"), depth: 3, chain: []string{"main", "run", "load", "parse"}},
+		{name: "transitive callee", call: find(callees.Calls, "parse"), depth: 3, chain: []string{"main", "run", "load", "parse"}},
+	}
+	for _, tt := range tests {
+		if tt.call.Depth != tt.depth || !slices.Equal(tt.call.Chain, tt.chain) {
+			t.Errorf("%s: depth %d, chain %v; want %d, %v", tt.name, tt.call.Depth, tt.call.Chain, tt.depth, tt.chain)
+		}
+	}
+}
+
+func TestTraverser_DeterministicOrder(t *testing.T) {
+	// A wide fan-out finishes in arbitrary order; output must not
+	srv := lsptest.NewServer("/ws")
+	root := srv.Func("main", "root", "root.go", 1)
+	var want []string
+	for i := range 20 {
+		mid := srv.Func("main", fmt.Sprintf("mid%02d", i), "mid.go", 10+i)
+		leaf := srv.Func("main", fmt.Sprintf("leaf%02d", i), "leaf.go", 10+i)
+		srv.Call(root, mid, 1)
+		srv.Call(mid, leaf, 10+i)
+		want = append(want, mid.Name)
+	}
+	for i := range 20 {
+		want = append(want, fmt.Sprintf("leaf%02d", i))
+	}
+
+	client := srv.Client(t)
+	item := prepare(t, client, srv, root)
+	for _, n := range []int{1, 4, 32} {
+		calls, err := NewTraverser(client).GetCallees(t.Context(), item, Options{Concurrency: n})
+		if err != nil {
+			t.Fatalf("GetCallees: %v", err)
+		}
+		if got := symbolNames(calls.Calls); !slices.Equal(got, want) {
+			t.Errorf("concurrency %d: callees = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestTraverser_BuildTree(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["main"])
+
+	tree, err := tr.BuildTree(t.Context(), item, Options{Direction: Down, MaxDepth: 10})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+
+	if tree.Summary.NodeCount != 5 {
+		t.Errorf("NodeCount = %d, want 5", tree.Summary.NodeCount)
+	}
+	if tree.Summary.EdgeCount != 5 {
+		t.Errorf("EdgeCount = %d, want 5", tree.Summary.EdgeCount)
+	}
+	if got := tree.Nodes["ws.run"].Calls; !slices.Equal(got, []string{"ws.load", "ws.serve"}) {
+		t.Errorf("run calls %v, want [ws.load ws.serve]", got)
+	}
+}
+
+func TestTraverser_BuildTreeBoth(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["load"])
+
+	tree, err := tr.BuildTree(t.Context(), item, Options{Direction: Both, MaxDepth: 2})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+
+	if tree.Query.Direction != "both" {
+		t.Errorf("Direction = %q, want both", tree.Query.Direction)
+	}
+	want := map[string]int{
+		"ws.load":  0,
+		"ws.run":   -1,
+		"ws.main":  -2,
+		"ws.serve": -2,
+		"ws.parse": 1,
+
+		"ws.TestRun": -2,
+	}
+	for id, n := range tree.Nodes {
+		d, ok := want[id]
+		if !ok {
+			t.Errorf("unexpected node %s", id)
+			continue
+		}
+		if n.Depth != d {
+			t.Errorf("%s depth = %d, want %d", id, n.Depth, d)
+		}
+	}
+	if len(tree.Nodes) != len(want) {
+		t.Errorf("NodeCount = %d, want %d", len(tree.Nodes), len(want))
+	}
+	if got := tree.Nodes["ws.load"].CalledBy; !slices.Equal(got, []string{"ws.run"}) {
+		t.Errorf("load called by %v, want [ws.run]", got)
+	}
+	if got := tree.Nodes["ws.load"].Calls; !slices.Equal(got, []string{"ws.parse"}) {
+		t.Errorf("load calls %v, want [ws.parse]", got)
+	}
+	if tree.Summary.MaxDepthReached != 2 || !tree.Summary.Truncated {
+		t.Errorf("MaxDepthReached = %d, Truncated = %v, want 2, true", tree.Summary.MaxDepthReached, tree.Summary.Truncated)
+	}
+}
+
+func TestTraverser_Error(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	client.SetRetryPolicy(lsp.RetryPolicy{})
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["run"])
+
+	srv.Fail("callHierarchy/incomingCalls", lsp.CodeInternalError)
+	if _, err := tr.GetCallers(t.Context(), item, Options{Direction: Up}); err == nil {
+		t.Fatal("expected error from failing server")
+	}
+}
+
+// truncatedNames returns the symbols marked truncated.
+func truncatedNames(calls []CallInfo) []string {
+	var names []string
+	for _, c := range calls {
+		if c.Truncated {
+			names = append(names, c.Symbol)
+		}
+	}
+	return names
+}
+
+func TestTraverser_Budgets(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["main"])
+
+	tests := []struct {
+		name      string
+		opts      Options
+		want      []string
+		truncated []string
+		stopped   string
+	}{
+		// main and run are expanded; load and serve are found but not explored
+		{name: "max nodes", opts: Options{MaxNodes: 2}, want: []string{"run", "load", "serve"}, truncated: []string{"load", "serve"}, stopped: StopMaxNodes},
+		// run reports load, then the budget runs out before serve
+		{name: "max edges", opts: Options{MaxEdges: 2}, want: []string{"run", "load"}, truncated: []string{"run", "load"}, stopped: StopMaxEdges},
+		{name: "within budget", opts: Options{MaxNodes: 10, MaxEdges: 10}, want: []string{"run", "load", "serve", "parse", "run"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tr.GetCallees(t.Context(), item, tt.opts)
+			if err != nil {
+				t.Fatalf("GetCallees: %v", err)
+			}
+			if names := symbolNames(got.Calls); !slices.Equal(names, tt.want) {
+				t.Errorf("callees = %v, want %v", names, tt.want)
+			}
+			if names := truncatedNames(got.Calls); !slices.Equal(names, tt.truncated) {
+				t.Errorf("truncated = %v, want %v", names, tt.truncated)
+			}
+			if got.Stopped != tt.stopped {
+				t.Errorf("Stopped = %q, want %q", got.Stopped, tt.stopped)
+			}
+		})
+	}
+}
+
+func TestTraverser_Timeout(t *testing.T) {
+	// A chain deeper than the wall-clock budget allows
+	srv := lsptest.NewServer("/ws")
+	srv.Latency = 20 * time.Millisecond
+	first := srv.Func("main", "f00", "chain.go", 1)
+	prev := first
+	for i := 1; i < 50; i++ {
+		next := srv.Func("main", fmt.Sprintf("f%02d", i), "chain.go", 1+i)
+		srv.Call(prev, next, i)
+		prev = next
+	}
+
+	client := srv.Client(t)
+	item := prepare(t, client, srv, first)
+	got, err := NewTraverser(client).GetCallees(t.Context(), item, Options{Timeout: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("GetCallees: %v", err)
+	}
+	if got.Stopped != StopTimeout {
+		t.Errorf("Stopped = %q, want %q", got.Stopped, StopTimeout)
+	}
+	if n := len(got.Calls); n == 0 || n >= 49 {
+		t.Fatalf("found %d callees, want a partial chain", n)
+	}
+	if last := got.Calls[len(got.Calls)-1]; !last.Truncated {
+		t.Errorf("last callee %s not marked truncated", last.Symbol)
+	}
+}
+
+func TestTraverser_PartialFailure(t *testing.T) {
+	srv, syms := callGraph(t)
+	client := srv.Client(t)
+	client.SetRetryPolicy(lsp.RetryPolicy{})
+	tr := NewTraverser(client)
+	item := prepare(t, client, srv, syms["main"])
+
+	srv.FailCalls(syms["load"], lsp.CodeInternalError)
+	tree, err := tr.BuildTree(t.Context(), item, Options{Direction: Down})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+
+	// load's branch is marked; serve's is still explored
+	if tree.Nodes["ws.load"].Error == "" {
+		t.Error("load has no error marker")
+	}
+	if _, ok := tree.Nodes["ws.parse"]; ok {
+		t.Error("parse found below a failed node")
+	}
+	if got := tree.Nodes["ws.serve"].Calls; !slices.Equal(got, []string{"ws.run"}) {
+		t.Errorf("serve calls %v, want [ws.run]", got)
+	}
+	if tree.Summary.Truncated {
+		t.Error("tree truncated by a failure, want only the node marked")
+	}
+}
+
+func TestTraverser_BuildTreeIDs(t *testing.T) {
+	// Methods need their declarations on disk to find the receiver
+	root := t.TempDir()
+	files := map[string]string{
+		"server.go": "package ws\n\nfunc (s *Server) Start() {}\n",
+		"client.go": "package ws\n\nfunc (c *Client[T]) Start() {}\n",
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := lsptest.NewServer(root)
+	main := srv.Func("main", "main", "main.go", 1)
+	calls := []*lsptest.Symbol{
+		srv.Func("a", "New", "a/new.go", 3),
+		srv.Func("b", "New", "b/new.go", 3),
+		srv.Func("main", "setup", "setup_linux.go", 5),
+		srv.Func("main", "setup", "setup_darwin.go", 5),
+		srv.Method("Server", "Start", "server.go", 3),
+		srv.Method("Client", "Start", "client.go", 3),
+	}
+	for i, c := range calls {
+		srv.Call(main, c, 2+i)
+	}
+
+	client := srv.Client(t)
+	item := prepare(t, client, srv, main)
+	tree, err := NewTraverser(client).BuildTree(t.Context(), item, Options{Direction: Down})
+	if err != nil {
+		t.Fatalf("BuildTree: %v", err)
+	}
+
+	base := filepath.Base(root)
+	want := map[string]string{
+		base + ".main":                    base + ".main",
+		"a.New":                           "a.New",
+		"b.New":                           "b.New",
+		base + ".setup@setup_linux.go:5":  base + ".setup@setup_linux.go:5",
+		base + ".setup@setup_darwin.go:5": base + ".setup@setup_darwin.go:5",
+		base + ".Server.Start":            base + ".Server.Start",
+		base + ".Client.Start":            base + ".Client.Start",
+	}
+	if len(tree.Nodes) != len(want) {
+		t.Errorf("got %d nodes, want %d", len(tree.Nodes), len(want))
+	}
+	for id, name := range want {
+		n, ok := tree.Nodes[id]
+		if !ok {
+			t.Errorf("missing node %s", id)
+			continue
+		}
+		if n.ID != id || n.Name != name {
+			t.Errorf("node %s: ID %q, Name %q, want name %q", id, n.ID, n.Name, name)
+		}
+	}
+	for _, e := range tree.Edges {
+		if _, ok := tree.Nodes[e.To]; !ok || e.From != base+".main" {
+			t.Errorf("edge %s -> %s does not refer to nodes", e.From, e.To)
+		}
+	}
+	if tree.Query.RootID != base+".main" {
+		t.Errorf("RootID = %q, want %s.main", tree.Query.RootID, base)
+	}
+}
+
+func TestGoReceiver(t *testing.T) {
+	tests := map[string]string{
+		"func (s *Server) Start() {":         "Server",
+		"func (Server) Start() {":            "Server",
+		"\tfunc (m *Map[K, V]) Get(k K) V {": "Map",
+		"func Start() {":                     "",
+		"def start(self):":                   "",
+	}
+	for line, want := range tests {
+		if got := goReceiver(line); got != want {
+			t.Errorf("goReceiver(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
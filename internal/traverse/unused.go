@@ -0,0 +1,287 @@
+package traverse
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// Reasons FindUnused reports a function, from most to least certain.
+const (
+	UnusedNoReferences = "no_references" // Nothing refers to it
+	UnusedSelfOnly     = "self_only"     // Only its own body refers to it
+	UnusedTestOnly     = "test_only"     // Only tests refer to it
+	UnusedUnreachable  = "unreachable"   // Only other unused code refers to it
+)
+
+// Entrypoints selects the functions FindUnused starts from, besides main
+// and init functions, which always are.
+type Entrypoints struct {
+	Tests    bool     // Test, Benchmark, Fuzz and Example functions
+	Exported bool     // Exported functions and methods: the API of a library
+	Patterns []string // Globs matched against each function's name, display name and ID
+}
+
+// FindUnused reports the functions and methods declared in files that no
+// entrypoint reaches. Reachability follows the outgoing calls of
+// BuildGraph, and then the references that use a function as a value: a
+// function referenced from reachable code, or from outside any function
+// in non-test code, is reachable too. Methods that implement an interface
+// are taken as reachable through dynamic dispatch. Each function left over
+// is classified by where it is referenced from.
+//
+// When no main function is declared the files are taken to be a library
+// and exported functions are entrypoints whatever entry.Exported says.
+// A function whose references cannot be fetched is left out and listed in
+// the summary errors. Budgets apply to building the graph; when one runs
+// out the summary is marked truncated, and results may include functions
+// only reached through the part left unexplored.
+func (t *Traverser) FindUnused(ctx context.Context, files []string, entry Entrypoints, opts Options) (*output.UnusedResponse, error) {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+	g := t.buildGraph(ctx, files, opts)
+	ids := t.assignIDs(g.items)
+
+	out := make(map[string][]string)
+	for _, e := range g.edges {
+		out[e.From] = append(out[e.From], e.To)
+	}
+	reached := make(map[string]bool)
+	reach := func(key string) {
+		queue := []string{key}
+		for len(queue) > 0 {
+			k := queue[0]
+			queue = queue[1:]
+			if reached[k] {
+				continue
+			}
+			reached[k] = true
+			queue = append(queue, out[k]...)
+		}
+	}
+
+	library := entry.Exported || !slices.ContainsFunc(g.declared, func(key string) bool {
+		return isMain(g.items[key])
+	})
+	entrypoints := 0
+	for _, key := range g.declared {
+		if isEntrypoint(g.items[key], ids[key], g.nodes[key].Test, entry, library) {
+			entrypoints++
+			reach(key)
+		}
+	}
+
+	var candidates []lsp.CallHierarchyItem
+	for _, key := range g.declared {
+		if !reached[key] {
+			candidates = append(candidates, g.items[key])
+		}
+	}
+	refs, dispatched, errs := t.usage(ctx, candidates, opts)
+
+	// enclosing finds the declared function containing loc
+	byURI := make(map[string][]string)
+	for _, key := range g.declared {
+		byURI[g.items[key].URI] = append(byURI[g.items[key].URI], key)
+	}
+	enclosing := func(loc lsp.Location) (string, bool) {
+		for _, key := range byURI[loc.URI] {
+			if g.items[key].Range.Contains(loc.Range.Start) {
+				return key, true
+			}
+		}
+		return "", false
+	}
+
+	failed := slices.Clone(g.failed)
+	skip := make(map[string]bool)
+	for i, item := range candidates {
+		key := itemKey(item)
+		switch {
+		case errs[i] != nil:
+			skip[key] = true
+			if ctx.Err() != nil {
+				g.stopped = StopTimeout
+			} else {
+				failed = append(failed, fmt.Sprintf("%s: %v", item.Name, errs[i]))
+			}
+		case dispatched[i]:
+			reach(key)
+		}
+	}
+
+	// Function values: reaching a function can make the ones it refers
+	// to reachable, so repeat until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for i, item := range candidates {
+			key := itemKey(item)
+			if reached[key] || skip[key] {
+				continue
+			}
+			for _, loc := range refs[i] {
+				container, ok := enclosing(loc)
+				if (ok && container != key && reached[container]) ||
+					(!ok && !output.IsTestFile(lsp.URIToPath(loc.URI))) {
+					reach(key)
+					changed = true
+					break
+				}
+			}
+		}
+	}
+
+	resp := &output.UnusedResponse{
+		Results: []output.UnusedSymbol{},
+		Summary: output.UnusedSummary{
+			ByReason:    make(map[string]int),
+			Functions:   len(g.declared),
+			Entrypoints: entrypoints,
+			Library:     library && !entry.Exported,
+			StopReason:  g.stopped,
+			Truncated:   g.stopped != "",
+			Errors:      failed,
+		},
+	}
+	for _, key := range g.declared {
+		if reached[key] {
+			resp.Summary.Reachable++
+		}
+	}
+
+	for i, item := range candidates {
+		key := itemKey(item)
+		if reached[key] || skip[key] {
+			continue
+		}
+
+		var from []string
+		self, tests, others := false, false, false
+		for _, loc := range refs[i] {
+			container, ok := enclosing(loc)
+			switch {
+			case ok && container == key:
+				self = true
+				continue
+			case output.IsTestFile(lsp.URIToPath(loc.URI)):
+				tests = true
+			default:
+				others = true
+			}
+			where := fmt.Sprintf("%s:%d", lsp.URIToPath(loc.URI), loc.Range.Start.Line+1)
+			if ok {
+				where = ids[container].ID
+			}
+			if !slices.Contains(from, where) {
+				from = append(from, where)
+			}
+		}
+
+		reason := UnusedNoReferences
+		switch {
+		case others:
+			reason = UnusedUnreachable
+		case tests:
+			reason = UnusedTestOnly
+		case self:
+			reason = UnusedSelfOnly
+		}
+
+		n := g.nodes[key]
+		resp.Results = append(resp.Results, output.UnusedSymbol{
+			Symbol:         ids[key].ID,
+			Name:           ids[key].Name,
+			Kind:           n.Kind,
+			File:           n.File,
+			Line:           item.Range.Start.Line + 1,
+			LineEnd:        item.Range.End.Line + 1,
+			InTest:         n.Test,
+			Reason:         reason,
+			ReferencedFrom: from,
+		})
+		resp.Summary.ByReason[reason]++
+	}
+	resp.Summary.Count = len(resp.Results)
+	return resp, nil
+}
+
+// usage fetches the references to each item and, for methods, whether
+// they implement an interface. Results and errors are indexed like items.
+func (t *Traverser) usage(ctx context.Context, items []lsp.CallHierarchyItem, opts Options) ([][]lsp.Location, []bool, []error) {
+	refs := make([][]lsp.Location, len(items))
+	dispatched := make([]bool, len(items))
+	errs := make([]error, len(items))
+	sem := make(chan struct{}, opts.concurrency())
+
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			refs[i], errs[i] = t.client.References(ctx, item.URI, item.SelectionRange.Start, false)
+			if errs[i] != nil || item.Kind != lsp.SymbolKindMethod {
+				return
+			}
+			// One interface method found settles it, even if others failed
+			abstract, err := t.implementations(ctx, item, true)
+			dispatched[i] = len(abstract) > 0
+			if !dispatched[i] {
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	return refs, dispatched, errs
+}
+
+// isEntrypoint reports whether item starts a reachability search.
+func isEntrypoint(item lsp.CallHierarchyItem, id identity, inTest bool, entry Entrypoints, library bool) bool {
+	if isMain(item) || (item.Kind == lsp.SymbolKindFunction && item.Name == "init") {
+		return true
+	}
+	if entry.Tests && inTest && item.Kind == lsp.SymbolKindFunction {
+		for _, prefix := range []string{"Test", "Benchmark", "Fuzz", "Example"} {
+			if strings.HasPrefix(item.Name, prefix) {
+				return true
+			}
+		}
+	}
+	if library && !inTest {
+		if r, _ := utf8.DecodeRuneInString(item.Name); unicode.IsUpper(r) {
+			return true
+		}
+	}
+	for _, p := range entry.Patterns {
+		for _, name := range []string{item.Name, id.Name, id.ID} {
+			if ok, _ := path.Match(p, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isMain reports whether item is a program's main function.
+func isMain(item lsp.CallHierarchyItem) bool {
+	return item.Kind == lsp.SymbolKindFunction && item.Name == "main"
+}
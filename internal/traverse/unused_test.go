@@ -0,0 +1,145 @@
+package traverse
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/jasonmoo/wildcat/internal/lsp"
+	"github.com/jasonmoo/wildcat/internal/lsptest"
+	"github.com/jasonmoo/wildcat/internal/output"
+)
+
+// unusedGraph builds a program with every kind of dead code:
+//
+//	main -> run, which refers to handler as a value; handler -> serve
+//	tableFn is referenced from a package-level variable
+//	T.String implements fmt.Stringer
+//	TestRun -> helper
+//	old is never referenced, loop only calls itself
+//	deadCaller -> deadCallee
+func unusedGraph(t *testing.T) (*lsptest.Server, []string) {
+	t.Helper()
+	srv := lsptest.NewServer("/ws")
+	fn := func(name, file string, line, end int) *lsptest.Symbol {
+		return srv.Add(lsptest.Symbol{Name: name, Container: "main", Kind: lsp.SymbolKindFunction, File: file, Line: line, EndLine: end})
+	}
+	main := fn("main", "main.go", 1, 3)
+	run := fn("run", "main.go", 5, 9)
+	handler := fn("handler", "main.go", 11, 13)
+	serve := fn("serve", "main.go", 15, 17)
+	fn("old", "main.go", 20, 22)
+	loop := fn("loop", "main.go", 24, 26)
+	deadCaller := fn("deadCaller", "main.go", 28, 30)
+	deadCallee := fn("deadCallee", "main.go", 32, 34)
+	helper := fn("helper", "util.go", 1, 3)
+	tableFn := fn("tableFn", "util.go", 5, 7)
+	testRun := fn("TestRun", "main_test.go", 3, 5)
+	str := srv.Method("T", "String", "t.go", 3)
+	srv.Add(lsptest.Symbol{Name: "Stringer", Container: "fmt", Kind: lsp.SymbolKindInterface, File: "../usr/local/go/src/fmt/print.go", Line: 60, EndLine: 62})
+	abstract := srv.Method("Stringer", "String", "../usr/local/go/src/fmt/print.go", 61)
+
+	srv.Call(main, run, 2)
+	srv.Reference(handler, "main.go", 7)
+	srv.Call(handler, serve, 12)
+	srv.Call(loop, loop, 25)
+	srv.Call(deadCaller, deadCallee, 29)
+	srv.Call(testRun, helper, 4)
+	srv.Reference(tableFn, "util.go", 10)
+	srv.Implements(str, abstract)
+
+	var files []string
+	for _, f := range []string{"main.go", "util.go", "t.go", "main_test.go"} {
+		files = append(files, srv.URI(f))
+	}
+	return srv, files
+}
+
+// reasons maps each unused display name to its reason.
+func reasons(resp *output.UnusedResponse) map[string]string {
+	got := make(map[string]string)
+	for _, r := range resp.Results {
+		got[r.Name] = r.Reason
+	}
+	return got
+}
+
+func TestTraverser_FindUnused(t *testing.T) {
+	srv, files := unusedGraph(t)
+	tr := NewTraverser(srv.Client(t))
+
+	resp, err := tr.FindUnused(t.Context(), files, Entrypoints{Tests: true}, Options{})
+	if err != nil {
+		t.Fatalf("FindUnused: %v", err)
+	}
+
+	want := map[string]string{
+		"ws.old":        UnusedNoReferences,
+		"ws.loop":       UnusedSelfOnly,
+		"ws.deadCaller": UnusedNoReferences,
+		"ws.deadCallee": UnusedUnreachable,
+	}
+	if got := reasons(resp); !maps.Equal(got, want) {
+		t.Errorf("unused = %v, want %v", got, want)
+	}
+	for _, r := range resp.Results {
+		switch r.Name {
+		case "ws.old":
+			if r.File != "/ws/main.go" || r.Line != 20 || r.LineEnd != 22 {
+				t.Errorf("old spans %s:%d-%d, want /ws/main.go:20-22", r.File, r.Line, r.LineEnd)
+			}
+		case "ws.deadCallee":
+			if !slices.Equal(r.ReferencedFrom, []string{"ws.deadCaller"}) {
+				t.Errorf("deadCallee referenced from %v, want [ws.deadCaller]", r.ReferencedFrom)
+			}
+		}
+	}
+	if resp.Summary.Entrypoints != 2 || resp.Summary.Functions != 12 || resp.Summary.Reachable != 8 {
+		t.Errorf("entrypoints, functions, reachable = %d, %d, %d, want 2, 12, 8",
+			resp.Summary.Entrypoints, resp.Summary.Functions, resp.Summary.Reachable)
+	}
+}
+
+func TestTraverser_FindUnusedEntrypoints(t *testing.T) {
+	srv, files := unusedGraph(t)
+	tr := NewTraverser(srv.Client(t))
+
+	// Without tests, code only they use is reported as such
+	resp, err := tr.FindUnused(t.Context(), files, Entrypoints{}, Options{ExcludeTests: true})
+	if err != nil {
+		t.Fatalf("FindUnused: %v", err)
+	}
+	if got := reasons(resp)["ws.helper"]; got != UnusedTestOnly {
+		t.Errorf("helper reason = %q, want %q", got, UnusedTestOnly)
+	}
+
+	// Patterns add entrypoints
+	resp, err = tr.FindUnused(t.Context(), files, Entrypoints{Tests: true, Patterns: []string{"ws.dead*"}}, Options{})
+	if err != nil {
+		t.Fatalf("FindUnused: %v", err)
+	}
+	want := map[string]string{"ws.old": UnusedNoReferences, "ws.loop": UnusedSelfOnly}
+	if got := reasons(resp); !maps.Equal(got, want) {
+		t.Errorf("unused = %v, want %v", got, want)
+	}
+}
+
+func TestTraverser_FindUnusedLibrary(t *testing.T) {
+	srv := lsptest.NewServer("/ws")
+	load := srv.Func("config", "Load", "config.go", 1)
+	parse := srv.Func("config", "parse", "config.go", 3)
+	srv.Func("config", "unused", "config.go", 5)
+	srv.Call(load, parse, 1)
+
+	tr := NewTraverser(srv.Client(t))
+	resp, err := tr.FindUnused(t.Context(), []string{srv.URI("config.go")}, Entrypoints{}, Options{})
+	if err != nil {
+		t.Fatalf("FindUnused: %v", err)
+	}
+	if !resp.Summary.Library {
+		t.Error("library not detected")
+	}
+	if got := reasons(resp); !maps.Equal(got, map[string]string{"ws.unused": UnusedNoReferences}) {
+		t.Errorf("unused = %v, want only ws.unused", got)
+	}
+}